@@ -0,0 +1,130 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "strings"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+
+// Sound channels, for AudioChannel.
+const (
+	ChannelPulse1 = apu.CHANNEL_PULSE1
+	ChannelPulse2 = apu.CHANNEL_PULSE2
+	ChannelTriangle = apu.CHANNEL_TRIANGLE
+	ChannelNoise = apu.CHANNEL_NOISE
+	ChannelDMC = apu.CHANNEL_DMC
+	Channels = apu.CHANNELS
+)
+
+const AudioSampleRate = apu.SampleRate
+
+// Sound made during the last frame, signed 16-bit mono samples. The slice
+// is reused by the next frame.
+func Audio(c *Console) []int16 {
+	return c.cpu.IO.APU.Mixer.Samples
+}
+
+// Fill level of the frontend's audio buffer, 0.0 - 1.0, for the activity
+// graphs.
+func SetAudioFill(c *Console, fill float64) {
+	c.cpu.IO.ACTIVITY.AUDIO_FILL = fill
+}
+
+// Logs the writes to the sound registers, for VGM recording.
+func SetAPULog(c *Console, enable bool) {
+	c.cpu.IO.APU.Log.Enable = enable
+}
+
+// Sound register writes logged during the last frame, and the APU cycle
+// the frame ended on. The slice is reused by the next frame.
+func APULog(c *Console) ([]apu.RegisterWrite, uint64) {
+	return c.cpu.IO.APU.Log.Writes, c.cpu.IO.APU.Cycle
+}
+
+// CPU clock of the region, in Hz.
+func CPUFrequency(c *Console) int {
+	return c.cpu.IO.APU.Timing.CPUFrequency
+}
+
+// Keeps a separate output for every channel, as it would sound alone.
+func SetAudioChannels(c *Console, enable bool) {
+	c.cpu.IO.APU.Mixer.Stems = enable
+}
+
+// Spreads the pops of games that write the DMC level at $4011 directly
+// over a few samples.
+func SetDMCSmoothing(c *Console, enable bool) {
+	c.cpu.IO.APU.Mixer.SmoothDMC = enable
+}
+
+// Resampling of the sound to the output rate: "linear" (the default) or
+// "sinc", which keeps the high notes from aliasing at some CPU cost.
+// Returns false for unknown names.
+func SetAudioQuality(c *Console, name string) bool {
+	for quality, n := range apu.QualityNames {
+		if n == strings.ToLower(name) {
+			c.cpu.IO.APU.Mixer.Quality = quality
+			return true
+		}
+	}
+	return false
+}
+
+// Gain of a cartridge sound chip ("vrc6", "n163", "fds", "5b" or "mmc5")
+// in millibels against an APU pulse at the same volume. Returns false for
+// unknown chips. No chip is emulated yet, so the gain is only kept.
+func SetExpansionMix(name string, millibels int) bool {
+	chip, found := apu.ParseExpansion(strings.ToLower(name))
+	if found {
+		apu.ExpansionMix[chip] = millibels
+	}
+	return found
+}
+
+// Volume of every expansion chip, 0 to 1.
+func SetExpansionVolume(volume float64) {
+	apu.ExpansionVolume = volume
+}
+
+// Sound chip of the cartridge and its gain in millibels, "" when the
+// board has none.
+func ExpansionChip(c *Console) (string, int) {
+	chip, found := apu.ExpansionChip(c.Cart.Header.RomType.Mapper)
+	if found == false {
+		return "", 0
+	}
+	return apu.ExpansionNames[chip], apu.ExpansionMix[chip]
+}
+
+// Enables the APU test mode reads of $4018-$401A, which give the output of
+// the channels. Retail consoles have it disabled.
+func SetAPUTestMode(c *Console, enable bool) {
+	c.cpu.IO.APU_TEST = enable
+}
+
+// Sound of one channel during the last frame, empty unless
+// SetAudioChannels was enabled.
+func AudioChannel(c *Console, channel int) []int16 {
+	return c.cpu.IO.APU.Mixer.StemSamples[channel]
+}
+
+// Short lowercase name of a channel, like "pulse1".
+func ChannelName(channel int) string {
+	return apu.ChannelNames[channel]
+}
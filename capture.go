@@ -0,0 +1,73 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+
+// One frame of PPU register accesses with the state the frame started
+// from. Replaying it runs the PPU alone, so a glitch that survives the
+// replay comes from the PPU emulation and one that goes away when an
+// access is edited out comes from what the game wrote.
+type FrameCapture struct {
+	Start State
+	Accesses []ioports.PPU_ACCESS
+	Dots uint64 // Length of the frame in PPU dots
+}
+
+// Changes an access before it is replayed, or returns false to drop it.
+type CaptureEdit func(a *ioports.PPU_ACCESS) bool
+
+// Runs one frame like RunFrame while recording the PPU accesses.
+func CaptureFrame(c *Console) *FrameCapture {
+	f := new(FrameCapture)
+	f.Start = SaveState(c)
+	start := c.clock.PPU_DOTS
+	c.cpu.IO.PPU_CAPTURE = &ioports.PPU_CAPTURE{START: start}
+	RunFrame(c)
+	f.Accesses = c.cpu.IO.PPU_CAPTURE.ACCESSES
+	f.Dots = c.clock.PPU_DOTS - start
+	c.cpu.IO.PPU_CAPTURE = nil
+	return f
+}
+
+// Puts the console back at the start of the captured frame and runs the
+// PPU alone through it, feeding it the accesses edit lets through. The CPU
+// and the APU stay still. The console is left at the end of the replayed
+// frame, load a state to go on with the game. With the threaded PPU the
+// picture of the replay comes out with the next frame. Refused in hardcore
+// mode, like loading a state.
+func ReplayFrame(c *Console, f *FrameCapture, edit CaptureEdit) error {
+	if err := LoadState(c, f.Start); err != nil {
+		return err
+	}
+	next := 0
+	for dot := uint64(0); dot < f.Dots; dot++ {
+		for next < len(f.Accesses) && f.Accesses[next].DOT <= dot {
+			a := f.Accesses[next]
+			next++
+			if edit == nil || edit(&a) {
+				ioports.ReplayPPUAccess(&c.cpu.IO, a)
+			}
+		}
+		c.clock.PPU_DOTS++
+		ppu.Process(&c.ppu, c.Cart)
+	}
+	return nil
+}
@@ -22,6 +22,11 @@ import "fmt"
 import "os"
 import "log"
 import "bufio"
+import "bytes"
+import "errors"
+import "io"
+import "crypto/sha1"
+import "encoding/hex"
 
 type Header struct {
 	
@@ -39,6 +44,7 @@ type Cartridge struct {
 	Data []byte
 	PRG []byte
 	CHR []byte
+	Hash string // SHA-1 of the ROM without the iNES header
 }
 
 type RomType struct {
@@ -54,8 +60,6 @@ func LoadRom(Filename string) Cartridge {
 	
 	fmt.Println("Loading rom...")
 	
-	var cart Cartridge
-	
 	file, err := os.Open(Filename)
 	if err != nil {
 		log.Fatal(err)
@@ -68,16 +72,64 @@ func LoadRom(Filename string) Cartridge {
 	}
 	
 	var size int64 = info.Size()
-	cart.Data = make([]byte, size)
+	data := make([]byte, size)
 	
 	buffer := bufio.NewReader(file)
-	_, err = buffer.Read(cart.Data)
-	
+	_, err = io.ReadFull(buffer, data)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cart, err := ParseRom(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cart
+}
+
+// With Strict an image shorter than its header says is an error. Otherwise
+// the missing data is filled with $FF and bytes past the end are dropped,
+// which is what bad dumps with padding or a truncated CHR need.
+var Strict bool = false
+
+// Disk images, with the fwNES header or raw. The Disk System is not
+// emulated; when it is, writes to the disk belong in a file next to the
+// image, like the .sav of battery backed cartridges, and not in the image.
+func isDiskImage(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("FDS\x1A")) || bytes.HasPrefix(data, []byte("\x01*NINTENDO-HVC*"))
+}
+
+// Builds a cartridge from an iNES image in memory.
+func ParseRom(data []byte) (Cartridge, error) {
+
+	var cart Cartridge
+	cart.Data = data
+
+	if len(data) < 16 {
+		return cart, errors.New("The file is too small to be an iNES ROM")
+	}
+	if isDiskImage(data) {
+		return cart, errors.New("Famicom Disk System images are not supported")
+	}
+	if string(data[0:4]) != "NES\x1A" {
+		return cart, errors.New("Invalid iNES header")
+	}
+
 LoadHeader(&cart.Header, cart.Data)
+
+	var expected int = trainerSize(&cart) + 16 + int(cart.Header.ROM_SIZE)*16384 + int(cart.Header.VROM_SIZE)*8192
+	if len(data) < expected && Strict {
+		return cart, fmt.Errorf("The ROM has %d bytes but the header expects %d", len(data), expected)
+	}
+	if len(data) != expected && Strict == false {
+		cart.Data = fitRom(&cart, data, expected)
+	}
+
 LoadPRG(&cart)
 LoadCHR(&cart)
+HashRom(&cart)
 
-return cart
+return cart, nil
 }
 
 func LoadHeader(h *Header, b []byte) {
@@ -167,14 +219,51 @@ func TranslateRomType(h *Header) {
 	}
 }
 
+// Pads or truncates the image to the size of its header and tells what
+// was changed.
+func fitRom(c *Cartridge, data []byte, expected int) []byte {
+	if len(data) > expected {
+		fmt.Printf("The ROM has %d bytes more than the header expects, they are ignored\n", len(data) - expected)
+		return data[:expected]
+	}
+
+	prgend := trainerSize(c) + 16 + int(c.Header.ROM_SIZE)*16384
+	if len(data) < prgend {
+		fmt.Printf("PRG-ROM is %d bytes short, filled with $FF\n", prgend - len(data))
+	}
+	chrmissing := expected - len(data)
+	if chrmissing > expected - prgend {
+		chrmissing = expected - prgend
+	}
+	if chrmissing > 0 {
+		fmt.Printf("CHR-ROM is %d bytes short, filled with $FF\n", chrmissing)
+	}
+
+	fitted := make([]byte, expected)
+	copy(fitted, data)
+	for i := len(data); i < expected; i++ {
+		fitted[i] = 0xFF
+	}
+	return fitted
+}
+
+// The 512 bytes trainer sits between the header and the PRG-ROM.
+func trainerSize(c *Cartridge) int {
+	if c.Header.RomType.Trainer {
+		return 512
+	}
+	return 0
+}
+
 func LoadPRG(c *Cartridge) {
 
 	var page16bits = 16384
 	var size int = int(c.Header.ROM_SIZE)*page16bits
+	var offset int = 16 + trainerSize(c)
 
 	c.PRG = make([]byte, size)	
 	for i := 0; i < size; i++ {
-		c.PRG[i] = c.Data[i+16]
+		c.PRG[i] = c.Data[i+offset]
 	}
 }
 
@@ -184,11 +273,32 @@ func LoadCHR(c *Cartridge) {
 	var page16bits = 16384
 	var size int = int(c.Header.VROM_SIZE)*page8bits
 	var prgsize int = int(c.Header.ROM_SIZE)*page16bits
-	var offset int = 16 + prgsize
+	var offset int = 16 + trainerSize(c) + prgsize
 	fmt.Printf("CHR Size: %x\n",size)
 	
 	c.CHR = make([]byte, size)
 	for i := 0; i < size; i++ {
 		c.CHR[i] = c.Data[i+offset]
 	}
-}
\ No newline at end of file
+}
+func HashRom(c *Cartridge) {
+	sum := sha1.Sum(c.Data[16:])
+	c.Hash = hex.EncodeToString(sum[:])
+}
+
+// Bounds-safe PRG-ROM read. Offsets past the end of the image wrap around,
+// like the address lines of a smaller ROM chip.
+func ReadPRG(c *Cartridge, offset uint32) byte {
+	if len(c.PRG) == 0 {
+		return 0
+	}
+	return c.PRG[offset % uint32(len(c.PRG))]
+}
+
+// Bounds-safe CHR-ROM read.
+func ReadCHR(c *Cartridge, offset uint32) byte {
+	if len(c.CHR) == 0 {
+		return 0
+	}
+	return c.CHR[offset % uint32(len(c.CHR))]
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package cartridge
+
+import "testing"
+
+// iNES image of a mapper 0 board with 16 KB of PRG-ROM and 8 KB of CHR-ROM.
+func nromImage() []byte {
+	image := make([]byte, 16 + 16384 + 8192)
+	copy(image, "NES\x1A\x01\x01")
+	return image
+}
+
+// Random headers and images must be refused or loaded, never crash the
+// loader, and a loaded cartridge must have the sizes its header tells.
+func FuzzLoadRom(f *testing.F) {
+	f.Add(nromImage())
+	f.Add([]byte("NES\x1A"))
+	f.Add([]byte("NES\x1A\x02\x00\x04\x00"))
+	f.Add([]byte("FDS\x1A\x01"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cart, err := ParseRom(data)
+		if err != nil {
+			return
+		}
+		if len(cart.PRG) != int(cart.Header.ROM_SIZE)*16384 {
+			t.Fatalf("PRG-ROM has %d bytes, the header tells %d banks", len(cart.PRG), cart.Header.ROM_SIZE)
+		}
+		if len(cart.CHR) != int(cart.Header.VROM_SIZE)*8192 {
+			t.Fatalf("CHR-ROM has %d bytes, the header tells %d banks", len(cart.CHR), cart.Header.VROM_SIZE)
+		}
+		for _, offset := range []uint32{0, 0x3FFF, 0x8000, 1 << 20, 0xFFFFFFFF} {
+			ReadPRG(&cart, offset)
+			ReadCHR(&cart, offset)
+		}
+	})
+}
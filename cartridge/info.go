@@ -0,0 +1,117 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package cartridge
+
+import "crypto/sha1"
+import "encoding/hex"
+import "fmt"
+import "hash/crc32"
+import "io"
+
+// Summary of a ROM image for bug reports and to check dumps.
+
+type RomInfo struct {
+	Format string // "iNES" or "NES 2.0"
+	Mapper int
+	Submapper int // NES 2.0 only
+	Mirroring string
+	Battery bool
+	Trainer bool
+	PRGSize int
+	CHRSize int // 0 for boards with CHR-RAM
+	PRGRAMSize int
+	CHRRAMSize int
+	PRGCRC32 uint32
+	CHRCRC32 uint32
+	PRGSHA1 string
+	CHRSHA1 string
+	SHA1 string // Of the whole ROM without the header, see HashRom
+}
+
+func Info(c *Cartridge) RomInfo {
+	var info RomInfo
+	h := &c.Header
+	info.Format = "iNES"
+	info.Mapper = h.RomType.Mapper
+	info.Battery = h.RomType.SRAM
+	info.Trainer = h.RomType.Trainer
+	info.PRGSize = len(c.PRG)
+	info.CHRSize = len(c.CHR)
+
+	switch {
+		case h.RomType.FourScreenVRAM:
+			info.Mirroring = "four screen"
+		case h.RomType.VerticalMirroring:
+			info.Mirroring = "vertical"
+		default:
+			info.Mirroring = "horizontal"
+	}
+
+	// iNES assumes 8KB of PRG-RAM and 8KB of CHR-RAM when there is no CHR-ROM
+	info.PRGRAMSize = 8192
+	if info.CHRSize == 0 {
+		info.CHRRAMSize = 8192
+	}
+	if h.ROM_TYPE2 & 0x0C == 0x08 {
+		info.Format = "NES 2.0"
+		info.Mapper |= int(h.ROM_BLANK[0] & 0x0F) << 8
+		info.Submapper = int(h.ROM_BLANK[0] >> 4)
+		info.PRGRAMSize = shiftSize(h.ROM_BLANK[2] & 0x0F) + shiftSize(h.ROM_BLANK[2] >> 4)
+		info.CHRRAMSize = shiftSize(h.ROM_BLANK[3] & 0x0F) + shiftSize(h.ROM_BLANK[3] >> 4)
+	}
+
+	info.PRGCRC32 = crc32.ChecksumIEEE(c.PRG)
+	info.CHRCRC32 = crc32.ChecksumIEEE(c.CHR)
+	prg := sha1.Sum(c.PRG)
+	chr := sha1.Sum(c.CHR)
+	info.PRGSHA1 = hex.EncodeToString(prg[:])
+	info.CHRSHA1 = hex.EncodeToString(chr[:])
+	info.SHA1 = c.Hash
+	return info
+}
+
+// NES 2.0 RAM sizes are 64 << n bytes, 0 means none.
+func shiftSize(n byte) int {
+	if n == 0 {
+		return 0
+	}
+	return 64 << n
+}
+
+func WriteInfo(w io.Writer, c *Cartridge, supported bool) {
+	info := Info(c)
+	support := "yes"
+	if supported == false {
+		support = "no"
+	}
+	fmt.Fprintf(w, "Format:     %s\n", info.Format)
+	fmt.Fprintf(w, "Mapper:     %d, submapper %d, supported: %s\n", info.Mapper, info.Submapper, support)
+	fmt.Fprintf(w, "Mirroring:  %s\n", info.Mirroring)
+	fmt.Fprintf(w, "Battery:    %t\n", info.Battery)
+	fmt.Fprintf(w, "Trainer:    %t\n", info.Trainer)
+	fmt.Fprintf(w, "PRG-ROM:    %d KB, CRC32 %08X, SHA-1 %s\n", info.PRGSize / 1024, info.PRGCRC32, info.PRGSHA1)
+	if info.CHRSize > 0 {
+		fmt.Fprintf(w, "CHR-ROM:    %d KB, CRC32 %08X, SHA-1 %s\n", info.CHRSize / 1024, info.CHRCRC32, info.CHRSHA1)
+	} else {
+		fmt.Fprintf(w, "CHR-ROM:    none\n")
+	}
+	fmt.Fprintf(w, "PRG-RAM:    %d KB\n", info.PRGRAMSize / 1024)
+	fmt.Fprintf(w, "CHR-RAM:    %d KB\n", info.CHRRAMSize / 1024)
+	fmt.Fprintf(w, "ROM SHA-1:  %s\n", info.SHA1)
+}
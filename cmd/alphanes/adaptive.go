@@ -0,0 +1,93 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "fmt"
+import "time"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/video"
+
+// Adaptive quality: when the host cannot keep the frame rate the
+// performance preset is applied, and the options in use before come back
+// once the frames leave enough time to spare.
+//
+// The checks run once per second. Falling back takes adaptiveSlow slow
+// seconds in a row. Coming back takes adaptiveSpare seconds in a row where
+// at most half of the time was spent emulating, and that wait doubles after
+// each fallback so a host on the edge does not switch back and forth.
+type Adaptive struct {
+	Enable bool
+	Reduced bool
+	Saved alphanes.Preset // Options in use before the fallback
+	Slow int // Slow seconds in a row
+	Spare int // Seconds in a row with time to spare
+	Wait int // Seconds with time to spare needed to come back
+	Slept time.Duration // Time paceFrame slept in the current second
+}
+
+const adaptiveSlow = 3
+const adaptiveSpare = 10
+
+var adaptive = Adaptive{Wait: adaptiveSpare}
+
+// Called once per second with the frame rate it reached and its length.
+func adaptQuality(fps float64, second time.Duration) {
+	slept := adaptive.Slept
+	adaptive.Slept = 0
+	if adaptive.Enable == false {
+		return
+	}
+
+	if adaptive.Reduced == false {
+		if fps < 0.95 * alphanes.FrameRate(Console) {
+			adaptive.Slow++
+		} else {
+			adaptive.Slow = 0
+		}
+		if adaptive.Slow >= adaptiveSlow {
+			adaptive.Slow = 0
+			adaptive.Saved = alphanes.CurrentPreset(Console)
+			if adaptive.Saved.Name == "performance" {
+				return
+			}
+			adaptive.Reduced = true
+			performance, _ := alphanes.ParsePreset("performance")
+			alphanes.ApplyPreset(Console, performance)
+			video.ReducedQuality = true
+			fmt.Println(locale.T("Adaptive quality: %.1f fps, switching to the performance options", fps))
+		}
+		return
+	}
+
+	if slept * 2 >= second {
+		adaptive.Spare++
+	} else {
+		adaptive.Spare = 0
+	}
+	if adaptive.Spare >= adaptive.Wait {
+		adaptive.Spare = 0
+		adaptive.Wait *= 2
+		adaptive.Reduced = false
+		alphanes.ApplyPreset(Console, adaptive.Saved)
+		video.ReducedQuality = false
+		fmt.Println(locale.T("Adaptive quality: back to the %s options", adaptive.Saved.Name))
+	}
+}
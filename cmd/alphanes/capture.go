@@ -0,0 +1,113 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "fmt"
+import "image"
+import "image/color"
+import "image/png"
+import "os"
+import "strconv"
+import "strings"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+
+// F12 captures the PPU accesses of the next frame and replays them through
+// the PPU alone with the edits of --replay-edit. The picture of the frame
+// and the one of the replay are written next to each other, then the game
+// goes on from where the frame ended.
+
+const capturePicture = "alphanes-capture.png"
+const replayPicture = "alphanes-replay.png"
+
+func captureFrame() {
+	if alphanes.Hardcore(Console) {
+		fmt.Println(locale.T("Frame captures are disabled in hardcore mode"))
+		return
+	}
+	f := alphanes.CaptureFrame(Console)
+	live := alphanes.SaveState(Console)
+	err := writePicture(capturePicture)
+	if err == nil {
+		alphanes.ReplayFrame(Console, f, Alphanes.ReplayEdit)
+		err = writePicture(replayPicture)
+	}
+	alphanes.LoadState(Console, live)
+	if err != nil {
+		fmt.Println(locale.T("Cannot write the frame capture: %v", err))
+		return
+	}
+	fmt.Println(locale.T("%d PPU accesses captured, the frame is in %s and its replay in %s",
+		len(f.Accesses), capturePicture, replayPicture))
+}
+
+func writePicture(name string) error {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 240))
+	screen := alphanes.Screen(Console)
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 256; x++ {
+			r, g, b := alphanes.PaletteColor(screen[x + y*256])
+			img.Set(x, y, color.RGBA{r, g, b, 255})
+		}
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+// Comma separated edits: zero-scroll writes 0 for every $2005 write,
+// no-dma drops the $4014 DMAs and drop=2001 drops the accesses to a
+// register.
+func parseReplayEdit(list string) alphanes.CaptureEdit {
+	zeroScroll := false
+	dropped := make(map[uint16]bool)
+	for _, edit := range strings.Split(list, ",") {
+		switch {
+			case edit == "zero-scroll":
+				zeroScroll = true
+			case edit == "no-dma":
+				dropped[0x4014] = true
+			case strings.HasPrefix(edit, "drop="):
+				addr, err := strconv.ParseUint(strings.TrimPrefix(edit, "drop="), 16, 16)
+				if err != nil {
+					fmt.Println(locale.T("Invalid --replay-edit register %s", edit))
+					os.Exit(1)
+				}
+				dropped[uint16(addr)] = true
+			default:
+				fmt.Println(locale.T("Unknown --replay-edit %s, use zero-scroll, no-dma or drop=register", edit))
+				os.Exit(1)
+		}
+	}
+
+	return func(a *ioports.PPU_ACCESS) bool {
+		if dropped[a.ADDR] {
+			return false
+		}
+		if zeroScroll && a.ADDR == 0x2005 && a.WRITE {
+			a.VALUE = 0
+		}
+		return true
+	}
+}
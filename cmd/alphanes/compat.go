@@ -0,0 +1,86 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "encoding/json"
+import "fmt"
+import "os"
+import "path/filepath"
+import "time"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/settings"
+
+// Every session, and every verify run, appends its compatibility report
+// to a local database of JSON lines: by default next to the per-game
+// settings, or the file of --compat-report; --compat-report off keeps
+// none.
+
+func compatReportFile() string {
+	if file, found := optionValue("--compat-report"); found {
+		if file == "off" {
+			return ""
+		}
+		return file
+	}
+	return settings.CompatDatabaseFile()
+}
+
+// Report of the session that is ending, with the stall the watchdog saw
+// when the CPU did not stop by itself.
+func sessionReport(frames int) alphanes.CompatReport {
+	r := alphanes.CompatibilityReport(Console)
+	r.Frames = frames
+	if r.Crash == "" {
+		r.Crash = watchdogStall()
+	}
+	return r
+}
+
+func writeCompatReport(r alphanes.CompatReport) {
+	file := compatReportFile()
+	if file == "" {
+		return
+	}
+	r.Rom = filepath.Base(os.Args[1])
+	r.Time = time.Now().Format(time.RFC3339)
+	line, err := json.Marshal(r)
+	if err == nil {
+		err = appendLine(file, line)
+	}
+	if err != nil {
+		fmt.Println(locale.T("Cannot write the compatibility report: %v", err))
+	}
+}
+
+func appendLine(file string, line []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_APPEND | os.O_CREATE | os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
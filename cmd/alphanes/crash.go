@@ -0,0 +1,73 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "encoding/json"
+import "fmt"
+import "os"
+import "path/filepath"
+import "runtime"
+import "time"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+
+// A panic, or a CPU that stopped on an opcode it cannot run, writes
+// alphanes-crash-<time>.json in the working directory with the state of
+// the core and the Go stack, to be attached to the bug report.
+
+// Deferred by main: dumps the panic and lets it go on.
+func catchCrash() {
+	if r := recover(); r != nil {
+		writeCrashDump(fmt.Sprint(r))
+		panic(r)
+	}
+}
+
+// Dumps the CPU fault of the session, if there was one.
+func checkCrash() {
+	if Console == nil {
+		return
+	}
+	if crash := alphanes.CompatibilityReport(Console).Crash; crash != "" {
+		writeCrashDump(crash)
+	}
+}
+
+func writeCrashDump(reason string) {
+	d := alphanes.CrashDumpOf(Console, reason)
+	now := time.Now()
+	d.Time = now.Format(time.RFC3339)
+	if len(os.Args) > 1 {
+		d.Rom = filepath.Base(os.Args[1])
+	}
+	stack := make([]byte, 64 * 1024)
+	d.Stack = string(stack[:runtime.Stack(stack, false)])
+
+	file := fmt.Sprintf("alphanes-crash-%s.json", now.Format("20060102-150405"))
+	data, err := json.MarshalIndent(d, "", "\t")
+	if err == nil {
+		err = os.WriteFile(file, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, locale.T("Cannot write the crash dump: %v", err))
+		return
+	}
+	fmt.Fprintln(os.Stderr, locale.T("Crash dump written to %s, please attach it to the bug report", file))
+}
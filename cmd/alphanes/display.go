@@ -0,0 +1,59 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "fmt"
+import "os"
+import "strconv"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/video"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/settings"
+
+// Fullscreen, monitor and the window geometry of the last session.
+func selectDisplay() {
+	video.Output.Fullscreen = hasOption("--fullscreen")
+	if value, found := optionValue("--display"); found {
+		if value == "list" {
+			video.ListDisplays()
+			os.Exit(0)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			fmt.Println(locale.T("Invalid display: %s", value))
+			os.Exit(1)
+		}
+		video.Output.Display = n
+	}
+	w := settings.LoadWindow()
+	if w.Found {
+		video.Output.Window = video.WindowGeometry{X: w.X, Y: w.Y, W: w.W, H: w.H}
+	}
+}
+
+func saveWindow() {
+	if video.Output.Driver == "null" || video.Output.Window.W <= 0 {
+		return
+	}
+	g := video.Output.Window
+	err := settings.SaveWindow(settings.Window{Found: true, X: g.X, Y: g.Y, W: g.W, H: g.H})
+	if err != nil {
+		fmt.Println(locale.T("Cannot save the window position: %v", err))
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "fmt"
+import "os"
+import "strconv"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/video"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/settings"
+
+func loadGamepads() {
+	video.GamepadProfiles = settings.LoadGamepads()
+	video.BackgroundInput = hasOption("--background-input")
+
+	if value, found := optionValue("--deadzone"); found {
+		percent, err := strconv.Atoi(value)
+		if err != nil || percent < 0 || percent > 99 {
+			fmt.Println(locale.T("Invalid dead zone: %s (use 0 to 99)", value))
+			os.Exit(1)
+		}
+		video.Input.DeadZone = float64(percent) / 100
+	}
+	if mode, found := optionValue("--stick"); found && video.SetStickMode(mode) == false {
+		os.Exit(1)
+	}
+	if hasOption("--four-way") {
+		video.Input.FourWay = true
+	}
+	mode, found := optionValue("--opposing")
+	if found == false {
+		mode, found = Alphanes.Settings.Opposing, Alphanes.Settings.Opposing != ""
+	}
+	if found && video.SetOpposing(mode) == false {
+		os.Exit(1)
+	}
+
+	// The Zapper is not emulated, so the crosshair is asked for by hand
+	if style, found := optionValue("--crosshair"); found && video.SetCrosshair(style) == false {
+		os.Exit(1)
+	}
+	if color, found := optionValue("--crosshair-color"); found && video.SetCrosshairColor(color) == false {
+		os.Exit(1)
+	}
+	video.Crosshair.HideCursor = hasOption("--hide-cursor")
+}
+
+// Writes the mappings back when a new gamepad was connected, so it can be
+// edited and is used the next time the device is seen.
+func saveGamepads() {
+	if video.GamepadProfilesChanged == false {
+		return
+	}
+	if err := settings.SaveGamepads(video.GamepadProfiles); err != nil {
+		fmt.Println(locale.T("Cannot save the gamepad mappings: %v", err))
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "fmt"
+import "os"
+import "strconv"
+import "strings"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/journal"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/movie"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/video"
+
+// Session journal: records the input of every frame with periodic
+// keyframes, resumes the last session and rewinds through its history.
+
+// Opens the journal of the ROM, and with resume puts the console where the
+// last session ended.
+func openJournal(romfile string, resume bool) {
+	path := journal.JournalPath(romfile)
+	j, err := journal.OpenJournal(path, Cart.Hash)
+	if err != nil {
+		fmt.Println(locale.T("Cannot open the session journal: %v", err))
+		return
+	}
+	Alphanes.Journal = j
+
+	if resume && journal.FrameCount(j) > 0 {
+		fmt.Println(locale.T("Resuming the session journal at frame %d", journal.FrameCount(j)))
+		if seekJournal(journal.FrameCount(j)) == false {
+			closeJournal()
+		}
+	}
+}
+
+func closeJournal() {
+	if Alphanes.Journal == nil {
+		return
+	}
+	if err := journal.CloseJournal(Alphanes.Journal); err != nil {
+		fmt.Println(locale.T("Cannot write the session journal: %v", err))
+	}
+	Alphanes.Journal = nil
+}
+
+func currentFrame() movie.Frame {
+	var f movie.Frame
+	f.Buttons[0] = byte(alphanes.GetInput(Console, 0))
+	f.Buttons[1] = byte(alphanes.GetInput(Console, 1))
+	if alphanes.Microphone(Console) {
+		f.Flags |= movie.FLAG_MICROPHONE
+	}
+	return f
+}
+
+func applyFrame(f movie.Frame) {
+	alphanes.SetInput(Console, 0, alphanes.Input(f.Buttons[0]))
+	alphanes.SetInput(Console, 1, alphanes.Input(f.Buttons[1]))
+	alphanes.SetMicrophone(Console, f.Flags & movie.FLAG_MICROPHONE != 0)
+	alphanes.ReplayPolls(Console, f.Polls)
+}
+
+// Records the frame about to run.
+func journalFrame() {
+	j := Alphanes.Journal
+	if j == nil {
+		return
+	}
+	if journal.NeedsKeyframe(j) {
+		journalKeyframe()
+		if Alphanes.Journal == nil {
+			return
+		}
+	}
+	if err := journal.Record(j, currentFrame()); err != nil {
+		fmt.Println(locale.T("Session journal stopped: %v", err))
+		closeJournal()
+	}
+}
+
+// Adds a keyframe now. Used on the regular interval and whenever the
+// console state jumps, e.g. after a reset or loading a state.
+func journalKeyframe() {
+	if Alphanes.Journal == nil {
+		return
+	}
+	Alphanes.StateBuffer = alphanes.WriteState(Console, Alphanes.StateBuffer[:0])
+	if err := journal.AddKeyframe(Alphanes.Journal, Alphanes.StateBuffer); err != nil {
+		fmt.Println(locale.T("Session journal stopped: %v", err))
+		closeJournal()
+	}
+}
+
+// Puts the console at the start of frame: loads the keyframe before it
+// and replays the recorded input without presenting the frames.
+func seekJournal(frame int) bool {
+	j := Alphanes.Journal
+	key, state, err := journal.KeyframeBefore(j, frame)
+	if err == nil {
+		err = alphanes.ReadState(Console, state)
+	}
+	if err != nil {
+		fmt.Println(locale.T("Cannot seek the session journal: %v", err))
+		return false
+	}
+
+	driver := video.Output.Driver
+	video.Output.Driver = "null"
+	for f := key.Frame; f < frame; f++ {
+		applyFrame(j.Frames[f])
+		alphanes.RunFrame(Console)
+	}
+	video.Output.Driver = driver
+	return true
+}
+
+// Goes back the given number of frames. The history after that point is
+// dropped and play continues from there.
+func rewindJournal(frames int) {
+	j := Alphanes.Journal
+	if j == nil {
+		fmt.Println(locale.T("Rewinding needs --journal"))
+		return
+	}
+	target := journal.FrameCount(j) - frames
+	if target < 0 {
+		target = 0
+	}
+	if seekJournal(target) == false {
+		return
+	}
+	if Alphanes.Movie != nil {
+		rerecordMovie(len(Alphanes.Movie.Frames) - (journal.FrameCount(j) - target))
+	}
+	if err := journal.Truncate(j, target); err != nil {
+		fmt.Println(locale.T("Session journal stopped: %v", err))
+		closeJournal()
+	}
+}
+
+// Writes the frames from-to of the journal as a movie that starts with a
+// savestate of the first frame.
+func exportMovie(file string, segment string) {
+	if Alphanes.Journal == nil {
+		openJournal(os.Args[1], false)
+	}
+	j := Alphanes.Journal
+	if j == nil {
+		os.Exit(1)
+	}
+
+	from, to := 0, journal.FrameCount(j)
+	if segment != "" {
+		bounds := strings.SplitN(segment, "-", 2)
+		var err1, err2 error = nil, nil
+		from, err1 = strconv.Atoi(bounds[0])
+		if len(bounds) == 2 {
+			to, err2 = strconv.Atoi(bounds[1])
+		}
+		if err1 != nil || err2 != nil || from < 0 || to > journal.FrameCount(j) || from > to {
+			fmt.Println(locale.T("Invalid --segment, the journal has frames 0-%d", journal.FrameCount(j)))
+			os.Exit(1)
+		}
+	}
+
+	if seekJournal(from) == false {
+		os.Exit(1)
+	}
+	var m movie.Movie
+	m.Hash = Cart.Hash
+	m.Start = alphanes.WriteState(Console, nil)
+	m.Frames = j.Frames[from:to]
+	if err := movie.WriteMovie(file, &m); err != nil {
+		fmt.Println(locale.T("Cannot write the movie: %v", err))
+		os.Exit(1)
+	}
+	fmt.Println(locale.T("Frames %d-%d written to %s", from, to, file))
+	reportAutofire(&m)
+	closeJournal()
+}
@@ -0,0 +1,692 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/video"
+import "strings"
+import "strconv"
+import "math"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/settings"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/audio"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/audiofile"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/sram"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/remote"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/stream"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/timing"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/journal"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/movie"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/perf"
+import "fmt"
+import "os"
+import "bytes"
+import "time"
+
+	 
+	 type Emulator struct {
+	 	Running bool
+	 	Settings settings.GameSettings
+	 	Audio audio.Audio
+	 	SavePath string // Battery save file, "" if the cartridge has no battery
+	 	Saved []byte // SRAM contents last written to SavePath
+	 	LastSave time.Time
+	 	Frames int
+	 	FPS float64
+	 	Remote *remote.Server // HTTP status and control, nil if disabled
+	 	SlotNumber int // Savestate slot of F10 and F11, chosen with the number keys
+	 	Slots [slotCount]*alphanes.State // Savestates taken in this session
+	 	SlotFrames [slotCount]int // Length of the movie when each savestate was taken
+	 	Undo *alphanes.State // Console before the last savestate load, nil if none
+	 	ConfirmSave bool // F10 over a used slot needs a second press
+	 	OverwriteAsked time.Time // When F10 was refused, see confirmSave
+	 	Stream *stream.Stream // MJPEG frame output, nil if disabled
+	 	Perf *perf.Counters // expvar counters, nil if disabled
+	 	Timing timing.FrameTiming
+	 	TimingStats timing.Stats // Updated once per second
+	 	Journal *journal.Journal // Session journal, nil if disabled
+	 	StateBuffer []byte // Reused for the journal keyframes
+	 	Wav *audiofile.WavFile // Sound recording, nil if disabled
+	 	Stems []*audiofile.WavFile // One recording per channel
+	 	Vgm *audiofile.VgmFile // APU register log, nil if disabled
+	 	Watch *Watch // ROM reload on change, nil if disabled
+	 	NextFrame time.Time // When the next frame is due
+	 	FrameCarry float64 // Nanoseconds of frame time lost to rounding
+	 	Movie *movie.Movie // Movie being recorded, nil if disabled
+	 	MoviePath string
+	 	MovieCounters alphanes.FrameCounters // Counters when the movie started
+	 	ReplayEdit alphanes.CaptureEdit // Applied to the frames captured with F12
+	 }
+
+	 var Cart cartridge.Cartridge
+	 var Console *alphanes.Console
+	 var Debug debug.Debug
+	 var Alphanes Emulator
+    
+    func main() {
+
+		defer catchCrash()
+		locale.SetLanguage(locale.FromEnvironment())
+		command := parseSubcommand()
+		if lang, found := optionValue("--lang"); found {
+			if locale.SetLanguage(lang) == false {
+				fmt.Println(locale.T("Unknown language %s, use en or pt-BR", lang))
+				os.Exit(1)
+			}
+		}
+		fmt.Println(locale.T("Loading %s", os.Args[1]))
+		cartridge.Strict = hasOption("--strict")
+		Cart = cartridge.LoadRom(os.Args[1])
+		if command == "disasm" {
+			runDisasm()
+			return
+		}
+		if command == "statediff" {
+			runStateDiff()
+			return
+		}
+		if hasOption("--info") || command == "info" {
+			fmt.Println()
+			cartridge.WriteInfo(os.Stdout, &Cart, mapper.Supported(Cart.Header.RomType.Mapper))
+			return
+		}
+		Alphanes.Settings = settings.LoadGameSettings(Cart.Hash)
+		if Alphanes.Settings.Found {
+			fmt.Println(locale.T("Per-game settings loaded from %s", settings.GameSettingsFile(Cart.Hash)))
+		}
+	
+		if (len(os.Args) >= 3) && strings.Contains( string(os.Args[2]), ".debug") {
+			fmt.Println(locale.T("Debug mode is on"))
+			Debug = debug.OpenDebugFile(os.Args[2])
+		} else {
+			Debug.Enable = false
+			fmt.Println(locale.T("Debug mode is off"))
+		}
+
+		// Homebrew developer profile: a bigger window, the register access
+		// log and the ROM reloaded when the assembler writes it
+		dev := hasOption("--dev")
+		if dev {
+			video.Output.Scale = 3
+		}
+
+		if driver, found := optionValue("--video"); found {
+			video.Output.Driver = driver
+		}
+		audiodriver, found := optionValue("--audio")
+		if found == false {
+			audiodriver = "sdl"
+		}
+		benchframes, bench := optionValue("--bench")
+		moviefile, playback := optionValue("--play-movie")
+		verify := command == "verify"
+		if bench || playback || verify {
+			video.Output.Driver = "null"
+			audiodriver = "null"
+		}
+		Alphanes.Audio = audio.OpenAudio(audiodriver)
+
+		if shader, found := optionValue("--shader"); found {
+			video.Output.Shader = shader
+		}
+		if hasOption("--scanlines") {
+			video.Output.Scanlines = true
+		}
+		if degrees, found := optionValue("--rotate"); found {
+			video.SetRotation(degrees)
+		}
+		if hasOption("--mirror") {
+			video.Output.Mirror = true
+		}
+		selectDisplay()
+		loadGamepads()
+		if mode, found := optionValue("--blend"); found {
+			video.SetBlend(mode)
+		}
+		if hasOption("--activity") {
+			video.ShowActivity = true
+		}
+		if hasOption("--inputs") {
+			video.ShowInputDisplay = true
+		}
+		if hasOption("--sources") {
+			video.ShowSources = true
+		}
+		if hasOption("--nametables") {
+			video.ShowNametables = true
+		}
+		if hasOption("--autopause") {
+			video.AutoPause = true
+		}
+
+		if mapper.Supported(Cart.Header.RomType.Mapper) == false {
+			fmt.Println(locale.T("Mapper %d is not supported", Cart.Header.RomType.Mapper))
+			writeCompatReport(alphanes.CartridgeReport(&Cart))
+			os.Exit(1)
+		}
+		Console = alphanes.StartConsole(&Cart)
+		video.Start()
+
+		selectRegion()
+		selectRevision()
+		selectPreset()
+		selectExpansionMix()
+		selectGameOptions()
+		adaptive.Enable = hasOption("--adaptive")
+		Alphanes.ConfirmSave = hasOption("--confirm-save")
+		if value, found := optionValue("--oam-decay"); found {
+			ms, err := strconv.ParseFloat(value, 64)
+			if err != nil || ms < 0 {
+				fmt.Println(locale.T("Invalid --oam-decay, use a time in milliseconds"))
+				os.Exit(1)
+			}
+			alphanes.SetOAMDecay(Console, time.Duration(ms * float64(time.Millisecond)))
+		}
+		if quality, found := optionValue("--audio-quality"); found {
+			if alphanes.SetAudioQuality(Console, quality) == false {
+				fmt.Println(locale.T("Unknown audio quality %s, use linear or sinc", quality))
+				os.Exit(1)
+			}
+		}
+		if hasOption("--smooth-dmc") {
+			alphanes.SetDMCSmoothing(Console, true)
+		}
+		if hasOption("--apu-test") {
+			alphanes.SetAPUTestMode(Console, true)
+		}
+		if edits, found := optionValue("--replay-edit"); found {
+			Alphanes.ReplayEdit = parseReplayEdit(edits)
+		}
+		if hasOption("--oam-extension") {
+			alphanes.EnableOAMExtension(Console)
+		}
+		if hasOption("--hardcore") {
+			alphanes.SetHardcore(Console, true)
+			fmt.Println(locale.T("Hardcore mode: loading savestates, rewinding, movie playback and frame captures are disabled"))
+		}
+		if hasOption("--no-warmup") {
+			alphanes.SetPPUWarmUp(Console, false)
+		}
+		if hasOption("--threaded-ppu") {
+			ppu.ThreadedRender = true
+		}
+		if hasOption("--sprite-limit") {
+			ppu.SpriteLimit = true
+		}
+
+		Debug.Verbose = true
+		alphanes.SetDebugger(Console, Debug)
+
+                if len(os.Args) >= 3 && strings.Contains(os.Args[2], ".ppu") {
+                    dump := debug.OpenPPUDumpFile(os.Args[2])
+                    dump.Enable = true
+                    alphanes.SetPPUDump(Console, dump)
+                }
+
+		if filter, found := optionValue("--iolog-filter"); found {
+			filters, err := debug.ParseAccessFilters(filter)
+			if err != nil {
+				fmt.Println(locale.T("Invalid --iolog-filter: %v", err))
+				os.Exit(1)
+			}
+			alphanes.SetAccessLog(Console, debug.StartAccessLog(65536, filters))
+		} else if hasOption("--iolog") || dev {
+			alphanes.SetAccessLog(Console, debug.StartAccessLog(65536, nil))
+		}
+
+		if hasOption("--touch") {
+			video.EnableVirtualPad()
+		}
+		if level, found := optionValue("--mic"); found {
+			threshold, err := strconv.ParseFloat(level, 64)
+			if err != nil || threshold <= 0 || threshold > 1 {
+				fmt.Println(locale.T("Invalid --mic threshold, use a level between 0 and 1"))
+				os.Exit(1)
+			}
+			video.EnableMicrophone(threshold)
+		}
+		if Alphanes.Settings.Palette != "" {
+			ppu.LoadPalette(Alphanes.Settings.Palette)
+		}
+		
+		
+		if Cart.Header.RomType.SRAM && verify == false {
+			loadBattery(os.Args[1])
+		}
+		if addr, found := optionValue("--http"); found {
+			Alphanes.Remote = remote.StartServer(addr, alphanes.PaletteColor)
+		}
+		if addr, found := optionValue("--stream"); found {
+			Alphanes.Stream = stream.StartStream(addr, alphanes.PaletteColor)
+		}
+		if addr, found := optionValue("--pprof"); found {
+			Alphanes.Perf = perf.StartServer(addr)
+		}
+		
+		if bench {
+			runBench(benchframes)
+			return
+		}
+		if verify {
+			runVerify()
+			return
+		}
+		if playback {
+			if file, found := optionValue("--wav"); found {
+				startRecording(file, hasOption("--wav-stems"))
+			}
+			if file, found := optionValue("--vgm"); found {
+				startVgm(file)
+			}
+			playMovie(moviefile)
+			stopRecording()
+			stopVgm()
+			return
+		}
+		if file, found := optionValue("--export-movie"); found {
+			segment, _ := optionValue("--segment")
+			exportMovie(file, segment)
+			return
+		}
+		if hasOption("--journal") {
+			openJournal(os.Args[1], true)
+		}
+		if file, found := optionValue("--wav"); found {
+			startRecording(file, hasOption("--wav-stems"))
+		}
+		if file, found := optionValue("--vgm"); found {
+			startVgm(file)
+		}
+		if file, found := optionValue("--record-movie"); found {
+			startMovie(file)
+		}
+		if hasOption("--watch") || dev {
+			keep, _ := optionValue("--watch-keep")
+			startWatch(os.Args[1], keep)
+		}
+
+		timeout := defaultWatchdogTimeout
+		if value, found := optionValue("--watchdog"); found {
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil || seconds < 0 {
+				fmt.Println(locale.T("Invalid --watchdog, use a number of seconds or 0 to disable it"))
+				os.Exit(1)
+			}
+			timeout = time.Duration(seconds * float64(time.Second))
+		}
+		if timeout > 0 {
+			startWatchdog(timeout)
+		}
+
+		Alphanes.Running = true		
+		emulate()
+		checkCrash()
+		writeCompatReport(sessionReport(Alphanes.Frames))
+		saveWindow()
+		saveGamepads()
+		saveBattery()
+		closeJournal()
+		stopMovie()
+		stopRecording()
+		stopVgm()
+	
+		
+		
+}
+
+// The region comes from --region, then from the per-game settings, then
+// from the ROM database, the ROM header or the file name, NTSC when
+// nothing tells.
+func selectRegion() {
+	name, found := optionValue("--region")
+	source := "--region"
+	if found == false && Alphanes.Settings.Region != "" {
+		name, found = Alphanes.Settings.Region, true
+		source = locale.T("the game settings")
+	}
+	if found {
+		region, valid := alphanes.ParseRegion(name)
+		if valid == false {
+			fmt.Println(locale.T("Unknown region %s, use ntsc, pal or dendy", name))
+			os.Exit(1)
+		}
+		alphanes.SetRegion(Console, region)
+		fmt.Println(locale.T("Region: %s from %s", alphanes.RegionName(region), source))
+		return
+	}
+	err := alphanes.LoadRegionDatabase(settings.RegionDatabaseFile())
+	if err != nil && os.IsNotExist(err) == false {
+		fmt.Println(locale.T("Region database: %v", err))
+	}
+	if region, detected := alphanes.DetectRegion(&Cart, os.Args[1]); detected {
+		alphanes.SetRegion(Console, region)
+		fmt.Println(locale.T("Region: %s from the ROM", alphanes.RegionName(region)))
+	}
+}
+
+// The console revision comes from --revision, then from the per-game
+// settings, the front-loader NES when neither tells. Games that use the
+// microphone need a Famicom.
+func selectRevision() {
+	name, found := optionValue("--revision")
+	source := "--revision"
+	if found == false && Alphanes.Settings.Revision != "" {
+		name, found = Alphanes.Settings.Revision, true
+		source = locale.T("the game settings")
+	}
+	if found == false {
+		return
+	}
+	revision, valid := alphanes.ParseRevision(name)
+	if valid == false {
+		fmt.Println(locale.T("Unknown revision %s, use front-loader, top-loader, famicom, famicom-early or av-famicom", name))
+		os.Exit(1)
+	}
+	alphanes.SetRevision(Console, revision)
+	fmt.Println(locale.T("Console: %s (%s) from %s", alphanes.RevisionName(revision), alphanes.RevisionChip(revision), source))
+}
+
+// The accuracy preset comes from --preset, then from the per-game
+// settings, balanced when neither tells.
+func selectPreset() {
+	name, found := optionValue("--preset")
+	if found == false {
+		name, found = Alphanes.Settings.Preset, Alphanes.Settings.Preset != ""
+	}
+	if found == false {
+		return
+	}
+	preset, valid := alphanes.ParsePreset(name)
+	if valid == false {
+		fmt.Println(locale.T("Unknown preset %s, use performance, balanced or accuracy", name))
+		os.Exit(1)
+	}
+	alphanes.ApplyPreset(Console, preset)
+	fmt.Println(locale.T("Preset: %s", preset.Name))
+}
+
+// The per-game settings can turn the threaded PPU on with fastppu=true and
+// move the keyboard buttons with button.a=X and the like.
+func selectGameOptions() {
+	if Alphanes.Settings.FastPPU {
+		ppu.ThreadedRender = true
+	}
+	for button, key := range Alphanes.Settings.Buttons {
+		if video.SetKeyboardButton(button, key) == false {
+			fmt.Println(locale.T("Unknown button.%s=%s, use a, b, select, start, up, down, left or right and an SDL key name", button, key))
+		}
+	}
+}
+
+// Expansion chips are mixed at their default gains unless the per-game
+// settings give expansion.chip=millibels.
+func selectExpansionMix() {
+	alphanes.SetExpansionVolume(Alphanes.Settings.ExpansionVolume)
+	for chip, mb := range Alphanes.Settings.ExpansionMix {
+		if alphanes.SetExpansionMix(chip, mb) == false {
+			fmt.Println(locale.T("Unknown expansion chip %s, use vrc6, n163, fds, 5b or mmc5", chip))
+		}
+	}
+	if chip, mb := alphanes.ExpansionChip(Console); chip != "" {
+		fmt.Println(locale.T("Expansion audio: %s at %d mB (the chip is not emulated)", chip, mb))
+	}
+}
+
+// The sound card clock never runs exactly as fast as the system clock. To
+// keep the audio queue from slowly running dry or overflowing, the frames
+// are stretched or shortened by up to paceSkew while the queue is away
+// from paceFill.
+const paceSkew = 0.005
+const paceFill = 0.3
+
+// Waits until the next frame is due at the frame rate of the region,
+// 60.0988 or 50.007 Hz. When the emulation falls behind it does not try
+// to catch up.
+func paceFrame() {
+	period := float64(time.Second) / alphanes.FrameRate(Console)
+	if Alphanes.Audio.Driver != "null" {
+		skew := (audio.BufferFill(&Alphanes.Audio) - paceFill) * 2
+		period *= 1 + paceSkew * math.Max(-1, math.Min(1, skew))
+	}
+	// The fraction of a nanosecond is carried over, or 60.0988 Hz would drift
+	period += Alphanes.FrameCarry
+	frame := time.Duration(period)
+	Alphanes.FrameCarry = period - float64(frame)
+	Alphanes.NextFrame = Alphanes.NextFrame.Add(frame)
+	wait := time.Until(Alphanes.NextFrame)
+	if wait > 0 {
+		time.Sleep(wait)
+		adaptive.Slept += wait
+	} else if wait < -4*frame {
+		Alphanes.NextFrame = time.Now()
+	}
+}
+
+// Command line switches after the ROM name
+func hasOption(name string) bool {
+	for _, arg := range os.Args[2:] {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Value of a "--name value" command line switch
+func optionValue(name string) (string, bool) {
+	for i := 2; i < len(os.Args)-1; i++ {
+		if os.Args[i] == name {
+			return os.Args[i+1], true
+		}
+	}
+	return "", false
+}
+
+func emulate() {
+	var second time.Time = time.Now()
+	var frames int = 0
+	Alphanes.Timing = timing.StartFrameTiming()
+	video.FrameHistogramTarget = int(time.Duration(float64(time.Second) / alphanes.FrameRate(Console)) / timing.BucketWidth)
+	Alphanes.NextFrame = time.Now()
+
+	for Alphanes.Running == true && Console.Running == true && alphanes.Halted(Console) == false && video.Quit == false {
+		beat()
+		if video.Paused {
+			idle()
+			timing.Restart(&Alphanes.Timing)
+			Alphanes.NextFrame = time.Now()
+		}
+		if video.Rewind {
+			video.Rewind = false
+			rewindJournal(60)
+			timing.Restart(&Alphanes.Timing)
+			Alphanes.NextFrame = time.Now()
+		}
+		if video.SaveSlot {
+			video.SaveSlot = false
+			if confirmSave() {
+				saveSlot()
+			}
+		}
+		if video.SelectSlot >= 0 {
+			selectSlot(video.SelectSlot)
+			video.SelectSlot = -1
+		}
+		if video.LoadSlot {
+			video.LoadSlot = false
+			if err := loadSlot(); err != nil {
+				fmt.Println(locale.T("Cannot load the savestate: %v", err))
+			}
+			timing.Restart(&Alphanes.Timing)
+			Alphanes.NextFrame = time.Now()
+		}
+		if video.UndoLoad {
+			video.UndoLoad = false
+			if err := undoLoad(); err != nil {
+				fmt.Println(locale.T("Cannot undo the load: %v", err))
+			}
+			timing.Restart(&Alphanes.Timing)
+			Alphanes.NextFrame = time.Now()
+		}
+		journalFrame()
+		movieFrame()
+		if video.Capture {
+			video.Capture = false
+			captureFrame()
+		} else {
+			alphanes.RunFrame(Console)
+		}
+		moviePolls()
+		timing.Tick(&Alphanes.Timing)
+		Alphanes.Frames++
+		audio.QueueSamples(&Alphanes.Audio, alphanes.Audio(Console))
+		alphanes.SetAudioFill(Console, audio.BufferFill(&Alphanes.Audio))
+		recordAudio()
+		if Alphanes.Stream != nil {
+			stream.PublishFrame(Alphanes.Stream, alphanes.Screen(Console))
+		}
+		if Alphanes.Perf != nil {
+			counters := alphanes.GetCounters(Console)
+			perf.Publish(Alphanes.Perf, Alphanes.Frames, counters.Instructions, counters.PPUDots, Alphanes.Audio.Underruns)
+		}
+
+		frames++
+		if time.Since(second) >= time.Second {
+			Alphanes.FPS = float64(frames) / time.Since(second).Seconds()
+			adaptQuality(Alphanes.FPS, time.Since(second))
+			Alphanes.TimingStats = timing.Compute(&Alphanes.Timing)
+			video.FrameHistogram = Alphanes.TimingStats.Histogram[:]
+			frames = 0
+			second = time.Now()
+			checkWatch()
+		}
+
+		if time.Since(Alphanes.LastSave) >= autosaveInterval {
+			saveBattery()
+		}
+		serveRemote()
+		paceFrame()
+	}
+}
+
+// Publishes the frame to the HTTP server and runs its pending commands.
+func serveRemote() {
+	if Alphanes.Remote == nil {
+		return
+	}
+
+	var status remote.Status
+	status.Rom = os.Args[1]
+	status.Hash = Cart.Hash
+	status.FPS = Alphanes.FPS
+	status.Frame = Alphanes.Frames
+	status.Paused = video.Paused
+	status.Hardcore = alphanes.Hardcore(Console)
+	status.Timing = Alphanes.TimingStats
+	status.Mapper = alphanes.MapperStatus(Console)
+	remote.Publish(Alphanes.Remote, status, alphanes.Screen(Console), alphanes.Scroll(Console), alphanes.Sprites(Console))
+
+	for {
+		command, found := remote.NextCommand(Alphanes.Remote)
+		if found == false {
+			return
+		}
+		command.Reply <- runCommand(command)
+	}
+}
+
+func runCommand(command remote.Command) error {
+	switch(command.Name) {
+		case "pause":
+			video.Paused = true
+		case "resume":
+			video.Paused = false
+		case "reset":
+			alphanes.Reset(Console)
+			journalKeyframe()
+		case "savestate":
+			saveSlot()
+		case "loadstate":
+			return loadSlot()
+		case "input":
+			alphanes.SetInput(Console, command.Port, alphanes.Input(command.Buttons))
+	}
+	return nil
+}
+
+// Runs frames as fast as possible without video or sound and reports the
+// speed, to measure changes to the core.
+func runBench(count string) {
+	frames, err := strconv.Atoi(count)
+	if err != nil || frames <= 0 {
+		fmt.Println(locale.T("Invalid --bench frame count"))
+		os.Exit(1)
+	}
+
+	start := time.Now()
+	for i := 0; i < frames && Console.Running && alphanes.Halted(Console) == false; i++ {
+		alphanes.RunFrame(Console)
+	}
+	elapsed := time.Since(start)
+	fmt.Println(locale.T("%d frames in %.3fs: %.1f fps, %.3fms per frame", frames, elapsed.Seconds(),
+		float64(frames) / elapsed.Seconds(), elapsed.Seconds() * 1000 / float64(frames)))
+}
+
+const autosaveInterval = 30 * time.Second
+
+func loadBattery(romfile string) {
+	Alphanes.SavePath = sram.SavePath(romfile)
+	Alphanes.LastSave = time.Now()
+	data, found := sram.LoadSRAM(Alphanes.SavePath)
+	if found {
+		fmt.Println(locale.T("Battery save loaded from %s", Alphanes.SavePath))
+		copy(alphanes.SRAM(Console), data)
+	}
+	Alphanes.Saved = append([]byte(nil), alphanes.SRAM(Console)...)
+}
+
+// Writes the SRAM if it changed since the last save.
+func saveBattery() {
+	Alphanes.LastSave = time.Now()
+	if Alphanes.SavePath == "" || bytes.Equal(Alphanes.Saved, alphanes.SRAM(Console)) {
+		return
+	}
+	data := append([]byte(nil), alphanes.SRAM(Console)...)
+	if err := sram.SaveSRAM(Alphanes.SavePath, data); err != nil {
+		fmt.Println(locale.T("Cannot write the battery save: %v", err))
+		return
+	}
+	Alphanes.Saved = data
+}
+
+// Low-power loop while the emulation is paused
+func idle() {
+	audio.PauseAudio(&Alphanes.Audio, true)
+	for video.Paused {
+		beat()
+		time.Sleep(50 * time.Millisecond)
+		video.CheckEvents()
+		serveRemote()
+	}
+	audio.PauseAudio(&Alphanes.Audio, false)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "crypto/sha1"
+import "fmt"
+import "os"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/movie"
+
+// Movie playback without video or sound device, as fast as possible. The
+// sound only depends on the CPU cycles run, so a movie always makes the
+// same samples: they can be recorded with --wav for an encode, and their
+// SHA-1 is printed to compare builds.
+func playMovie(file string) {
+	if alphanes.Hardcore(Console) {
+		fmt.Println(locale.T("Movie playback is disabled in hardcore mode"))
+		os.Exit(1)
+	}
+	m, err := movie.ReadMovie(file)
+	if err != nil {
+		fmt.Println(locale.T("Cannot read the movie: %v", err))
+		os.Exit(1)
+	}
+	if m.Hash != Cart.Hash {
+		fmt.Println(locale.T("The movie was recorded with another ROM"))
+		os.Exit(1)
+	}
+	if len(m.Start) > 0 {
+		if err := alphanes.ReadState(Console, m.Start); err != nil {
+			fmt.Println(locale.T("Cannot load the movie start: %v", err))
+			os.Exit(1)
+		}
+	}
+	reportAutofire(&m)
+	start := alphanes.GetFrameCounters(Console)
+
+	hash := sha1.New()
+	var buffer []byte
+	for _, f := range m.Frames {
+		applyFrame(f)
+		alphanes.RunFrame(Console)
+		recordAudio()
+
+		buffer = buffer[:0]
+		for _, s := range alphanes.Audio(Console) {
+			buffer = append(buffer, byte(s), byte(uint16(s) >> 8))
+		}
+		hash.Write(buffer)
+	}
+	fmt.Println(locale.T("%d frames played, audio SHA-1 %x", len(m.Frames), hash.Sum(nil)))
+
+	// Movies of older versions and journal segments have no counts
+	end := alphanes.GetFrameCounters(Console)
+	lag, latches := end.LagFrames - start.LagFrames, end.Latches - start.Latches
+	fmt.Println(locale.T("%d lag frames, %d controller latches", lag, latches))
+	if (m.LagFrames > 0 || m.Latches > 0) && (lag != m.LagFrames || latches != m.Latches) {
+		fmt.Println(locale.T("Warning: the movie was recorded with %d lag frames and %d latches, it may have desynchronized", m.LagFrames, m.Latches))
+	}
+}
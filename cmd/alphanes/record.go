@@ -0,0 +1,119 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "fmt"
+import "math"
+import "strings"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/audiofile"
+
+// Sound recording to WAV files: the mix, and with stems one more file per
+// channel next to it (game-pulse1.wav, game-triangle.wav...). The APU
+// register writes can also be logged as VGM.
+
+func startRecording(path string, stems bool) {
+	w, err := audiofile.CreateWav(path)
+	if err != nil {
+		fmt.Println(locale.T("Cannot record the sound: %v", err))
+		return
+	}
+	Alphanes.Wav = w
+	if stems == false {
+		return
+	}
+
+	alphanes.SetAudioChannels(Console, true)
+	base := strings.TrimSuffix(path, ".wav")
+	for ch := 0; ch < alphanes.Channels; ch++ {
+		stem, err := audiofile.CreateWav(base + "-" + alphanes.ChannelName(ch) + ".wav")
+		if err != nil {
+			fmt.Println(locale.T("Cannot record the sound channels: %v", err))
+			stopRecording()
+			return
+		}
+		Alphanes.Stems = append(Alphanes.Stems, stem)
+	}
+}
+
+func recordAudio() {
+	recordVgm()
+	if Alphanes.Wav == nil {
+		return
+	}
+	err := audiofile.WriteWav(Alphanes.Wav, alphanes.Audio(Console))
+	for ch, stem := range Alphanes.Stems {
+		if err == nil {
+			err = audiofile.WriteWav(stem, alphanes.AudioChannel(Console, ch))
+		}
+	}
+	if err != nil {
+		fmt.Println(locale.T("Cannot record the sound: %v", err))
+		stopRecording()
+	}
+}
+
+func stopRecording() {
+	if Alphanes.Wav == nil {
+		return
+	}
+	files := append([]*audiofile.WavFile{Alphanes.Wav}, Alphanes.Stems...)
+	for _, w := range files {
+		if err := audiofile.CloseWav(w); err != nil {
+			fmt.Println(locale.T("Cannot finish the sound recording: %v", err))
+		}
+	}
+	Alphanes.Wav = nil
+	Alphanes.Stems = nil
+	alphanes.SetAudioChannels(Console, false)
+}
+
+func startVgm(path string) {
+	v, err := audiofile.CreateVgm(path, alphanes.CPUFrequency(Console), int(math.Round(alphanes.FrameRate(Console))))
+	if err != nil {
+		fmt.Println(locale.T("Cannot log the sound registers: %v", err))
+		return
+	}
+	Alphanes.Vgm = v
+	alphanes.SetAPULog(Console, true)
+}
+
+func recordVgm() {
+	if Alphanes.Vgm == nil {
+		return
+	}
+	writes, cycle := alphanes.APULog(Console)
+	if err := audiofile.WriteVgm(Alphanes.Vgm, writes, cycle); err != nil {
+		fmt.Println(locale.T("Cannot log the sound registers: %v", err))
+		stopVgm()
+	}
+}
+
+func stopVgm() {
+	if Alphanes.Vgm == nil {
+		return
+	}
+	if err := audiofile.CloseVgm(Alphanes.Vgm); err != nil {
+		fmt.Println(locale.T("Cannot finish the sound register log: %v", err))
+	}
+	Alphanes.Vgm = nil
+	alphanes.SetAPULog(Console, false)
+}
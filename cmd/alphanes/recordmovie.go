@@ -0,0 +1,119 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "fmt"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/movie"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/video"
+
+// Movie recording. The input of every frame is echoed to the movie as it
+// runs, from a savestate of the console when recording starts. Loading a
+// savestate or rewinding while recording is a re-record: the movie is cut
+// back to the frame the console returned to and goes on from there. The
+// buttons of each controller latch are kept too, and saved with the frames
+// of games that saw other buttons than the ones held when the frame started.
+
+func startMovie(path string) {
+	Alphanes.Movie = &movie.Movie{Hash: Cart.Hash}
+	Alphanes.Movie.Start = alphanes.WriteState(Console, nil)
+	Alphanes.MoviePath = path
+	Alphanes.MovieCounters = alphanes.GetFrameCounters(Console)
+	video.Recording = true
+	video.Rerecords = 0
+	alphanes.SetPollRecording(Console, true)
+}
+
+// Records the frame about to run.
+func movieFrame() {
+	if Alphanes.Movie == nil {
+		return
+	}
+	Alphanes.Movie.Frames = append(Alphanes.Movie.Frames, currentFrame())
+}
+
+// Adds the latches of the frame that just ran when they differ from its
+// buttons.
+func moviePolls() {
+	if Alphanes.Movie == nil {
+		return
+	}
+	polls := alphanes.Polls(Console)
+	f := &Alphanes.Movie.Frames[len(Alphanes.Movie.Frames)-1]
+	for _, p := range polls {
+		if p != f.Buttons {
+			f.Polls = polls
+			return
+		}
+	}
+}
+
+// Cuts the movie to its first frames after the console went back to them.
+func rerecordMovie(frames int) {
+	m := Alphanes.Movie
+	if m == nil {
+		return
+	}
+	if frames < 0 || frames > len(m.Frames) {
+		fmt.Println(locale.T("The console went back past the start of the movie, recording stopped"))
+		stopMovie()
+		return
+	}
+	m.Frames = m.Frames[:frames]
+	m.Rerecords++
+	video.Rerecords = m.Rerecords
+	fmt.Println(locale.T("Re-record %d at frame %d", m.Rerecords, frames))
+}
+
+func stopMovie() {
+	m := Alphanes.Movie
+	if m == nil {
+		return
+	}
+	// Savestates keep the counters, so re-records do not count twice
+	start, now := Alphanes.MovieCounters, alphanes.GetFrameCounters(Console)
+	if now.Frames >= start.Frames {
+		m.LagFrames = now.LagFrames - start.LagFrames
+		m.Latches = now.Latches - start.Latches
+	}
+	if err := movie.WriteMovie(Alphanes.MoviePath, m); err != nil {
+		fmt.Println(locale.T("Cannot write the movie: %v", err))
+	} else {
+		fmt.Println(locale.T("%d frames and %d re-records written to %s", len(m.Frames), m.Rerecords, Alphanes.MoviePath))
+		fmt.Println(locale.T("%d lag frames, %d controller latches", m.LagFrames, m.Latches))
+		reportAutofire(m)
+	}
+	Alphanes.Movie = nil
+	video.Recording = false
+	alphanes.SetPollRecording(Console, false)
+}
+
+// Lists the spans of the movie tagged as auto-fire.
+func reportAutofire(m *movie.Movie) {
+	if len(m.Autofire) == 0 {
+		return
+	}
+	fmt.Println(locale.T("Warning: the movie has input faster than a player can press, tagged as auto-fire:"))
+	for _, a := range m.Autofire {
+		fmt.Println(locale.T("  controller %d, %s, frames %d-%d", a.Port+1, ioports.ButtonNames[a.Button], a.Start, a.End))
+	}
+}
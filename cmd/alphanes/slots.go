@@ -0,0 +1,144 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "errors"
+import "fmt"
+import "os"
+import "path/filepath"
+import "strings"
+import "time"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/savestate"
+
+// Savestate slots. F10 saves and F11 loads the slot chosen with the number
+// keys, 0 until one is pressed. Every savestate is also written next to
+// the ROM, game.st0 to game.st9, with the header of the savestate package,
+// so the game can be resumed in a later session. Within the session the
+// copy in memory is used, which knows the movie frame it was taken at.
+//
+// Shift+F11 undoes the last load, back to the console as it was just
+// before. With --confirm-save, F10 over a slot that holds a savestate
+// only warns and a second press within overwriteWindow overwrites it.
+
+const slotCount = 10
+const overwriteWindow = 3 * time.Second
+
+func slotPath(n int) string {
+	rom := os.Args[1]
+	return fmt.Sprintf("%s.st%d", strings.TrimSuffix(rom, filepath.Ext(rom)), n)
+}
+
+func slotUsed(n int) bool {
+	_, err := os.Stat(slotPath(n))
+	return Alphanes.Slots[n] != nil || err == nil
+}
+
+func selectSlot(n int) {
+	Alphanes.SlotNumber = n
+	Alphanes.OverwriteAsked = time.Time{}
+	if slotUsed(n) == false {
+		fmt.Println(locale.T("Savestate slot %d (empty)", n))
+		return
+	}
+	fmt.Println(locale.T("Savestate slot %d", n))
+}
+
+// Whether F10 may save now, see overwriteWindow.
+func confirmSave() bool {
+	n := Alphanes.SlotNumber
+	if Alphanes.ConfirmSave == false || slotUsed(n) == false || time.Since(Alphanes.OverwriteAsked) < overwriteWindow {
+		Alphanes.OverwriteAsked = time.Time{}
+		return true
+	}
+	Alphanes.OverwriteAsked = time.Now()
+	fmt.Println(locale.T("Slot %d holds a savestate, press F10 again to overwrite it", n))
+	return false
+}
+
+// Takes a savestate in the slot, noting the movie frame it belongs to.
+func saveSlot() {
+	n := Alphanes.SlotNumber
+	state := alphanes.SaveState(Console)
+	Alphanes.Slots[n] = &state
+	if Alphanes.Movie != nil {
+		Alphanes.SlotFrames[n] = len(Alphanes.Movie.Frames)
+	}
+
+	if err := savestate.WriteFile(slotPath(n), alphanes.WriteState(Console, nil)); err != nil {
+		fmt.Println(locale.T("Cannot write the savestate: %v", err))
+		return
+	}
+	fmt.Println(locale.T("Savestate saved in slot %d", n))
+}
+
+func loadSlot() error {
+	n := Alphanes.SlotNumber
+	undo := alphanes.SaveState(Console)
+	if Alphanes.Slots[n] != nil {
+		if err := alphanes.LoadState(Console, *Alphanes.Slots[n]); err != nil {
+			return err
+		}
+		Alphanes.Undo = &undo
+		journalKeyframe()
+		rerecordMovie(Alphanes.SlotFrames[n])
+		return nil
+	}
+
+	// A savestate of another session is not in the movie being recorded
+	if Alphanes.Movie != nil {
+		return fmt.Errorf("slot %d was not saved during this recording", n)
+	}
+	data, err := savestate.ReadFile(slotPath(n))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("slot %d is empty", n)
+	}
+	if err != nil {
+		return err
+	}
+	if err := alphanes.ReadState(Console, data); err != nil {
+		return err
+	}
+	Alphanes.Undo = &undo
+	journalKeyframe()
+	fmt.Println(locale.T("Savestate loaded from %s", slotPath(n)))
+	return nil
+}
+
+// Goes back to before the last load. There is one level: undoing twice
+// loads the savestate again.
+func undoLoad() error {
+	if Alphanes.Undo == nil {
+		return errors.New("no savestate was loaded")
+	}
+	// The load cut the movie back, the frames undone are gone
+	if Alphanes.Movie != nil {
+		return errors.New("a movie is being recorded")
+	}
+	current := alphanes.SaveState(Console)
+	if err := alphanes.LoadState(Console, *Alphanes.Undo); err != nil {
+		return err
+	}
+	Alphanes.Undo = &current
+	journalKeyframe()
+	fmt.Println(locale.T("Load undone, Shift+F11 again redoes it"))
+	return nil
+}
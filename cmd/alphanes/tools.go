@@ -0,0 +1,168 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "bytes"
+import "crypto/sha1"
+import "fmt"
+import "io/ioutil"
+import "os"
+import "strconv"
+import "strings"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/movie"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/savestate"
+
+// Subcommands given before the ROM name. Without one the ROM is run.
+var subcommands = []string{"run", "info", "verify", "disasm", "statediff"}
+
+// Takes the subcommand out of the arguments, so the ROM name is always
+// os.Args[1].
+func parseSubcommand() string {
+	if len(os.Args) >= 2 {
+		for _, name := range subcommands {
+			if os.Args[1] == name {
+				os.Args = append(os.Args[:1], os.Args[2:]...)
+				return name
+			}
+		}
+	}
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "--") {
+		fmt.Println(locale.T("Usage: alphanes [run|info|verify|disasm|statediff] game.nes [options]"))
+		os.Exit(2)
+	}
+	return "run"
+}
+
+// Runs --frames frames without video or sound and prints the SHA-1 of the
+// last picture. With --hash, exits with status 1 when it is another one,
+// for regression tests of the core.
+func runVerify() {
+	count, _ := optionValue("--frames")
+	frames, err := strconv.Atoi(count)
+	if err != nil || frames <= 0 {
+		fmt.Println(locale.T("verify needs --frames with a frame count"))
+		os.Exit(2)
+	}
+
+	ran := 0
+	for ran < frames && Console.Running && alphanes.Halted(Console) == false {
+		alphanes.RunFrame(Console)
+		ran++
+	}
+	checkCrash()
+	writeCompatReport(sessionReport(ran))
+
+	hash := sha1.New()
+	for _, index := range alphanes.Screen(Console) {
+		hash.Write([]byte{byte(index)})
+	}
+	sum := fmt.Sprintf("%x", hash.Sum(nil))
+	fmt.Println(locale.T("Picture SHA-1 after %d frames: %s", frames, sum))
+
+	if expected, found := optionValue("--hash"); found {
+		if strings.EqualFold(expected, sum) == false {
+			fmt.Println(locale.T("Mismatch, expected %s", expected))
+			os.Exit(1)
+		}
+		fmt.Println(locale.T("Match"))
+	}
+}
+
+// Lists the 16 KB PRG banks given by --bank, or all of them. The last bank
+// is listed at $C000 where it is fixed, the others at $8000.
+func runDisasm() {
+	banks := len(Cart.PRG) / 0x4000
+	first, last := 0, banks - 1
+	if value, found := optionValue("--bank"); found {
+		bank, err := strconv.Atoi(value)
+		if err != nil || bank < 0 || bank >= banks {
+			fmt.Println(locale.T("Invalid --bank, the ROM has banks 0-%d", banks - 1))
+			os.Exit(2)
+		}
+		first, last = bank, bank
+	}
+
+	for bank := first; bank <= last; bank++ {
+		base := uint16(0x8000)
+		if bank == banks - 1 {
+			base = 0xC000
+		}
+		fmt.Printf("; Bank %d\n", bank)
+		debug.WriteListing(os.Stdout, Cart.PRG[bank*0x4000:(bank+1)*0x4000], base)
+	}
+}
+
+// Prints the fields that differ between two savestates of the ROM. Each
+// file is a savestate or a movie, which gives the state it starts from.
+func runStateDiff() {
+	if len(os.Args) < 4 {
+		fmt.Println(locale.T("Usage: alphanes statediff game.nes first second"))
+		os.Exit(2)
+	}
+	a := readStateFile(os.Args[2])
+	b := readStateFile(os.Args[3])
+
+	Console = alphanes.StartConsole(&Cart)
+	diffs, err := alphanes.DiffStates(Console, a, b)
+	if err != nil {
+		fmt.Println(locale.T("Cannot compare the savestates: %v", err))
+		os.Exit(2)
+	}
+	subsystem := ""
+	for _, d := range diffs {
+		name := strings.SplitN(d.Field, ".", 2)[0]
+		if name != subsystem {
+			subsystem = name
+			fmt.Printf("[%s]\n", subsystem)
+		}
+		fmt.Printf("  %-40s %s -> %s\n", d.Field, d.A, d.B)
+	}
+	fmt.Println(locale.T("%d differences", len(diffs)))
+	if len(diffs) > 0 {
+		os.Exit(1)
+	}
+}
+
+func readStateFile(file string) []byte {
+	if m, err := movie.ReadMovie(file); err == nil {
+		if len(m.Start) == 0 {
+			fmt.Println(locale.T("The movie %s starts at power on, it has no savestate", file))
+			os.Exit(2)
+		}
+		return m.Start
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		fmt.Println(locale.T("Cannot read %s: %v", file, err))
+		os.Exit(2)
+	}
+	// Slot files have a header in front of the state
+	if bytes.HasPrefix(data, []byte(savestate.Magic)) {
+		data, err = savestate.Decode(data)
+		if err != nil {
+			fmt.Println(locale.T("Cannot read %s: %v", file, err))
+			os.Exit(2)
+		}
+	}
+	return data
+}
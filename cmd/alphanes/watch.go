@@ -0,0 +1,108 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "fmt"
+import "os"
+import "time"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+
+// Watch mode for homebrew development: when the ROM file changes the new
+// build is loaded and started. What survives the reload depends on keep:
+// "" starts from power up, "ram" keeps the work RAM and the battery RAM,
+// "state" restores everything but the ROM as it was just before.
+
+type Watch struct {
+	Path string
+	Keep string
+	Modified time.Time
+}
+
+func startWatch(path string, keep string) {
+	if keep != "" && keep != "ram" && keep != "state" {
+		fmt.Println(locale.T("Invalid --watch-keep, use ram or state"))
+		os.Exit(1)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		fmt.Println(locale.T("Cannot watch the ROM: %v", err))
+		return
+	}
+	Alphanes.Watch = &Watch{Path: path, Keep: keep, Modified: info.ModTime()}
+	fmt.Println(locale.T("Watching %s for changes", path))
+}
+
+// Reloads the ROM if it changed. A build that cannot be loaded, like one
+// the assembler is still writing, is tried again at the next check.
+func checkWatch() {
+	w := Alphanes.Watch
+	if w == nil {
+		return
+	}
+	info, err := os.Stat(w.Path)
+	if err != nil || info.ModTime().Equal(w.Modified) {
+		return
+	}
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		return
+	}
+	cart, err := cartridge.ParseRom(data)
+	if err != nil {
+		fmt.Println(locale.T("Not reloading the ROM: %v", err))
+		return
+	}
+	w.Modified = info.ModTime()
+
+	keep := w.Keep
+	if keep == "state" && sameBoard(&Cart, &cart) == false {
+		fmt.Println(locale.T("The board of the ROM changed, starting from power up"))
+		keep = ""
+	}
+	var state alphanes.State
+	if keep == "state" {
+		state = alphanes.SaveState(Console)
+	}
+
+	if Alphanes.Journal != nil {
+		fmt.Println(locale.T("The ROM changed, the session journal is closed"))
+		closeJournal()
+	}
+	saveBattery()
+	Cart = cart
+	alphanes.InsertCartridge(Console, &Cart, keep == "ram")
+	if keep == "state" {
+		if err := alphanes.LoadState(Console, state); err != nil {
+			fmt.Println(locale.T("Cannot keep the state over the reload: %v", err))
+		}
+	}
+	Alphanes.Saved = append([]byte(nil), alphanes.SRAM(Console)...)
+	fmt.Println(locale.T("Reloaded %s", w.Path))
+}
+
+// Savestates only fit cartridges with the same mapper and memory sizes.
+func sameBoard(a *cartridge.Cartridge, b *cartridge.Cartridge) bool {
+	return a.Header.RomType.Mapper == b.Header.RomType.Mapper &&
+		a.Header.ROM_SIZE == b.Header.ROM_SIZE &&
+		a.Header.VROM_SIZE == b.Header.VROM_SIZE &&
+		a.Header.RomType.FourScreenVRAM == b.Header.RomType.FourScreenVRAM
+}
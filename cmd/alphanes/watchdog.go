@@ -0,0 +1,69 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "fmt"
+import "os"
+import "sync/atomic"
+import "time"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/locale"
+
+// The main loop beats once per frame, and while paused. When the beats
+// stop for longer than the timeout the console is stuck inside a frame,
+// and the watchdog prints what the CPU was doing instead of leaving a
+// frozen window. It reports each stall once.
+
+const defaultWatchdogTimeout = 5 * time.Second
+
+var heartbeat int64
+var stall atomic.Value // Last stall reported, for the compatibility report
+
+func beat() {
+	atomic.AddInt64(&heartbeat, 1)
+}
+
+func watchdogStall() string {
+	text, _ := stall.Load().(string)
+	return text
+}
+
+func startWatchdog(timeout time.Duration) {
+	go func() {
+		last := atomic.LoadInt64(&heartbeat)
+		since := time.Now()
+		reported := false
+		for range time.Tick(timeout / 5) {
+			now := atomic.LoadInt64(&heartbeat)
+			if now != last {
+				last = now
+				since = time.Now()
+				reported = false
+				continue
+			}
+			if reported == false && time.Since(since) >= timeout {
+				reported = true
+				stall.Store(fmt.Sprintf("no frame for %s, PC at $%04X", time.Since(since).Round(time.Second), alphanes.GetRegisters(Console).PC))
+				fmt.Fprintln(os.Stderr, locale.T("The emulation has not finished a frame for %s:", time.Since(since).Round(time.Second)))
+				alphanes.WriteDiagnostics(os.Stderr, Console)
+			}
+		}
+	}()
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package main
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "fmt"
+import "os"
+import "path/filepath"
+
+// Runs ROMs in lockstep with trace logs of a reference emulator (Mesen,
+// FCEUX or Nintendulator, see debug.ReadTrace) and reports the first
+// instruction where the registers differ, with the lines before it.
+//
+// The console starts with the registers of the first line of the trace, so
+// nestest can run from its automation entry point at $C000. The B and
+// unused bits of P are not compared because the loggers disagree on them.
+// Cycle counts are compared with --cycles, relative to the first line.
+//
+// The PPU, the APU and the controllers are not in step with the reference
+// emulator, so after an instruction that read one of their registers the
+// registers A, X, Y and P are taken from the next line of the trace, as if
+// the read had returned what the reference saw. --exact-io turns this off.
+
+const contextLines = 5
+
+func main() {
+
+	var args []string
+	cycles := false
+	substitute := true
+	for _, arg := range os.Args[1:] {
+		switch(arg) {
+			case "--cycles":
+				cycles = true
+			case "--exact-io":
+				substitute = false
+			default:
+				args = append(args, arg)
+		}
+	}
+	if len(args) == 0 || len(args) % 2 != 0 {
+		fmt.Println("Usage: tracecmp [--cycles] [--exact-io] <rom> <trace> [<rom> <trace>...]")
+		os.Exit(2)
+	}
+
+	failed := 0
+	for i := 0; i < len(args); i += 2 {
+		if compareTrace(args[i], args[i+1], cycles, substitute) == false {
+			failed++
+		}
+	}
+
+	fmt.Printf("%d traces, %d diverging\n", len(args) / 2, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func compareTrace(romfile string, tracefile string, cycles bool, substitute bool) bool {
+	name := filepath.Base(romfile)
+	lines, err := debug.ReadTrace(tracefile)
+	if err != nil {
+		fmt.Printf("%s: %v\n", name, err)
+		return false
+	}
+	if len(lines) == 0 {
+		fmt.Printf("%s: no instructions in %s\n", name, tracefile)
+		return false
+	}
+
+	cart := cartridge.LoadRom(romfile)
+	console := alphanes.StartConsole(&cart)
+	first := lines[0]
+	alphanes.SetRegisters(console, alphanes.Registers{A: first.A, X: first.X, Y: first.Y, P: first.P, SP: first.SP, PC: first.PC})
+	start := alphanes.GetCounters(console).CPUCycles
+
+	for i, line := range lines {
+		problem := compareLine(console, line, cycles, alphanes.GetCounters(console).CPUCycles - start + first.Cycles)
+		if problem == "" && console.Running && alphanes.Halted(console) == false {
+			reads := alphanes.GetCounters(console).PeripheralReads
+			alphanes.StepInstruction(console)
+			if substitute && alphanes.GetCounters(console).PeripheralReads != reads && i + 1 < len(lines) {
+				trusted := lines[i+1]
+				r := alphanes.GetRegisters(console)
+				r.A, r.X, r.Y, r.P = trusted.A, trusted.X, trusted.Y, trusted.P
+				alphanes.SetRegisters(console, r)
+			}
+			continue
+		}
+		if problem == "" {
+			problem = "the CPU stopped"
+		}
+
+		fmt.Printf("%s: diverges at instruction %d (line %d of %s): %s\n", name, i, line.Line, tracefile, problem)
+		for j := i - contextLines; j < i; j++ {
+			if j >= 0 {
+				fmt.Printf("      %s\n", lines[j].Text)
+			}
+		}
+		fmt.Printf("    > %s\n", line.Text)
+		c := alphanes.GetRegisters(console)
+		scanline, dot := alphanes.Beam(console)
+		fmt.Printf("      Alphanes: PC:%04X A:%02X X:%02X Y:%02X P:%02X SP:%02X CYC:%d scanline %d dot %d\n",
+			c.PC, c.A, c.X, c.Y, c.P, c.SP, alphanes.GetCounters(console).CPUCycles - start + first.Cycles,
+			scanline, dot)
+		return false
+	}
+	fmt.Printf("%s: %d instructions match\n", name, len(lines))
+	return true
+}
+
+// Returns what differs between the console and a line of the trace, or ""
+// when they agree.
+func compareLine(console *alphanes.Console, line debug.TraceLine, cycles bool, elapsed uint64) string {
+	c := alphanes.GetRegisters(console)
+	switch {
+		case c.PC != line.PC:
+			return fmt.Sprintf("PC is %04X instead of %04X", c.PC, line.PC)
+		case c.A != line.A:
+			return fmt.Sprintf("A is %02X instead of %02X", c.A, line.A)
+		case c.X != line.X:
+			return fmt.Sprintf("X is %02X instead of %02X", c.X, line.X)
+		case c.Y != line.Y:
+			return fmt.Sprintf("Y is %02X instead of %02X", c.Y, line.Y)
+		case c.P & 0xCF != line.P & 0xCF:
+			return fmt.Sprintf("P is %02X instead of %02X", c.P, line.P)
+		case c.SP != line.SP:
+			return fmt.Sprintf("SP is %02X instead of %02X", c.SP, line.SP)
+		case cycles && line.HasCycles && elapsed != line.Cycles:
+			return fmt.Sprintf("CPU cycle is %d instead of %d", elapsed, line.Cycles)
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "fmt"
+import "sort"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+
+// Compatibility report of a session: the board of the game and what it did
+// that the emulation does not handle. Frontends add the session details and
+// keep the reports, so the missing mappers and features that break the
+// most games can be found.
+type CompatReport struct {
+	Rom string `json:"rom"`
+	Hash string `json:"hash"`
+	Time string `json:"time"`
+	Frames int `json:"frames"`
+	Mapper int `json:"mapper"`
+	MapperSupported bool `json:"mapper_supported"`
+	Board string `json:"board"`
+	UnknownReads []CompatAccess `json:"unknown_reads,omitempty"` // $4020-$5FFF with nothing behind
+	UnknownWrites []CompatAccess `json:"unknown_writes,omitempty"`
+	ROMWrites int `json:"rom_writes,omitempty"` // Writes to PRG-ROM of a board without registers
+	Unsupported []string `json:"unsupported,omitempty"` // Features the game needs that are missing
+	Crash string `json:"crash,omitempty"` // Why the emulation stopped, "" if it did not
+}
+
+type CompatAccess struct {
+	Address string `json:"address"`
+	Count int `json:"count"`
+}
+
+// Report of what the console saw since power up. Rom, Time and Frames are
+// left to the frontend.
+func CompatibilityReport(c *Console) CompatReport {
+	r := CartridgeReport(c.Cart)
+	r.Board = MapperStatus(c).Board
+
+	log := &c.cpu.IO.COMPAT
+	r.UnknownReads = compatAccesses(log.UNKNOWN_READS)
+	r.UnknownWrites = compatAccesses(log.UNKNOWN_WRITES)
+	r.ROMWrites = log.ROM_WRITES
+	r.Crash = log.CRASH
+	return r
+}
+
+// Report of a cartridge that cannot run, with what the header tells.
+func CartridgeReport(cart *cartridge.Cartridge) CompatReport {
+	var r CompatReport
+	r.Hash = cart.Hash
+	r.Mapper = cart.Header.RomType.Mapper
+	r.MapperSupported = mapper.Supported(r.Mapper)
+	var board mapper.Board
+	r.Board = mapper.GetStatus(&board, cart).Board
+	if r.MapperSupported == false {
+		r.Unsupported = append(r.Unsupported, fmt.Sprintf("mapper %d", r.Mapper))
+	}
+	return r
+}
+
+// In address order.
+func compatAccesses(counts map[uint16]int) []CompatAccess {
+	addrs := make([]int, 0, len(counts))
+	for addr := range counts {
+		addrs = append(addrs, int(addr))
+	}
+	sort.Ints(addrs)
+	var accesses []CompatAccess
+	for _, addr := range addrs {
+		accesses = append(accesses, CompatAccess{fmt.Sprintf("$%04X", addr), counts[uint16(addr)]})
+	}
+	return accesses
+}
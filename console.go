@@ -0,0 +1,260 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package alphanes is the public API of the Alphanes emulator core.
+//
+// A program loads a ROM with the cartridge package, starts a Console,
+// feeds it with Input and runs it frame by frame:
+//
+//	cart := cartridge.LoadRom("game.nes")
+//	console := alphanes.StartConsole(&cart)
+//	for {
+//		alphanes.SetInput(console, 0, alphanes.ButtonStart)
+//		alphanes.RunFrame(console)
+//		screen := alphanes.Screen(console)
+//		...
+//	}
+//
+// SaveState and LoadState take and restore a State snapshot. The packages
+// under internal/ are implementation details and may change at any time.
+package alphanes
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/cpu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+
+// Console owns the CPU, the PPU and the master clock they share.
+type Console struct {
+	Running bool
+	Cart *cartridge.Cartridge
+
+	Region Region
+	Revision Revision
+	PPUWarmUp bool // Ignore some PPU writes after power up, see SetPPUWarmUp
+
+	cpu cpu.CPU
+	ppu ppu.PPU
+	clock ioports.MASTER_CLOCK
+	ppuDebug debug.PPUDebug
+	ppuDelay int // CPU cycles left before the PPU starts
+	ppuDots int // PPU dots every 5 CPU cycles, see SetRegion
+	dotCredit int // PPU dots owed, in fifths
+	hardcore bool // See SetHardcore
+}
+
+// Starts a console with the cartridge inserted. The console is returned as
+// a pointer because the PPU keeps a reference to the CPU bus.
+func StartConsole(cart *cartridge.Cartridge) *Console {
+	c := new(Console)
+	c.Cart = cart
+	c.cpu = cpu.StartCPU()
+	c.cpu.IO = ioports.StartIOPorts(cart)
+	c.cpu.IO.CLOCK = &c.clock
+	cpu.SetResetVector(&c.cpu, cart)
+
+	c.ppu = ppu.StartPPU(&c.cpu.IO)
+	c.ppu.D = &c.ppuDebug
+	c.ppuDelay = 30000
+	c.PPUWarmUp = true
+	SetRegion(c, RegionNTSC)
+	c.Running = true
+	return c
+}
+
+// Presses the reset button. The CPU, the PPU and the APU keep part of
+// their state, see SoftResetCPU, ioports.ResetPPU and apu.Reset, and the
+// PPU warms up again.
+func Reset(c *Console) {
+	ioports.ResetMapper(&c.cpu.IO)
+	cpu.SoftResetCPU(&c.cpu)
+	cpu.SetResetVector(&c.cpu, c.Cart)
+	ioports.ResetPPU(&c.cpu.IO)
+	apu.Reset(&c.cpu.IO.APU)
+	if c.PPUWarmUp {
+		c.cpu.IO.PPU_WARMUP = c.clock.CPU_CYCLES + regionTimings[c.Region].WarmUp
+	}
+	c.Running = true
+}
+
+// Puts another cartridge in and powers the console up again. The video
+// output, the debugger and the access log stay as they are; with keepRAM
+// the work RAM and the battery RAM survive too, except in hardcore mode.
+func InsertCartridge(c *Console, cart *cartridge.Cartridge, keepRAM bool) {
+	keepRAM = keepRAM && !c.hardcore
+	var ram []byte
+	if keepRAM {
+		ram = append([]byte(nil), c.cpu.IO.CPU_RAM[:0x8000]...)
+	}
+	debugger := c.cpu.D
+	accesslog := c.cpu.IO.ACCESS_LOG
+	mixer := c.cpu.IO.APU.Mixer
+	writelog := c.cpu.IO.APU.Log
+	apuTest := c.cpu.IO.APU_TEST
+	oamExtension := c.cpu.IO.OAM_EXTENSION
+	oamDecay := c.cpu.IO.OAM_DECAY
+
+	c.Cart = cart
+	c.clock = ioports.MASTER_CLOCK{}
+	c.cpu = cpu.StartCPU()
+	c.cpu.D = debugger
+	c.cpu.IO = ioports.StartIOPorts(cart)
+	c.cpu.IO.CLOCK = &c.clock
+	c.cpu.IO.ACCESS_LOG = accesslog
+	c.cpu.IO.APU.Mixer = mixer
+	c.cpu.IO.APU.Log = writelog
+	c.cpu.IO.APU_TEST = apuTest
+	if oamExtension {
+		ioports.EnableOAMExtension(&c.cpu.IO)
+	}
+	c.cpu.IO.OAM_DECAY = oamDecay
+	if keepRAM {
+		copy(c.cpu.IO.CPU_RAM[:0x0800], ram[:0x0800])
+		copy(c.cpu.IO.CPU_RAM[0x6000:0x8000], ram[0x6000:0x8000])
+	}
+	cpu.SetResetVector(&c.cpu, cart)
+
+	c.ppu.IO = &c.cpu.IO
+	c.ppu.CYC = 0
+	c.ppu.SCANLINE = 241
+	c.ppuDelay = 30000
+	c.dotCredit = 0
+	SetRegion(c, c.Region)
+	SetRevision(c, c.Revision)
+	c.Running = true
+}
+
+// Runs one CPU cycle and the PPU dots that happen during it, three on
+// NTSC and Dendy and 3.2 on PAL.
+func Step(c *Console) {
+
+	cpu.Process(&c.cpu, c.Cart)
+	apu.Process(&c.cpu.IO.APU)
+	ioports.ClockDMC(&c.cpu.IO)
+	ioports.ClockMapperCPU(&c.cpu.IO)
+
+	if c.ppuDelay > 0 {
+		c.ppuDelay--
+		return
+	}
+	c.dotCredit += c.ppuDots
+	for c.dotCredit >= 5 {
+		c.dotCredit -= 5
+		c.clock.PPU_DOTS++
+		ppu.Process(&c.ppu, c.Cart)
+	}
+}
+
+// Runs until the PPU enters the vertical blank of the next frame. Without
+// a video frontend a frame makes no heap allocations; keep it that way, GC
+// pauses show up as uneven frame times.
+func RunFrame(c *Console) {
+	var previous int = c.clock.SCANLINE
+	apu.ClearSamples(&c.cpu.IO.APU)
+	for c.Running && c.cpu.Running {
+		Step(c)
+		if c.clock.SCANLINE == c.ppu.VBLANK_LINE && previous != c.ppu.VBLANK_LINE {
+			return
+		}
+		previous = c.clock.SCANLINE
+	}
+}
+
+// Runs until the CPU is about to start its next instruction or interrupt,
+// for tools that follow the trace of another emulator. The sound made on
+// the way is dropped.
+func StepInstruction(c *Console) {
+	apu.ClearSamples(&c.cpu.IO.APU)
+	Step(c)
+	for c.Running && c.cpu.Running && cpu.InstructionDue(&c.cpu) == false {
+		Step(c)
+	}
+}
+
+// Work done by the console, for performance counters.
+type Counters struct {
+	Instructions uint64 // CPU instructions executed since power up
+	PPUDots uint64 // PPU dots run since power up
+	CPUCycles uint64 // CPU cycles run since power up
+	PeripheralReads uint64 // Reads of the PPU, APU and controller registers since power up
+}
+
+func GetCounters(c *Console) Counters {
+	return Counters{Instructions: c.cpu.Instructions, PPUDots: c.clock.PPU_DOTS,
+		CPUCycles: c.clock.CPU_CYCLES, PeripheralReads: c.cpu.PeripheralReads}
+}
+
+// CPU registers, for debuggers and trace tools.
+type Registers struct {
+	A byte
+	X byte
+	Y byte
+	P byte
+	SP byte
+	PC uint16
+}
+
+func GetRegisters(c *Console) Registers {
+	return Registers{c.cpu.A, c.cpu.X, c.cpu.Y, c.cpu.P, c.cpu.SP, c.cpu.PC}
+}
+
+// Overwrites the registers, for tools that start the CPU somewhere else
+// than the reset vector.
+func SetRegisters(c *Console, r Registers) {
+	c.cpu.A, c.cpu.X, c.cpu.Y, c.cpu.P, c.cpu.SP, c.cpu.PC = r.A, r.X, r.Y, r.P, r.SP, r.PC
+}
+
+// The CPU stopped on a jam opcode and only a reset starts it again.
+func Halted(c *Console) bool {
+	return c.cpu.Running == false
+}
+
+// Scanline and dot the PPU is on.
+func Beam(c *Console) (int, int) {
+	return c.clock.SCANLINE, c.clock.DOT
+}
+
+// Non-standard OAM of 512 sprites for homebrew, in pages of 64 selected
+// by writes to $4020. It can only be enabled, before the game runs.
+func EnableOAMExtension(c *Console) {
+	ioports.EnableOAMExtension(&c.cpu.IO)
+}
+
+// Banks, mirroring and IRQ counter of the cartridge board.
+func MapperStatus(c *Console) mapper.Status {
+	return ioports.MapperStatus(&c.cpu.IO)
+}
+
+// NES framebuffer, 256x240 palette indexes.
+func Screen(c *Console) []int {
+	return c.ppu.SCREEN_DATA[:256*240]
+}
+
+// RGB value of a palette index.
+func PaletteColor(index int) (byte, byte, byte) {
+	return ppu.PaletteColor(index)
+}
+
+// Battery backed RAM at $6000-$7FFF. The slice aliases the console memory.
+func SRAM(c *Console) []byte {
+	return c.cpu.IO.CPU_RAM[0x6000:0x8000]
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "reflect"
+import "testing"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/snapshot"
+
+// NROM cartridge that turns NMI and rendering on and spins in a loop.
+func testCartridge(t *testing.T) *cartridge.Cartridge {
+	image := make([]byte, 16 + 16384 + 8192)
+	copy(image, "NES\x1A\x01\x01")
+	program := []byte{
+		0xA9, 0x80, // LDA #$80
+		0x8D, 0x00, 0x20, // STA $2000
+		0xA9, 0x1E, // LDA #$1E
+		0x8D, 0x01, 0x20, // STA $2001
+		0xE6, 0x00, // INC $00
+		0x4C, 0x0A, 0x80, // JMP $800A
+		0x40, // RTI
+	}
+	copy(image[16:], program)
+	copy(image[16 + 0x3FFA:], []byte{0x0F, 0x80, 0x00, 0x80, 0x0F, 0x80})
+	cart, err := cartridge.ParseRom(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cart
+}
+
+// A frame with no video driver attached must not allocate, see RunFrame.
+func TestRunFrameAllocations(t *testing.T) {
+	c := StartConsole(testCartridge(t))
+	for i := 0; i < 5; i++ {
+		RunFrame(c)
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		RunFrame(c)
+	})
+	if allocs != 0 {
+		t.Fatalf("RunFrame made %v allocations per frame", allocs)
+	}
+	if c.cpu.IO.CPU_RAM[0] == 0 {
+		t.Fatal("the test program did not run")
+	}
+}
+
+// The reset button keeps the registers, moves the stack pointer down three
+// bytes and sets I, silences the APU and clears the PPU write latch; power
+// up starts the registers over.
+func TestResetAndPowerUp(t *testing.T) {
+	cart := testCartridge(t)
+	c := StartConsole(cart)
+	for i := 0; i < 3; i++ {
+		RunFrame(c)
+	}
+	c.cpu.A, c.cpu.X, c.cpu.Y = 0x11, 0x22, 0x33
+	c.cpu.SP = 0xF0
+	c.cpu.P = 0x20
+	c.cpu.IO.APU.Pulse1.Enabled = true
+	c.cpu.IO.APU.Pulse1.Length = 10
+	c.cpu.IO.APU.Noise.Enabled = true
+	c.cpu.IO.APU.Noise.Length = 10
+	c.cpu.IO.PPU_MEMORY_STEP = 1
+
+	Reset(c)
+	if c.cpu.A != 0x11 || c.cpu.X != 0x22 || c.cpu.Y != 0x33 {
+		t.Errorf("reset changed A X Y to %02X %02X %02X", c.cpu.A, c.cpu.X, c.cpu.Y)
+	}
+	if c.cpu.SP != 0xED {
+		t.Errorf("SP %02X after reset, want ED", c.cpu.SP)
+	}
+	if c.cpu.P & 0x04 == 0 {
+		t.Errorf("P %02X after reset, I is clear", c.cpu.P)
+	}
+	if c.cpu.PC != 0x8000 {
+		t.Errorf("PC %04X after reset, want 8000", c.cpu.PC)
+	}
+	if status := apu.ReadStatus(&c.cpu.IO.APU); status & 0x1F != 0 {
+		t.Errorf("$4015 reads %02X after reset, the channels still run", status)
+	}
+	if c.cpu.IO.PPU_MEMORY_STEP != 0 {
+		t.Error("the PPU write latch survived the reset")
+	}
+
+	c.cpu.SP = 0xF0
+	InsertCartridge(c, cart, false)
+	if c.cpu.A != 0 || c.cpu.X != 0 || c.cpu.Y != 0 || c.cpu.SP != 0xFD || c.cpu.P != 0x24 {
+		t.Errorf("power up left A X Y SP P at %02X %02X %02X %02X %02X", c.cpu.A, c.cpu.X, c.cpu.Y, c.cpu.SP, c.cpu.P)
+	}
+	if c.cpu.PC != 0x8000 {
+		t.Errorf("PC %04X after power up, want 8000", c.cpu.PC)
+	}
+}
+
+// A state read back into another console gives the same bus, mapper banks
+// and APU included.
+func TestStateRoundTrip(t *testing.T) {
+	cart := testCartridge(t)
+	c := StartConsole(cart)
+	for i := 0; i < 3; i++ {
+		RunFrame(c)
+	}
+	c.cpu.IO.BOARD.PRG[0] = 5
+	c.cpu.IO.BOARD.CHR[2] = 7
+	c.cpu.IO.BOARD.Registers[1] = 0x5A
+	c.cpu.IO.BOARD.IRQCounter = 42
+	c.cpu.IO.BOARD.IRQEnabled = true
+	apu.WriteRegister(&c.cpu.IO.APU, 0x4015, 0x0F)
+	apu.WriteRegister(&c.cpu.IO.APU, 0x4003, 0x08)
+	state := WriteState(c, nil)
+
+	other := StartConsole(cart)
+	if err := ReadState(other, state); err != nil {
+		t.Fatal(err)
+	}
+	// Not part of the state
+	other.cpu.IO.CLOCK = c.cpu.IO.CLOCK
+	other.cpu.IO.ACTIVITY = c.cpu.IO.ACTIVITY
+	other.cpu.IO.COMPAT = c.cpu.IO.COMPAT
+	other.cpu.IO.POLLS = c.cpu.IO.POLLS
+	other.cpu.IO.MIRRORING_CHANGES = c.cpu.IO.MIRRORING_CHANGES
+	other.cpu.IO.PPU_A12 = c.cpu.IO.PPU_A12
+	other.cpu.IO.A12_LOW_CYCLE = c.cpu.IO.A12_LOW_CYCLE
+	other.cpu.IO.FETCH_LINE = c.cpu.IO.FETCH_LINE
+	other.cpu.IO.APU.Log = c.cpu.IO.APU.Log
+	other.cpu.IO.APU.Mixer.Samples = c.cpu.IO.APU.Mixer.Samples
+	if !reflect.DeepEqual(c.cpu.IO, other.cpu.IO) {
+		t.Error("the bus read back differs from the one written")
+	}
+	if other.cpu.IO.BOARD.PRG[0] != 5 || other.cpu.IO.BOARD.IRQCounter != 42 {
+		t.Errorf("mapper PRG bank %d, IRQ counter %d after the load", other.cpu.IO.BOARD.PRG[0], other.cpu.IO.BOARD.IRQCounter)
+	}
+
+	if err := ReadState(other, append(state, 0)); err != snapshot.ErrLong {
+		t.Errorf("a state with a trailing byte gave %v", err)
+	}
+}
+
+// A board without a memory mapper stops the CPU with a crash reason
+// instead of ending the process.
+func TestUnsupportedMapperHalts(t *testing.T) {
+	image := make([]byte, 16 + 16384)
+	copy(image, "NES\x1A\x01\x00\x10")
+	cart, err := cartridge.ParseRom(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := StartConsole(&cart)
+	RunFrame(c)
+	if Halted(c) == false || CompatibilityReport(c).Crash == "" {
+		t.Errorf("mapper 1 runs: halted %v, crash %q", Halted(c), CompatibilityReport(c).Crash)
+	}
+}
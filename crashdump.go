@@ -0,0 +1,80 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "fmt"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/cpu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+
+// State of the core when the emulation failed, written as JSON by the
+// frontends next to the Go stack, so a bug report can be read without
+// running the game. Like WriteDiagnostics it only reads memory.
+type CrashDump struct {
+	Time string `json:"time"`
+	Reason string `json:"reason"` // Panic value or why the CPU stopped
+	Rom string `json:"rom,omitempty"`
+	Hash string `json:"hash,omitempty"` // SHA-1 of the ROM
+	Mapper int `json:"mapper"`
+	CPU *CrashCPU `json:"cpu,omitempty"` // nil when the crash came before the console started
+	RecentPCs []string `json:"recent_pcs,omitempty"` // The oldest first, the last is the faulting instruction
+	Scanline int `json:"scanline"`
+	Dot int `json:"dot"`
+	CPUCycles uint64 `json:"cpu_cycles"`
+	MapperState *mapper.Status `json:"mapper_state,omitempty"`
+	Stack string `json:"stack,omitempty"` // Go stack trace
+}
+
+type CrashCPU struct {
+	A byte `json:"a"`
+	X byte `json:"x"`
+	Y byte `json:"y"`
+	P byte `json:"p"`
+	SP byte `json:"sp"`
+	PC string `json:"pc"`
+	Running bool `json:"running"`
+	Instructions uint64 `json:"instructions"`
+}
+
+// Dump of the console. Time, Rom and Stack are left to the frontend.
+func CrashDumpOf(c *Console, reason string) CrashDump {
+	var d CrashDump
+	d.Reason = reason
+	if c == nil {
+		return d
+	}
+	if c.Cart != nil {
+		d.Hash = c.Cart.Hash
+		d.Mapper = c.Cart.Header.RomType.Mapper
+	}
+
+	p := &c.cpu
+	d.CPU = &CrashCPU{p.A, p.X, p.Y, p.P, p.SP, fmt.Sprintf("$%04X", p.PC), p.Running, p.Instructions}
+	for _, pc := range cpu.RecentPCs(p) {
+		d.RecentPCs = append(d.RecentPCs, fmt.Sprintf("$%04X", pc))
+	}
+	d.Scanline = c.clock.SCANLINE
+	d.Dot = c.clock.DOT
+	d.CPUCycles = c.clock.CPU_CYCLES
+	if c.Cart != nil {
+		status := MapperStatus(c)
+		d.MapperState = &status
+	}
+	return d
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+
+// Traces the CPU into the debugger. InsertCartridge keeps it.
+func SetDebugger(c *Console, d debug.Debug) {
+	c.cpu.D = d
+}
+
+// Dumps the PPU register writes, see debug.OpenPPUDumpFile.
+func SetPPUDump(c *Console, d debug.PPUDebug) {
+	c.ppuDebug = d
+}
+
+// Logs the accesses to the hardware registers, see debug.StartAccessLog.
+// InsertCartridge keeps the log.
+func SetAccessLog(c *Console, log debug.AccessLog) {
+	c.cpu.IO.ACCESS_LOG = log
+}
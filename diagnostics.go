@@ -0,0 +1,60 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "fmt"
+import "io"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/cpu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+
+// Dump of the CPU registers, the clock and the last instructions run, for
+// bug reports and for the frontend watchdog. It only reads memory without
+// side effects, so it can be written from another goroutine while the
+// console is stuck; the values may then be a few cycles apart.
+func WriteDiagnostics(w io.Writer, c *Console) {
+	p := &c.cpu
+	fmt.Fprintf(w, "CPU:        A:%02X X:%02X Y:%02X P:%02X SP:%02X PC:%04X running: %t\n",
+		p.A, p.X, p.Y, p.P, p.SP, p.PC, p.Running)
+	fmt.Fprintf(w, "Clock:      CPU cycle %d, scanline %d, dot %d, PPU dots %d\n",
+		c.clock.CPU_CYCLES, c.clock.SCANLINE, c.clock.DOT, c.clock.PPU_DOTS)
+	fmt.Fprintf(w, "Interrupts: NMI pending %t, APU frame IRQ %t, DMC IRQ %t\n",
+		c.cpu.IO.NMI, c.cpu.IO.APU.FrameIRQ, c.cpu.IO.APU.DMC.IRQ)
+	fmt.Fprintf(w, "Executed:   %d instructions\n", p.Instructions)
+	fmt.Fprintf(w, "Last instructions:\n")
+	for _, pc := range cpu.RecentPCs(p) {
+		fmt.Fprintf(w, "  %04X  %s\n", pc, disassembleROM(c, pc))
+	}
+}
+
+// Instruction at a PRG-ROM address, or "?" for RAM and registers.
+func disassembleROM(c *Console, pc uint16) string {
+	var code [3]byte
+	for i := range code {
+		region, offset, err := mapper.MemoryMapper(&c.cpu.IO.BOARD, c.Cart, pc + uint16(i))
+		if err != nil || region != mapper.RegionPRG {
+			return "?"
+		}
+		code[i] = cartridge.ReadPRG(c.Cart, offset)
+	}
+	text, _ := debug.Disassemble(code[:], pc)
+	return text
+}
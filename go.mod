@@ -0,0 +1,8 @@
+module github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator
+
+go 1.21
+
+require (
+	github.com/go-gl/gl v0.0.0-20260331235117-4566fea9a276
+	github.com/veandco/go-sdl2 v0.4.40
+)
@@ -0,0 +1,54 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "errors"
+
+// Hardcore mode, for achievements and fair races. While it is on the core
+// refuses everything that changes the game from outside, so no frontend
+// can do it by mistake:
+//
+//	loading savestates (LoadState, ReadState), which also rules out
+//	rewinding and frame replays;
+//	writing memory (WriteMemory);
+//	feeding recorded controller latches (ReplayPolls), that is movie
+//	playback;
+//	keeping the work RAM over a cartridge swap (InsertCartridge).
+//
+// Whatever is added to change the game or its speed, cheats or fast
+// forward, must check it too. Taking savestates is still allowed, they
+// just cannot be loaded.
+//
+// Once on, hardcore mode stays on for the life of the console: turning it
+// off is refused, start a new console instead.
+
+var ErrHardcore = errors.New("not allowed in hardcore mode")
+
+func SetHardcore(c *Console, enable bool) error {
+	if c.hardcore && !enable {
+		return ErrHardcore
+	}
+	c.hardcore = enable
+	return nil
+}
+
+// Frontends query it to hide or disable the features it rules out.
+func Hardcore(c *Console) bool {
+	return c.hardcore
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "testing"
+
+func TestHardcore(t *testing.T) {
+	cart := testCartridge(t)
+	c := StartConsole(cart)
+	RunFrame(c)
+	state := SaveState(c)
+	data := WriteState(c, nil)
+	if err := SetHardcore(c, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadState(c, state); err != ErrHardcore {
+		t.Errorf("LoadState gave %v", err)
+	}
+	if err := ReadState(c, data); err != ErrHardcore {
+		t.Errorf("ReadState gave %v", err)
+	}
+	if err := WriteMemory(c, "CPU RAM", 0, 1); err != ErrHardcore {
+		t.Errorf("WriteMemory gave %v", err)
+	}
+	if err := ReplayPolls(c, [][2]byte{{0x80, 0}}); err != ErrHardcore {
+		t.Errorf("ReplayPolls gave %v", err)
+	}
+
+	c.cpu.IO.CPU_RAM[0x10] = 0x55
+	InsertCartridge(c, cart, true)
+	if c.cpu.IO.CPU_RAM[0x10] != 0 {
+		t.Error("the work RAM survived a cartridge swap")
+	}
+	if !Hardcore(c) {
+		t.Error("hardcore mode did not survive the cartridge swap")
+	}
+
+	if err := SetHardcore(c, false); err != ErrHardcore || !Hardcore(c) {
+		t.Errorf("turning hardcore mode off gave %v", err)
+	}
+}
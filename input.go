@@ -0,0 +1,97 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+
+// Buttons of a standard controller.
+type Input byte
+
+const (
+	ButtonA Input = 1 << ioports.BUTTON_A
+	ButtonB Input = 1 << ioports.BUTTON_B
+	ButtonSelect Input = 1 << ioports.BUTTON_SELECT
+	ButtonStart Input = 1 << ioports.BUTTON_START
+	ButtonUp Input = 1 << ioports.BUTTON_UP
+	ButtonDown Input = 1 << ioports.BUTTON_DOWN
+	ButtonLeft Input = 1 << ioports.BUTTON_LEFT
+	ButtonRight Input = 1 << ioports.BUTTON_RIGHT
+)
+
+// Sets the buttons held on the controller in port 0 or 1.
+func SetInput(c *Console, port int, buttons Input) {
+	c.cpu.IO.JOYPAD[port].BUTTONS = byte(buttons)
+}
+
+func GetInput(c *Console, port int) Input {
+	return Input(c.cpu.IO.JOYPAD[port].BUTTONS)
+}
+
+// Famicom microphone on the second controller.
+func SetMicrophone(c *Console, active bool) {
+	c.cpu.IO.MICROPHONE = active
+}
+
+func Microphone(c *Console) bool {
+	return c.cpu.IO.MICROPHONE
+}
+
+// Frames run, lag frames, where the game did not read the controllers,
+// and controller latches since power up. Savestates keep them.
+type FrameCounters struct {
+	Frames uint64
+	LagFrames uint64
+	Latches uint64
+}
+
+func GetFrameCounters(c *Console) FrameCounters {
+	n := c.cpu.IO.COUNTERS
+	return FrameCounters{Frames: n.FRAMES, LagFrames: n.LAG_FRAMES, Latches: n.LATCHES}
+}
+
+// Starts or stops keeping the buttons of each controller latch, see Polls.
+func SetPollRecording(c *Console, enable bool) {
+	log := &c.cpu.IO.POLLS
+	log.RECORD = enable
+	log.REPLAY = false
+	log.LATCHES = log.LATCHES[:0]
+}
+
+// Buttons of both controllers at each latch since the last call, and starts
+// over for the next frame.
+func Polls(c *Console) [][2]byte {
+	log := &c.cpu.IO.POLLS
+	polls := append([][2]byte(nil), log.LATCHES...)
+	log.LATCHES = log.LATCHES[:0]
+	return polls
+}
+
+// Gives the latches of the next frame the buttons of polls, in order. Once
+// they run out the buttons set with SetInput are used. Refused in hardcore
+// mode.
+func ReplayPolls(c *Console, polls [][2]byte) error {
+	if c.hardcore {
+		return ErrHardcore
+	}
+	log := &c.cpu.IO.POLLS
+	log.REPLAY = len(polls) > 0
+	log.LATCHES = polls
+	log.NEXT = 0
+	return nil
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package apu
+
+// Audio processing unit of the 2A03: two pulse channels, a triangle, a
+// noise channel and the DMC, the frame counter that clocks their envelopes
+// and length counters, and the mixer. Process runs once per CPU cycle.
+
+// Register write, stamped with the APU cycle counter.
+type RegisterWrite struct {
+	Cycle uint64
+	Addr uint16
+	Value byte
+}
+
+// Writes made since ClearSamples, for the music loggers.
+type RegisterLog struct {
+	Enable bool
+	Writes []RegisterWrite
+}
+
+type APU struct {
+	Region int
+	Timing Timing
+
+	Pulse1 Pulse
+	Pulse2 Pulse
+	Triangle Triangle
+	Noise Noise
+	DMC DMC
+
+	Cycle uint64 // CPU cycles since power up
+	FiveStep bool
+	IRQInhibit bool
+	FrameIRQ bool
+	FrameCycle int // CPU cycles into the frame counter sequence
+	FrameStep int
+
+	Mixer Mixer
+	Log RegisterLog
+}
+
+func StartAPU(region int, sampleRate int) APU {
+	var a APU
+	a.Region = region
+	a.Timing = Timings[region]
+	a.Pulse1 = startPulse(true)
+	a.Pulse2 = startPulse(false)
+	a.Noise = startNoise(&a.Timing)
+	a.DMC = startDMC(&a.Timing)
+	a.Mixer = startMixer(&a.Timing, sampleRate)
+	return a
+}
+
+// Reset button: the channels are silenced as by a write of 0 to $4015, the
+// triangle goes back to the start of its sequence and the DMC output keeps
+// its lowest bit only. The frame counter restarts in the mode last written
+// to $4017.
+func Reset(a *APU) {
+	writeStatus(a, 0)
+	a.Triangle.Step = 0
+	a.DMC.Output &= 1
+	a.FrameIRQ = false
+	a.FrameCycle = 0
+	a.FrameStep = 0
+}
+
+// Writes to $4000-$4017, except the $4014 and $4016 ones.
+func WriteRegister(a *APU, addr uint16, value byte) {
+	if a.Log.Enable {
+		a.Log.Writes = append(a.Log.Writes, RegisterWrite{a.Cycle, addr, value})
+	}
+	switch {
+		case addr >= 0x4000 && addr <= 0x4003:
+			writePulse(&a.Pulse1, addr - 0x4000, value)
+		case addr >= 0x4004 && addr <= 0x4007:
+			writePulse(&a.Pulse2, addr - 0x4004, value)
+		case addr >= 0x4008 && addr <= 0x400B:
+			writeTriangle(&a.Triangle, addr - 0x4008, value)
+		case addr >= 0x400C && addr <= 0x400F:
+			writeNoise(&a.Noise, addr - 0x400C, value)
+		case addr >= 0x4010 && addr <= 0x4013:
+			writeDMC(&a.DMC, addr - 0x4010, value)
+		case addr == 0x4015:
+			writeStatus(a, value)
+		case addr == 0x4017:
+			writeFrameCounter(a, value)
+	}
+}
+
+// Enabling a channel does not reload its length counter, disabling it
+// clears the counter right away. The DMC restarts its sample when it was
+// over and stops it when disabled; the write acknowledges its IRQ.
+func writeStatus(a *APU, value byte) {
+	a.Pulse1.Enabled = value & 0x01 != 0
+	a.Pulse2.Enabled = value & 0x02 != 0
+	a.Triangle.Enabled = value & 0x04 != 0
+	a.Noise.Enabled = value & 0x08 != 0
+	if a.Pulse1.Enabled == false {
+		a.Pulse1.Length = 0
+	}
+	if a.Pulse2.Enabled == false {
+		a.Pulse2.Length = 0
+	}
+	if a.Triangle.Enabled == false {
+		a.Triangle.Length = 0
+	}
+	if a.Noise.Enabled == false {
+		a.Noise.Length = 0
+	}
+
+	a.DMC.IRQ = false
+	if value & 0x10 == 0 {
+		a.DMC.BytesRemaining = 0
+	} else if a.DMC.BytesRemaining == 0 {
+		a.DMC.CurrentAddr = a.DMC.SampleAddr
+		a.DMC.BytesRemaining = a.DMC.SampleLength
+	}
+}
+
+// The letterless 2A03 of the first Famicoms has no short noise mode, the
+// mode bit of $400E is ignored.
+func SetShortNoise(a *APU, enable bool) {
+	a.Noise.ShortMode = enable
+}
+
+// The frame counter or the DMC is holding the IRQ line low.
+func IRQ(a *APU) bool {
+	return a.FrameIRQ || a.DMC.IRQ
+}
+
+func writeFrameCounter(a *APU, value byte) {
+	a.FiveStep = value & 0x80 != 0
+	a.IRQInhibit = value & 0x40 != 0
+	if a.IRQInhibit {
+		a.FrameIRQ = false
+	}
+	a.FrameCycle = 0
+	a.FrameStep = 0
+	if a.FiveStep {
+		clockQuarterFrame(a)
+		clockHalfFrame(a)
+	}
+}
+
+// $4015 reads: the length counters that are running, the DMC sample in
+// progress and the two IRQs. The read acknowledges the frame IRQ only.
+func ReadStatus(a *APU) byte {
+	var value byte
+	if a.Pulse1.Length > 0 {
+		value |= 0x01
+	}
+	if a.Pulse2.Length > 0 {
+		value |= 0x02
+	}
+	if a.Triangle.Length > 0 {
+		value |= 0x04
+	}
+	if a.Noise.Length > 0 {
+		value |= 0x08
+	}
+	if a.DMC.BytesRemaining > 0 {
+		value |= 0x10
+	}
+	if a.FrameIRQ {
+		value |= 0x40
+	}
+	if a.DMC.IRQ {
+		value |= 0x80
+	}
+	a.FrameIRQ = false
+	return value
+}
+
+func clockQuarterFrame(a *APU) {
+	clockEnvelope(&a.Pulse1.Envelope)
+	clockEnvelope(&a.Pulse2.Envelope)
+	clockEnvelope(&a.Noise.Envelope)
+	clockLinear(&a.Triangle)
+}
+
+func clockLength(length *byte, halt bool) {
+	if *length > 0 && halt == false {
+		*length--
+	}
+}
+
+func clockHalfFrame(a *APU) {
+	clockLength(&a.Pulse1.Length, a.Pulse1.Envelope.Loop)
+	clockLength(&a.Pulse2.Length, a.Pulse2.Envelope.Loop)
+	clockLength(&a.Triangle.Length, a.Triangle.Control)
+	clockLength(&a.Noise.Length, a.Noise.Envelope.Loop)
+	clockSweep(&a.Pulse1)
+	clockSweep(&a.Pulse2)
+}
+
+func clockFrameCounter(a *APU) {
+	a.FrameCycle++
+	if a.FiveStep {
+		if a.FrameCycle != a.Timing.FrameSteps5[a.FrameStep] {
+			return
+		}
+		if a.FrameStep != 3 {
+			clockQuarterFrame(a)
+		}
+		if a.FrameStep == 1 || a.FrameStep == 4 {
+			clockHalfFrame(a)
+		}
+		a.FrameStep++
+		if a.FrameStep == len(a.Timing.FrameSteps5) {
+			a.FrameStep = 0
+			a.FrameCycle = 0
+		}
+		return
+	}
+
+	if a.FrameCycle != a.Timing.FrameSteps4[a.FrameStep] {
+		return
+	}
+	clockQuarterFrame(a)
+	if a.FrameStep == 1 || a.FrameStep == 3 {
+		clockHalfFrame(a)
+	}
+	if a.FrameStep == 3 && a.IRQInhibit == false {
+		a.FrameIRQ = true
+	}
+	a.FrameStep++
+	if a.FrameStep == len(a.Timing.FrameSteps4) {
+		a.FrameStep = 0
+		a.FrameCycle = 0
+	}
+}
+
+// Runs one CPU cycle.
+func Process(a *APU) {
+	a.Cycle++
+	clockFrameCounter(a)
+	clockTriangleTimer(&a.Triangle)
+	clockNoiseTimer(&a.Noise)
+	clockDMCTimer(&a.DMC)
+	if a.Cycle % 2 == 0 {
+		clockPulseTimer(&a.Pulse1)
+		clockPulseTimer(&a.Pulse2)
+	}
+	mix(a)
+}
+
+// Reads of the CPU test registers with test mode enabled: $4018 holds the
+// outputs of both pulse channels, $4019 the triangle and the noise, $401A
+// the DMC. Returns false for the other test registers.
+func ReadTestRegister(a *APU, addr uint16) (byte, bool) {
+	switch(addr) {
+		case 0x4018:
+			return pulseOutput(&a.Pulse1) | pulseOutput(&a.Pulse2) << 4, true
+		case 0x4019:
+			return triangleOutput(&a.Triangle) | noiseOutput(&a.Noise) << 4, true
+		case 0x401A:
+			return a.DMC.Output, true
+	}
+	return 0, false
+}
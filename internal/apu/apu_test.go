@@ -0,0 +1,85 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package apu
+
+import "testing"
+
+// APU with the four channels enabled and their length counters loaded.
+func runningAPU() APU {
+	a := StartAPU(REGION_NTSC, SampleRate)
+	WriteRegister(&a, 0x4015, 0x0F)
+	for _, addr := range []uint16{0x4003, 0x4007, 0x400B, 0x400F} {
+		WriteRegister(&a, addr, 0x08)
+	}
+	return a
+}
+
+// Clearing an enable bit of $4015 silences the channel at once, setting it
+// again does not bring the length counter back.
+func TestStatusEnableClearsLength(t *testing.T) {
+	for bit := uint(0); bit < 4; bit++ {
+		a := runningAPU()
+		if status := ReadStatus(&a); status != 0x0F {
+			t.Fatalf("status %02X after loading the lengths, want 0F", status)
+		}
+		WriteRegister(&a, 0x4015, 0x0F &^ (1 << bit))
+		if status := ReadStatus(&a); status != 0x0F &^ (1 << bit) {
+			t.Errorf("status %02X after disabling channel %d", status, bit)
+		}
+		WriteRegister(&a, 0x4015, 0x0F)
+		if status := ReadStatus(&a); status != 0x0F &^ (1 << bit) {
+			t.Errorf("status %02X after enabling channel %d again", status, bit)
+		}
+	}
+
+	// A disabled channel ignores length loads
+	a := StartAPU(REGION_NTSC, SampleRate)
+	WriteRegister(&a, 0x4003, 0x08)
+	if status := ReadStatus(&a); status != 0 {
+		t.Errorf("status %02X after a length load with the channels disabled", status)
+	}
+}
+
+// The DMC bit follows the bytes left of the sample. The read acknowledges
+// the frame IRQ but not the DMC one, which a $4015 write acknowledges.
+func TestStatusReadback(t *testing.T) {
+	a := StartAPU(REGION_NTSC, SampleRate)
+	WriteRegister(&a, 0x4013, 0x01)
+	WriteRegister(&a, 0x4015, 0x10)
+	if status := ReadStatus(&a); status != 0x10 {
+		t.Errorf("status %02X with a sample playing, want 10", status)
+	}
+	WriteRegister(&a, 0x4015, 0x00)
+	if status := ReadStatus(&a); status != 0x00 {
+		t.Errorf("status %02X after stopping the sample, want 00", status)
+	}
+
+	a.FrameIRQ = true
+	a.DMC.IRQ = true
+	if status := ReadStatus(&a); status != 0xC0 {
+		t.Errorf("status %02X with both IRQs, want C0", status)
+	}
+	if status := ReadStatus(&a); status != 0x80 {
+		t.Errorf("status %02X on the second read, want 80", status)
+	}
+	WriteRegister(&a, 0x4015, 0x00)
+	if status := ReadStatus(&a); status != 0x00 {
+		t.Errorf("status %02X after a $4015 write, want 00", status)
+	}
+}
@@ -0,0 +1,400 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package apu
+
+// Sound channels of the 2A03. The timers count in CPU cycles for the
+// triangle and in APU cycles (every other CPU cycle) for the others.
+
+var LengthTable = [32]byte{
+	10, 254, 20, 2, 40, 4, 80, 6, 160, 8, 60, 10, 14, 12, 26, 14,
+	12, 16, 24, 18, 48, 20, 96, 22, 192, 24, 72, 26, 16, 28, 32, 30,
+}
+
+var dutyTable = [4][8]byte{
+	{0, 1, 0, 0, 0, 0, 0, 0},
+	{0, 1, 1, 0, 0, 0, 0, 0},
+	{0, 1, 1, 1, 1, 0, 0, 0},
+	{1, 0, 0, 1, 1, 1, 1, 1},
+}
+
+var triangleTable = [32]byte{
+	15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0,
+	0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+}
+
+type Envelope struct {
+	Start bool
+	Loop bool // Also halts the length counter
+	Constant bool
+	Period byte // Also the constant volume
+	Divider byte
+	Decay byte
+}
+
+type Pulse struct {
+	Enabled bool
+	Negate1 bool // Pulse 1 negates the sweep with the ones' complement
+	Duty byte
+	DutyStep byte
+	Timer uint16
+	Period uint16
+	Length byte
+	Envelope Envelope
+
+	SweepEnabled bool
+	SweepPeriod byte
+	SweepNegate bool
+	SweepShift byte
+	SweepReload bool
+	SweepDivider byte
+}
+
+type Triangle struct {
+	Enabled bool
+	Control bool // Also halts the length counter
+	Timer uint16
+	Period uint16
+	Step byte
+	Length byte
+	LinearPeriod byte
+	Linear byte
+	LinearReload bool
+}
+
+type Noise struct {
+	Periods [16]uint16 // Of the region, see Timing
+	Enabled bool
+	Mode bool
+	ShortMode bool // The mode bit selects the 93-step sequence, not on the letterless 2A03
+	Shift uint16
+	Timer uint16
+	Period uint16
+	Length byte
+	Envelope Envelope
+}
+
+// Delta modulation channel. The timer and the output unit run from the
+// rate table of the region. The APU has no access to memory: when the
+// buffer is empty SampleDue asks for the byte at CurrentAddr, which the
+// DMA of the CPU side fetches and hands over with FillSample.
+type DMC struct {
+	Rates [16]uint16 // Of the region, see Timing
+	IRQEnabled bool
+	Loop bool
+	Rate byte
+	Timer uint16
+	Output byte
+	SampleAddr uint16
+	SampleLength uint16
+	CurrentAddr uint16
+	BytesRemaining uint16
+	IRQ bool
+
+	Shift byte
+	BitsRemaining byte
+	Silence bool
+	Buffer byte
+	BufferEmpty bool
+}
+
+func startDMC(t *Timing) DMC {
+	var d DMC
+	d.Rates = t.DMCRates
+	d.Timer = d.Rates[0]
+	d.BitsRemaining = 8
+	d.Silence = true
+	d.BufferEmpty = true
+	return d
+}
+
+func writeDMC(d *DMC, reg uint16, value byte) {
+	switch(reg) {
+		case 0:
+			d.IRQEnabled = value & 0x80 != 0
+			d.Loop = value & 0x40 != 0
+			d.Rate = value & 0x0F
+			if d.IRQEnabled == false {
+				d.IRQ = false
+			}
+		case 1:
+			d.Output = value & 0x7F
+		case 2:
+			d.SampleAddr = 0xC000 + uint16(value)*64
+		case 3:
+			d.SampleLength = uint16(value)*16 + 1
+	}
+}
+
+// True when the memory reader waits for the byte at CurrentAddr.
+func SampleDue(d *DMC) bool {
+	return d.BufferEmpty && d.BytesRemaining > 0
+}
+
+// Takes the byte at CurrentAddr into the empty buffer and moves to the
+// next one. The end of the sample restarts it or raises the IRQ.
+func FillSample(d *DMC, value byte) {
+	d.Buffer = value
+	d.BufferEmpty = false
+	if d.CurrentAddr == 0xFFFF {
+		d.CurrentAddr = 0x8000
+	} else {
+		d.CurrentAddr++
+	}
+	d.BytesRemaining--
+	if d.BytesRemaining == 0 {
+		if d.Loop {
+			d.CurrentAddr = d.SampleAddr
+			d.BytesRemaining = d.SampleLength
+		} else if d.IRQEnabled {
+			d.IRQ = true
+		}
+	}
+}
+
+// Runs every CPU cycle, the rates are in CPU cycles.
+func clockDMCTimer(d *DMC) {
+	if d.Timer > 1 {
+		d.Timer--
+		return
+	}
+	d.Timer = d.Rates[d.Rate]
+
+	if d.Silence == false {
+		if d.Shift & 1 == 1 {
+			if d.Output <= 125 {
+				d.Output += 2
+			}
+		} else if d.Output >= 2 {
+			d.Output -= 2
+		}
+	}
+	d.Shift >>= 1
+	d.BitsRemaining--
+	if d.BitsRemaining == 0 {
+		d.BitsRemaining = 8
+		d.Silence = d.BufferEmpty
+		if d.BufferEmpty == false {
+			d.Shift = d.Buffer
+			d.BufferEmpty = true
+		}
+	}
+}
+
+func clockEnvelope(e *Envelope) {
+	if e.Start {
+		e.Start = false
+		e.Decay = 15
+		e.Divider = e.Period
+		return
+	}
+	if e.Divider > 0 {
+		e.Divider--
+		return
+	}
+	e.Divider = e.Period
+	if e.Decay > 0 {
+		e.Decay--
+	} else if e.Loop {
+		e.Decay = 15
+	}
+}
+
+func envelopeVolume(e *Envelope) byte {
+	if e.Constant {
+		return e.Period
+	}
+	return e.Decay
+}
+
+func writeEnvelope(e *Envelope, value byte) {
+	e.Loop = value & 0x20 != 0
+	e.Constant = value & 0x10 != 0
+	e.Period = value & 0x0F
+}
+
+func loadLength(enabled bool, length *byte, value byte) {
+	if enabled {
+		*length = LengthTable[value >> 3]
+	}
+}
+
+// Pulse
+
+// Pulse 1 (first) and pulse 2 differ in the negate of the sweep.
+func startPulse(first bool) Pulse {
+	var p Pulse
+	p.Negate1 = first
+	return p
+}
+
+func writePulse(p *Pulse, reg uint16, value byte) {
+	switch(reg) {
+		case 0:
+			p.Duty = value >> 6
+			writeEnvelope(&p.Envelope, value)
+		case 1:
+			p.SweepEnabled = value & 0x80 != 0
+			p.SweepPeriod = (value >> 4) & 7
+			p.SweepNegate = value & 0x08 != 0
+			p.SweepShift = value & 7
+			p.SweepReload = true
+		case 2:
+			p.Period = (p.Period & 0x700) | uint16(value)
+		case 3:
+			p.Period = (p.Period & 0xFF) | (uint16(value & 7) << 8)
+			loadLength(p.Enabled, &p.Length, value)
+			p.DutyStep = 0
+			p.Envelope.Start = true
+	}
+}
+
+func clockPulseTimer(p *Pulse) {
+	if p.Timer == 0 {
+		p.Timer = p.Period
+		p.DutyStep = (p.DutyStep + 1) & 7
+	} else {
+		p.Timer--
+	}
+}
+
+func sweepTarget(p *Pulse) int {
+	change := int(p.Period >> p.SweepShift)
+	if p.SweepNegate {
+		if p.Negate1 {
+			return int(p.Period) - change - 1
+		}
+		return int(p.Period) - change
+	}
+	return int(p.Period) + change
+}
+
+func pulseMuted(p *Pulse) bool {
+	return p.Period < 8 || sweepTarget(p) > 0x7FF
+}
+
+func clockSweep(p *Pulse) {
+	if p.SweepDivider == 0 && p.SweepEnabled && p.SweepShift > 0 && pulseMuted(p) == false {
+		target := sweepTarget(p)
+		if target < 0 {
+			target = 0
+		}
+		p.Period = uint16(target)
+	}
+	if p.SweepDivider == 0 || p.SweepReload {
+		p.SweepDivider = p.SweepPeriod
+		p.SweepReload = false
+	} else {
+		p.SweepDivider--
+	}
+}
+
+func pulseOutput(p *Pulse) byte {
+	if p.Length == 0 || pulseMuted(p) || dutyTable[p.Duty][p.DutyStep] == 0 {
+		return 0
+	}
+	return envelopeVolume(&p.Envelope)
+}
+
+// Triangle
+
+func writeTriangle(t *Triangle, reg uint16, value byte) {
+	switch(reg) {
+		case 0:
+			t.Control = value & 0x80 != 0
+			t.LinearPeriod = value & 0x7F
+		case 2:
+			t.Period = (t.Period & 0x700) | uint16(value)
+		case 3:
+			t.Period = (t.Period & 0xFF) | (uint16(value & 7) << 8)
+			loadLength(t.Enabled, &t.Length, value)
+			t.LinearReload = true
+	}
+}
+
+func clockTriangleTimer(t *Triangle) {
+	if t.Timer == 0 {
+		t.Timer = t.Period
+		if t.Length > 0 && t.Linear > 0 {
+			t.Step = (t.Step + 1) & 31
+		}
+	} else {
+		t.Timer--
+	}
+}
+
+func clockLinear(t *Triangle) {
+	if t.LinearReload {
+		t.Linear = t.LinearPeriod
+	} else if t.Linear > 0 {
+		t.Linear--
+	}
+	if t.Control == false {
+		t.LinearReload = false
+	}
+}
+
+// The sequencer keeps its last step when it is halted, which is what
+// the channel outputs. Ultrasonic periods are left running as they are.
+func triangleOutput(t *Triangle) byte {
+	return triangleTable[t.Step]
+}
+
+// Noise
+
+func startNoise(t *Timing) Noise {
+	var n Noise
+	n.Periods = t.NoisePeriods
+	n.ShortMode = true
+	n.Shift = 1
+	return n
+}
+
+func writeNoise(n *Noise, reg uint16, value byte) {
+	switch(reg) {
+		case 0:
+			writeEnvelope(&n.Envelope, value)
+		case 2:
+			n.Mode = value & 0x80 != 0
+			n.Period = n.Periods[value & 0x0F]
+		case 3:
+			loadLength(n.Enabled, &n.Length, value)
+			n.Envelope.Start = true
+	}
+}
+
+func clockNoiseTimer(n *Noise) {
+	if n.Timer == 0 {
+		n.Timer = n.Period
+		var bit uint16 = 1
+		if n.Mode && n.ShortMode {
+			bit = 6
+		}
+		feedback := (n.Shift & 1) ^ ((n.Shift >> bit) & 1)
+		n.Shift = (n.Shift >> 1) | (feedback << 14)
+	} else {
+		n.Timer--
+	}
+}
+
+func noiseOutput(n *Noise) byte {
+	if n.Length == 0 || n.Shift & 1 == 1 {
+		return 0
+	}
+	return envelopeVolume(&n.Envelope)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package apu
+
+import "math"
+
+// Sound chips of cartridges. Each one is mixed against the 2A03 with its
+// own gain, in millibels against an APU pulse at the same volume like the
+// mixe chunk of NSF2 files. The defaults are the ones of that chunk; a
+// chip emulation adds its output, scaled by ExpansionGain, to
+// Mixer.Expansion every cycle.
+//
+// None of the chips is emulated yet, so nothing calls ExpansionGain and
+// Mixer.Expansion stays 0: the gains and the volume are the settings the
+// chips will use, and have no effect on the sound for now.
+
+const (
+	EXPANSION_VRC6 = 0
+	EXPANSION_N163 = 1
+	EXPANSION_FDS = 2
+	EXPANSION_5B = 3
+	EXPANSION_MMC5 = 4
+	EXPANSIONS = 5
+)
+
+var ExpansionNames = [EXPANSIONS]string{"vrc6", "n163", "fds", "5b", "mmc5"}
+
+var DefaultExpansionMix = [EXPANSIONS]int{0, 1100, 700, -130, 0}
+
+var ExpansionMix = DefaultExpansionMix
+
+// Volume of all the expansion chips, 0 to 1.
+var ExpansionVolume float64 = 1.0
+
+// The console mixes the audio of the cartridge, false on the NES-101
+// top-loader, which has no way to bring it to the output.
+var ExpansionOutput bool = true
+
+// Chip of the boards that have one, by iNES mapper number.
+func ExpansionChip(mapper int) (int, bool) {
+	switch mapper {
+		case 24, 26:
+			return EXPANSION_VRC6, true
+		case 19:
+			return EXPANSION_N163, true
+		case 69:
+			return EXPANSION_5B, true
+		case 5:
+			return EXPANSION_MMC5, true
+	}
+	return 0, false
+}
+
+// Chip of a name like "fds". Returns false for unknown names.
+func ParseExpansion(name string) (int, bool) {
+	for chip, n := range ExpansionNames {
+		if n == name {
+			return chip, true
+		}
+	}
+	return 0, false
+}
+
+func ExpansionGain(chip int) float64 {
+	if ExpansionOutput == false {
+		return 0
+	}
+	return ExpansionVolume * math.Pow(10, float64(ExpansionMix[chip]) / 2000)
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package apu
+
+import "math"
+
+// Mixer. The channels are mixed every CPU cycle with the nonlinear DAC
+// curves of the 2A03, resampled to the output rate (see resample.go) and
+// run through a high-pass filter like the one of the console output. The
+// channels can also be kept apart (stems), each one as it would sound
+// alone. Decimation skips the resampling and takes the channels only when
+// a sample is due, which is cheaper but lets high notes alias.
+//
+// Games that write $4011 directly can make the DMC jump by up to 127 steps
+// at once, which sounds like a pop. With SmoothDMC a jump bigger than the
+// steps of the sample playback is spread over a few output samples.
+
+const SampleRate = 44100
+
+const (
+	CHANNEL_PULSE1 = 0
+	CHANNEL_PULSE2 = 1
+	CHANNEL_TRIANGLE = 2
+	CHANNEL_NOISE = 3
+	CHANNEL_DMC = 4
+	CHANNELS = 5
+)
+
+var ChannelNames = [CHANNELS]string{"pulse1", "pulse2", "triangle", "noise", "dmc"}
+
+var pulseTable [31]float64
+var tndTable [203]float64
+
+func init() {
+	for i := 1; i < len(pulseTable); i++ {
+		pulseTable[i] = 95.52 / (8128.0 / float64(i) + 100)
+	}
+	for i := 1; i < len(tndTable); i++ {
+		tndTable[i] = 163.67 / (24329.0 / float64(i) + 100)
+	}
+}
+
+type highPass struct {
+	Input float64
+	Output float64
+}
+
+type Mixer struct {
+	SampleRate int
+	Step float64 // CPU cycles per output sample
+	Elapsed float64
+	Sum float64 // Mix integrated since the last sample
+	Quality int // QUALITY_LINEAR or QUALITY_SINC
+	Filter highPass
+	Samples []int16 // Output since ClearSamples
+	Decimate bool // Mix once per output sample instead of every cycle
+	SmoothDMC bool // Ramp the big jumps of the DMC output
+	DMCLevel float64 // DMC output after the ramp
+	DMCRamp float64 // Change of DMCLevel per cycle, 0 when it follows the output
+	Expansion float64 // Output of the cartridge sound chip, see ExpansionGain
+
+	Stems bool
+	StemSums [CHANNELS]float64
+	StemFilters [CHANNELS]highPass
+	StemSamples [CHANNELS][]int16
+
+	sinc *sincState // Filter of QUALITY_SINC, see sincFor
+}
+
+func startMixer(t *Timing, sampleRate int) Mixer {
+	var m Mixer
+	m.SampleRate = sampleRate
+	m.Step = float64(t.CPUFrequency) / float64(sampleRate)
+	return m
+}
+
+// 90Hz first order high-pass.
+func filterSample(f *highPass, sampleRate int, input float64) float64 {
+	rc := 1 / (2 * 3.14159265 * 90)
+	k := rc / (rc + 1 / float64(sampleRate))
+	f.Output = k * (f.Output + input - f.Input)
+	f.Input = input
+	return f.Output
+}
+
+func toSample(v float64) int16 {
+	v = v * 32767
+	if v > 32767 {
+		v = 32767
+	}
+	if v < -32768 {
+		v = -32768
+	}
+	return int16(v)
+}
+
+// Output samples a $4011 jump is spread over.
+const dmcRampSamples = 4
+
+// Moves DMCLevel one cycle towards the DMC output.
+func rampDMC(m *Mixer, output byte) {
+	target := float64(output)
+	if m.SmoothDMC == false {
+		m.DMCLevel = target
+		m.DMCRamp = 0
+		return
+	}
+	diff := target - m.DMCLevel
+	if m.DMCRamp == 0 || (diff > 0) != (m.DMCRamp > 0) {
+		if math.Abs(diff) <= 2 {
+			m.DMCLevel = target
+			m.DMCRamp = 0
+			return
+		}
+		m.DMCRamp = diff / (dmcRampSamples * m.Step)
+	}
+	m.DMCLevel += m.DMCRamp
+	if (m.DMCRamp > 0 && m.DMCLevel >= target) || (m.DMCRamp < 0 && m.DMCLevel <= target) {
+		m.DMCLevel = target
+		m.DMCRamp = 0
+	}
+}
+
+// Nonlinear DAC of the triangle, noise and DMC group with a fractional DMC
+// level.
+func tndOutput(t int, n int, d float64) float64 {
+	i := 3*t + 2*n + int(d)
+	if i + 1 >= len(tndTable) {
+		return tndTable[len(tndTable)-1]
+	}
+	frac := d - math.Floor(d)
+	return tndTable[i] + frac * (tndTable[i+1] - tndTable[i])
+}
+
+func mix(a *APU) {
+	m := &a.Mixer
+	if m.SampleRate == 0 {
+		return
+	}
+	rampDMC(m, a.DMC.Output)
+	if m.Decimate && m.Elapsed + 1 < m.Step {
+		m.Elapsed++
+		return
+	}
+
+	p1 := pulseOutput(&a.Pulse1)
+	p2 := pulseOutput(&a.Pulse2)
+	t := triangleOutput(&a.Triangle)
+	n := noiseOutput(&a.Noise)
+	d := m.DMCLevel
+
+	out := pulseTable[p1 + p2] + tndOutput(int(t), int(n), d) + m.Expansion
+	var stems [CHANNELS]float64
+	if m.Stems {
+		stems[CHANNEL_PULSE1] = pulseTable[p1]
+		stems[CHANNEL_PULSE2] = pulseTable[p2]
+		stems[CHANNEL_TRIANGLE] = tndTable[3*int(t)]
+		stems[CHANNEL_NOISE] = tndTable[2*int(n)]
+		stems[CHANNEL_DMC] = tndOutput(0, 0, d)
+	}
+
+	if m.Decimate {
+		m.Elapsed += 1 - m.Step
+		emitSample(m, out)
+		if m.Stems {
+			for ch := 0; ch < CHANNELS; ch++ {
+				emitStem(m, ch, stems[ch])
+			}
+		}
+		return
+	}
+
+	if m.Quality == QUALITY_SINC {
+		s := sincFor(m)
+		sincInput(&s.Mix, &s.Kernel, out, func(v float64) { emitSample(m, v) })
+		if m.Stems {
+			for ch := 0; ch < CHANNELS; ch++ {
+				sincInput(&s.Stems[ch], &s.Kernel, stems[ch], func(v float64) { emitStem(m, ch, v) })
+			}
+		}
+		return
+	}
+
+	// Part of the cycle before the end of the sample
+	part := 1.0
+	if m.Elapsed + 1 >= m.Step {
+		part = m.Step - m.Elapsed
+	}
+	m.Sum += out * part
+	if m.Stems {
+		for ch := 0; ch < CHANNELS; ch++ {
+			m.StemSums[ch] += stems[ch] * part
+		}
+	}
+	m.Elapsed++
+	if m.Elapsed < m.Step {
+		return
+	}
+	m.Elapsed -= m.Step
+
+	emitSample(m, m.Sum / m.Step)
+	m.Sum = out * (1 - part)
+	if m.Stems {
+		for ch := 0; ch < CHANNELS; ch++ {
+			emitStem(m, ch, m.StemSums[ch] / m.Step)
+			m.StemSums[ch] = stems[ch] * (1 - part)
+		}
+	}
+}
+
+func emitSample(m *Mixer, v float64) {
+	m.Samples = append(m.Samples, toSample(filterSample(&m.Filter, m.SampleRate, v)))
+}
+
+func emitStem(m *Mixer, ch int, v float64) {
+	v = filterSample(&m.StemFilters[ch], m.SampleRate, v)
+	m.StemSamples[ch] = append(m.StemSamples[ch], toSample(v))
+}
+
+// Drops the samples and the register writes handed out, keeping the buffers.
+func ClearSamples(a *APU) {
+	a.Log.Writes = a.Log.Writes[:0]
+	a.Mixer.Samples = a.Mixer.Samples[:0]
+	for ch := 0; ch < CHANNELS; ch++ {
+		a.Mixer.StemSamples[ch] = a.Mixer.StemSamples[ch][:0]
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package apu
+
+// Timing of the consoles of each region. The PAL 2A07 runs from a slower
+// clock and its noise periods and frame counter steps are tuned to it.
+// The Dendy clone has a faster clock than PAL but keeps the NTSC tables.
+
+const (
+	REGION_NTSC = 0
+	REGION_PAL = 1
+	REGION_DENDY = 2
+)
+
+type Timing struct {
+	Name string
+	CPUFrequency int
+	NoisePeriods [16]uint16 // CPU cycles
+	DMCRates [16]uint16 // CPU cycles between two output bits of the DMC
+	FrameSteps4 [4]int // Frame counter steps, CPU cycles since the sequence started
+	FrameSteps5 [5]int
+}
+
+var Timings = [3]Timing{
+	{
+		Name: "ntsc",
+		CPUFrequency: 1789773,
+		NoisePeriods: [16]uint16{4, 8, 16, 32, 64, 96, 128, 160, 202, 254, 380, 508, 762, 1016, 2034, 4068},
+		DMCRates: [16]uint16{428, 380, 340, 320, 286, 254, 226, 214, 190, 160, 142, 128, 106, 84, 72, 54},
+		FrameSteps4: [4]int{7457, 14913, 22371, 29829},
+		FrameSteps5: [5]int{7457, 14913, 22371, 29829, 37281},
+	},
+	{
+		Name: "pal",
+		CPUFrequency: 1662607,
+		NoisePeriods: [16]uint16{4, 8, 14, 30, 60, 88, 118, 148, 188, 236, 354, 472, 708, 944, 1890, 3778},
+		DMCRates: [16]uint16{398, 354, 316, 298, 276, 236, 210, 198, 176, 148, 132, 118, 98, 78, 66, 50},
+		FrameSteps4: [4]int{8313, 16627, 24939, 33252},
+		FrameSteps5: [5]int{8313, 16627, 24939, 33252, 41565},
+	},
+	{
+		Name: "dendy",
+		CPUFrequency: 1773448,
+		NoisePeriods: [16]uint16{4, 8, 16, 32, 64, 96, 128, 160, 202, 254, 380, 508, 762, 1016, 2034, 4068},
+		DMCRates: [16]uint16{428, 380, 340, 320, 286, 254, 226, 214, 190, 160, 142, 128, 106, 84, 72, 54},
+		FrameSteps4: [4]int{7457, 14913, 22371, 29829},
+		FrameSteps5: [5]int{7457, 14913, 22371, 29829, 37281},
+	},
+}
+
+// Switches the timing of a running APU to another region.
+func SetRegion(a *APU, region int) {
+	a.Region = region
+	a.Timing = Timings[region]
+	a.Noise.Periods = a.Timing.NoisePeriods
+	a.DMC.Rates = a.Timing.DMCRates
+	a.Mixer.Step = float64(a.Timing.CPUFrequency) / float64(a.Mixer.SampleRate)
+}
+
+// Region of a name like "pal", NTSC for anything else.
+func RegionByName(name string) int {
+	for region, t := range Timings {
+		if t.Name == name {
+			return region
+		}
+	}
+	return REGION_NTSC
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package apu
+
+import "math"
+
+// Resampling of the mix, made every CPU cycle, to the output rate. There
+// are about 40.58 cycles per sample on NTSC, so sample boundaries fall
+// inside cycles.
+//
+// QUALITY_LINEAR integrates the mix over the exact span of each sample,
+// splitting the cycle a boundary falls in, and reads the integral at the
+// boundary by linear interpolation. It is a box filter: cheap, but some
+// of the high notes fold back as aliases.
+//
+// QUALITY_SINC first averages blocks of sincBlock cycles and then filters
+// the blocks with a Blackman windowed sinc cut at 45% of the output rate,
+// evaluated at the fractional position of each sample. The output is
+// sincZeros samples late.
+
+const (
+	QUALITY_LINEAR = 0
+	QUALITY_SINC = 1
+)
+
+var QualityNames = []string{"linear", "sinc"}
+
+const sincBlock = 8 // CPU cycles averaged into one filter input
+const sincZeros = 8 // Zero crossings of the kernel on each side, in output samples
+const sincPhases = 64 // Kernel table entries per block
+const sincHistory = 256 // Blocks kept, more than the kernel spans
+
+// Windowed sinc for one ratio of blocks per output sample.
+type sincKernel struct {
+	Ratio float64
+	Width float64 // Half width in blocks
+	Table []float64 // Kernel at distances 0, 1/sincPhases, ... blocks
+}
+
+type sincResampler struct {
+	Sum float64 // Cycles of the block being averaged
+	Cycles int
+	History [sincHistory]float64
+	Blocks uint64 // Blocks written to History
+	Position float64 // Time of the next output sample, in blocks
+}
+
+type sincState struct {
+	Kernel sincKernel
+	Mix sincResampler
+	Stems [CHANNELS]sincResampler
+}
+
+func makeKernel(ratio float64) sincKernel {
+	var k sincKernel
+	k.Ratio = ratio
+	k.Width = sincZeros * ratio
+	cutoff := 0.45 / ratio // Cycles per block
+	k.Table = make([]float64, int(k.Width * sincPhases) + 2)
+	for i := range k.Table {
+		x := float64(i) / sincPhases
+		if x >= k.Width {
+			continue
+		}
+		s := 1.0
+		if x > 0 {
+			s = math.Sin(2 * math.Pi * cutoff * x) / (2 * math.Pi * cutoff * x)
+		}
+		// Blackman window over [-Width, Width]
+		w := 0.42 + 0.5 * math.Cos(math.Pi * x / k.Width) + 0.08 * math.Cos(2 * math.Pi * x / k.Width)
+		k.Table[i] = s * w
+	}
+	return k
+}
+
+func kernelAt(k *sincKernel, x float64) float64 {
+	x = math.Abs(x) * sincPhases
+	i := int(x)
+	if i + 1 >= len(k.Table) {
+		return 0
+	}
+	frac := x - float64(i)
+	return k.Table[i] + frac * (k.Table[i+1] - k.Table[i])
+}
+
+// Adds one cycle. When output samples are due they are passed to emit.
+func sincInput(r *sincResampler, k *sincKernel, v float64, emit func(float64)) {
+	r.Sum += v
+	r.Cycles++
+	if r.Cycles < sincBlock {
+		return
+	}
+	r.History[r.Blocks % sincHistory] = r.Sum / sincBlock
+	r.Blocks++
+	r.Sum = 0
+	r.Cycles = 0
+
+	// A sample needs the blocks up to Width after it
+	for r.Position + k.Width < float64(r.Blocks - 1) {
+		emit(sincOutput(r, k))
+		r.Position += k.Ratio
+	}
+}
+
+func sincOutput(r *sincResampler, k *sincKernel) float64 {
+	first := math.Ceil(r.Position - k.Width)
+	if first < 0 {
+		first = 0
+	}
+	var acc, weights float64
+	for i := first; i <= r.Position + k.Width; i++ {
+		w := kernelAt(k, r.Position - i)
+		acc += w * r.History[uint64(i) % sincHistory]
+		weights += w
+	}
+	if weights == 0 {
+		return 0
+	}
+	return acc / weights
+}
+
+// Filter state of the sinc quality, created when first used and rebuilt
+// when the ratio changes with the region.
+func sincFor(m *Mixer) *sincState {
+	ratio := m.Step / sincBlock
+	if m.sinc == nil || m.sinc.Kernel.Ratio != ratio {
+		m.sinc = new(sincState)
+		m.sinc.Kernel = makeKernel(ratio)
+	}
+	return m.sinc
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package apu
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/snapshot"
+import "math"
+
+// Savestate encoding of the channels, the frame counter and the phase of
+// the mixer, so the samples made after a load are the same, bit for bit,
+// as the ones made when the state was taken. The output buffers and the
+// settings of the mixer belong to the audio output and are not saved.
+func EncodeState(a *APU, e *snapshot.Encoder) {
+	encodePulse(&a.Pulse1, e)
+	encodePulse(&a.Pulse2, e)
+
+	t := &a.Triangle
+	snapshot.PutBool(e, t.Enabled)
+	snapshot.PutBool(e, t.Control)
+	snapshot.PutUint16(e, t.Timer)
+	snapshot.PutUint16(e, t.Period)
+	snapshot.PutByte(e, t.Step)
+	snapshot.PutByte(e, t.Length)
+	snapshot.PutByte(e, t.LinearPeriod)
+	snapshot.PutByte(e, t.Linear)
+	snapshot.PutBool(e, t.LinearReload)
+
+	n := &a.Noise
+	snapshot.PutBool(e, n.Enabled)
+	snapshot.PutBool(e, n.Mode)
+	snapshot.PutUint16(e, n.Shift)
+	snapshot.PutUint16(e, n.Timer)
+	snapshot.PutUint16(e, n.Period)
+	snapshot.PutByte(e, n.Length)
+	encodeEnvelope(&n.Envelope, e)
+
+	dmc := &a.DMC
+	snapshot.PutBool(e, dmc.IRQEnabled)
+	snapshot.PutBool(e, dmc.Loop)
+	snapshot.PutByte(e, dmc.Rate)
+	snapshot.PutUint16(e, dmc.Timer)
+	snapshot.PutByte(e, dmc.Output)
+	snapshot.PutUint16(e, dmc.SampleAddr)
+	snapshot.PutUint16(e, dmc.SampleLength)
+	snapshot.PutUint16(e, dmc.CurrentAddr)
+	snapshot.PutUint16(e, dmc.BytesRemaining)
+	snapshot.PutBool(e, dmc.IRQ)
+	snapshot.PutByte(e, dmc.Shift)
+	snapshot.PutByte(e, dmc.BitsRemaining)
+	snapshot.PutBool(e, dmc.Silence)
+	snapshot.PutByte(e, dmc.Buffer)
+	snapshot.PutBool(e, dmc.BufferEmpty)
+
+	snapshot.PutUint64(e, a.Cycle)
+	snapshot.PutBool(e, a.FiveStep)
+	snapshot.PutBool(e, a.IRQInhibit)
+	snapshot.PutBool(e, a.FrameIRQ)
+	snapshot.PutInt(e, a.FrameCycle)
+	snapshot.PutInt(e, a.FrameStep)
+
+	m := &a.Mixer
+	snapshot.PutUint64(e, math.Float64bits(m.Elapsed))
+	snapshot.PutUint64(e, math.Float64bits(m.Sum))
+	snapshot.PutUint64(e, math.Float64bits(m.DMCLevel))
+	snapshot.PutUint64(e, math.Float64bits(m.DMCRamp))
+	encodeFilter(&m.Filter, e)
+	for ch := 0; ch < CHANNELS; ch++ {
+		snapshot.PutUint64(e, math.Float64bits(m.StemSums[ch]))
+		encodeFilter(&m.StemFilters[ch], e)
+	}
+}
+
+func encodeFilter(f *highPass, e *snapshot.Encoder) {
+	snapshot.PutUint64(e, math.Float64bits(f.Input))
+	snapshot.PutUint64(e, math.Float64bits(f.Output))
+}
+
+func decodeFilter(f *highPass, d *snapshot.Decoder) {
+	f.Input = math.Float64frombits(snapshot.Uint64(d))
+	f.Output = math.Float64frombits(snapshot.Uint64(d))
+}
+
+func DecodeState(a *APU, d *snapshot.Decoder) {
+	decodePulse(&a.Pulse1, d)
+	decodePulse(&a.Pulse2, d)
+
+	t := &a.Triangle
+	t.Enabled = snapshot.Bool(d)
+	t.Control = snapshot.Bool(d)
+	t.Timer = snapshot.Uint16(d)
+	t.Period = snapshot.Uint16(d)
+	t.Step = snapshot.Byte(d) & 31
+	t.Length = snapshot.Byte(d)
+	t.LinearPeriod = snapshot.Byte(d)
+	t.Linear = snapshot.Byte(d)
+	t.LinearReload = snapshot.Bool(d)
+
+	n := &a.Noise
+	n.Enabled = snapshot.Bool(d)
+	n.Mode = snapshot.Bool(d)
+	n.Shift = snapshot.Uint16(d)
+	n.Timer = snapshot.Uint16(d)
+	n.Period = snapshot.Uint16(d)
+	n.Length = snapshot.Byte(d)
+	decodeEnvelope(&n.Envelope, d)
+
+	dmc := &a.DMC
+	dmc.IRQEnabled = snapshot.Bool(d)
+	dmc.Loop = snapshot.Bool(d)
+	dmc.Rate = snapshot.Byte(d) & 0x0F
+	dmc.Timer = snapshot.Uint16(d)
+	dmc.Output = snapshot.Byte(d) & 0x7F
+	dmc.SampleAddr = snapshot.Uint16(d)
+	dmc.SampleLength = snapshot.Uint16(d)
+	dmc.CurrentAddr = snapshot.Uint16(d)
+	dmc.BytesRemaining = snapshot.Uint16(d)
+	dmc.IRQ = snapshot.Bool(d)
+	dmc.Shift = snapshot.Byte(d)
+	dmc.BitsRemaining = snapshot.Byte(d)
+	if dmc.BitsRemaining == 0 || dmc.BitsRemaining > 8 {
+		dmc.BitsRemaining = 8
+	}
+	dmc.Silence = snapshot.Bool(d)
+	dmc.Buffer = snapshot.Byte(d)
+	dmc.BufferEmpty = snapshot.Bool(d)
+
+	a.Cycle = snapshot.Uint64(d)
+	a.FiveStep = snapshot.Bool(d)
+	a.IRQInhibit = snapshot.Bool(d)
+	a.FrameIRQ = snapshot.Bool(d)
+	a.FrameCycle = snapshot.Int(d)
+	a.FrameStep = snapshot.Int(d)
+	if a.FrameStep < 0 || a.FrameStep >= len(a.Timing.FrameSteps5) {
+		a.FrameStep = 0
+	}
+
+	m := &a.Mixer
+	m.Elapsed = math.Float64frombits(snapshot.Uint64(d))
+	m.Sum = math.Float64frombits(snapshot.Uint64(d))
+	m.DMCLevel = math.Float64frombits(snapshot.Uint64(d))
+	m.DMCRamp = math.Float64frombits(snapshot.Uint64(d))
+	decodeFilter(&m.Filter, d)
+	for ch := 0; ch < CHANNELS; ch++ {
+		m.StemSums[ch] = math.Float64frombits(snapshot.Uint64(d))
+		decodeFilter(&m.StemFilters[ch], d)
+	}
+}
+
+func encodeEnvelope(env *Envelope, e *snapshot.Encoder) {
+	snapshot.PutBool(e, env.Start)
+	snapshot.PutBool(e, env.Loop)
+	snapshot.PutBool(e, env.Constant)
+	snapshot.PutByte(e, env.Period)
+	snapshot.PutByte(e, env.Divider)
+	snapshot.PutByte(e, env.Decay)
+}
+
+func decodeEnvelope(env *Envelope, d *snapshot.Decoder) {
+	env.Start = snapshot.Bool(d)
+	env.Loop = snapshot.Bool(d)
+	env.Constant = snapshot.Bool(d)
+	env.Period = snapshot.Byte(d) & 0x0F
+	env.Divider = snapshot.Byte(d)
+	env.Decay = snapshot.Byte(d) & 0x0F
+}
+
+func encodePulse(p *Pulse, e *snapshot.Encoder) {
+	snapshot.PutBool(e, p.Enabled)
+	snapshot.PutByte(e, p.Duty)
+	snapshot.PutByte(e, p.DutyStep)
+	snapshot.PutUint16(e, p.Timer)
+	snapshot.PutUint16(e, p.Period)
+	snapshot.PutByte(e, p.Length)
+	encodeEnvelope(&p.Envelope, e)
+	snapshot.PutBool(e, p.SweepEnabled)
+	snapshot.PutByte(e, p.SweepPeriod)
+	snapshot.PutBool(e, p.SweepNegate)
+	snapshot.PutByte(e, p.SweepShift)
+	snapshot.PutBool(e, p.SweepReload)
+	snapshot.PutByte(e, p.SweepDivider)
+}
+
+func decodePulse(p *Pulse, d *snapshot.Decoder) {
+	p.Enabled = snapshot.Bool(d)
+	p.Duty = snapshot.Byte(d) & 3
+	p.DutyStep = snapshot.Byte(d) & 7
+	p.Timer = snapshot.Uint16(d)
+	p.Period = snapshot.Uint16(d)
+	p.Length = snapshot.Byte(d)
+	decodeEnvelope(&p.Envelope, d)
+	p.SweepEnabled = snapshot.Bool(d)
+	p.SweepPeriod = snapshot.Byte(d)
+	p.SweepNegate = snapshot.Bool(d)
+	p.SweepShift = snapshot.Byte(d) & 7
+	p.SweepReload = snapshot.Bool(d)
+	p.SweepDivider = snapshot.Byte(d)
+}
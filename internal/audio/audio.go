@@ -0,0 +1,123 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package audio
+
+import "fmt"
+import "os"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+const SampleRate = apu.SampleRate
+const BufferSamples = 4096 // Samples kept queued before the output starts to lag
+
+// Audio output. The null driver accepts and discards the samples so the
+// emulator can run on machines without a sound device.
+type Audio struct {
+	Driver string // "sdl" or "null"
+	Device sdl.AudioDeviceID
+	Queued int // Samples queued in the null driver
+	Buffer []byte
+	Playing bool // Samples were queued since the output was opened or resumed
+	Underruns int // Times the device ran out of samples while playing
+}
+
+func OpenAudio(driver string) Audio {
+	var a Audio
+	a.Driver = driver
+
+	if driver == "null" {
+		fmt.Println("Audio: null driver")
+		return a
+	}
+
+	if err := sdl.Init(sdl.INIT_AUDIO); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize audio, using the null driver: %s\n", err)
+		a.Driver = "null"
+		return a
+	}
+
+	var spec sdl.AudioSpec
+	spec.Freq = SampleRate
+	spec.Format = sdl.AUDIO_S16LSB
+	spec.Channels = 1
+	spec.Samples = 1024
+	device, err := sdl.OpenAudioDevice("", false, &spec, nil, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open the audio device, using the null driver: %s\n", err)
+		a.Driver = "null"
+		return a
+	}
+	a.Device = device
+	sdl.PauseAudioDevice(device, false)
+	return a
+}
+
+func QueueSamples(a *Audio, samples []int16) {
+
+	if a.Driver == "null" {
+		// Behaves like a device that plays instantly
+		a.Queued = 0
+		return
+	}
+
+	// Running ahead of the sound card, drop the frame instead of lagging
+	if int(sdl.GetQueuedAudioSize(a.Device)) / 2 > BufferSamples {
+		return
+	}
+
+	if a.Playing && sdl.GetQueuedAudioSize(a.Device) == 0 {
+		a.Underruns++
+	}
+	a.Playing = true
+
+	a.Buffer = a.Buffer[:0]
+	for _, s := range samples {
+		a.Buffer = append(a.Buffer, byte(s), byte(uint16(s) >> 8))
+	}
+	sdl.QueueAudio(a.Device, a.Buffer)
+}
+
+// Fill level of the output buffer, 0.0 - 1.0.
+func BufferFill(a *Audio) float64 {
+	var queued int = a.Queued
+	if a.Driver != "null" {
+		queued = int(sdl.GetQueuedAudioSize(a.Device)) / 2
+	}
+	fill := float64(queued) / BufferSamples
+	if fill > 1 {
+		fill = 1
+	}
+	return fill
+}
+
+// Stops (and mutes) or resumes the output.
+func PauseAudio(a *Audio, pause bool) {
+	if a.Driver != "null" {
+		sdl.PauseAudioDevice(a.Device, pause)
+	}
+	a.Playing = false
+}
+
+func CloseAudio(a *Audio) {
+	if a.Driver != "null" {
+		sdl.CloseAudioDevice(a.Device)
+	}
+}
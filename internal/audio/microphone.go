@@ -0,0 +1,92 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package audio
+
+import "fmt"
+import "os"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// Sound capture used as the microphone of the Famicom second controller.
+type Microphone struct {
+	Enable bool
+	Device sdl.AudioDeviceID
+	Threshold float64 // Peak level, 0.0 - 1.0, that counts as blowing into the mic
+	Buffer []byte
+}
+
+func OpenMicrophone(threshold float64) Microphone {
+	var m Microphone
+	m.Threshold = threshold
+
+	if err := sdl.Init(sdl.INIT_AUDIO); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize audio capture: %s\n", err)
+		return m
+	}
+
+	var spec sdl.AudioSpec
+	spec.Freq = SampleRate
+	spec.Format = sdl.AUDIO_S16LSB
+	spec.Channels = 1
+	spec.Samples = 512
+	device, err := sdl.OpenAudioDevice("", true, &spec, nil, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open the microphone: %s\n", err)
+		return m
+	}
+	m.Device = device
+	m.Buffer = make([]byte, 4096)
+	m.Enable = true
+	sdl.PauseAudioDevice(device, false)
+	return m
+}
+
+// Reads the sound captured since the last call and reports whether its
+// peak level is over the threshold.
+func MicrophoneActive(m *Microphone) bool {
+
+	if m.Enable == false {
+		return false
+	}
+
+	var peak int = 0
+	for {
+		n, err := sdl.DequeueAudio(m.Device, m.Buffer)
+		if err != nil || n < 2 {
+			break
+		}
+		for i := 0; i+1 < n; i += 2 {
+			sample := int(int16(uint16(m.Buffer[i]) | uint16(m.Buffer[i+1])<<8))
+			if sample < 0 {
+				sample = -sample
+			}
+			if sample > peak {
+				peak = sample
+			}
+		}
+	}
+	return float64(peak) / 32768 >= m.Threshold
+}
+
+func CloseMicrophone(m *Microphone) {
+	if m.Enable {
+		sdl.CloseAudioDevice(m.Device)
+		m.Enable = false
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package audiofile
+
+import "encoding/binary"
+import "os"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+
+// VGM 1.71 logger of the APU register writes, which players and trackers
+// read as an NES APU stream. Time is taken from the APU cycle of every
+// write and turned into waits of 44100Hz samples.
+
+const vgmHeaderSize = 0x100
+
+type VgmFile struct {
+	File *os.File
+	Clock int // CPU frequency of the console
+	Rate int // Frames per second, 60 or 50
+	Cycle uint64 // APU cycle of the last write or frame end
+	Started bool
+	Elapsed uint64 // CPU cycles logged, without the jumps of savestates and rewinds
+	Samples int64 // Samples waited so far
+	Bytes int64 // Commands written so far
+	Buffer []byte
+}
+
+func CreateVgm(path string, clock int, rate int) (*VgmFile, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	v := &VgmFile{File: file, Clock: clock, Rate: rate}
+	if _, err := file.Write(vgmHeader(v, 0, 0)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return v, nil
+}
+
+func vgmHeader(v *VgmFile, size int64, samples int64) []byte {
+	h := make([]byte, vgmHeaderSize)
+	copy(h[0:], "Vgm ")
+	binary.LittleEndian.PutUint32(h[0x04:], headerSize(vgmHeaderSize + size - 4))
+	binary.LittleEndian.PutUint32(h[0x08:], 0x171)
+	binary.LittleEndian.PutUint32(h[0x18:], headerSize(samples))
+	binary.LittleEndian.PutUint32(h[0x24:], uint32(v.Rate))
+	binary.LittleEndian.PutUint32(h[0x34:], vgmHeaderSize - 0x34)
+	binary.LittleEndian.PutUint32(h[0x84:], uint32(v.Clock))
+	return h
+}
+
+func advanceVgm(v *VgmFile, cycle uint64) {
+	if v.Started && cycle > v.Cycle {
+		v.Elapsed += cycle - v.Cycle
+	}
+	v.Cycle = cycle
+	v.Started = true
+
+	// 44100 samples a second pass a 32-bit int in under 14 hours
+	wait := int64(v.Elapsed * apu.SampleRate / uint64(v.Clock)) - v.Samples
+	v.Samples += wait
+	for wait > 0 {
+		n := wait
+		if n > 0xFFFF {
+			n = 0xFFFF
+		}
+		switch {
+			case n == 735:
+				v.Buffer = append(v.Buffer, 0x62)
+			case n == 882:
+				v.Buffer = append(v.Buffer, 0x63)
+			case n <= 16:
+				v.Buffer = append(v.Buffer, 0x70 + byte(n - 1))
+			default:
+				v.Buffer = append(v.Buffer, 0x61, byte(n), byte(n >> 8))
+		}
+		wait -= n
+	}
+}
+
+// Logs the writes of a frame and the time up to cycle, its end.
+func WriteVgm(v *VgmFile, writes []apu.RegisterWrite, cycle uint64) error {
+	v.Buffer = v.Buffer[:0]
+	for _, w := range writes {
+		advanceVgm(v, w.Cycle)
+		v.Buffer = append(v.Buffer, 0xB4, byte(w.Addr - 0x4000), w.Value)
+	}
+	advanceVgm(v, cycle)
+	n, err := v.File.Write(v.Buffer)
+	v.Bytes += int64(n)
+	return err
+}
+
+func CloseVgm(v *VgmFile) error {
+	n, err := v.File.Write([]byte{0x66})
+	v.Bytes += int64(n)
+	if err == nil {
+		_, err = v.File.WriteAt(vgmHeader(v, v.Bytes, v.Samples), 0)
+	}
+	if err != nil {
+		v.File.Close()
+		return err
+	}
+	return v.File.Close()
+}
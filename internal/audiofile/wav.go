@@ -0,0 +1,90 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package audiofile
+
+import "encoding/binary"
+import "os"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+
+// WAV file writer, 16-bit mono PCM. The sizes in the header are written
+// when the file is closed.
+
+type WavFile struct {
+	File *os.File
+	Bytes int64 // Sample data written so far, past 2GB on long recordings
+	Buffer []byte
+}
+
+func CreateWav(path string) (*WavFile, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &WavFile{File: file}
+	if _, err := file.Write(wavHeader(apu.SampleRate, 0)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// The 32-bit sizes of the header saturate instead of wrapping around, so
+// players still find the data of a recording longer than 4GB.
+func headerSize(n int64) uint32 {
+	if n > 0xFFFFFFFF {
+		return 0xFFFFFFFF
+	}
+	return uint32(n)
+}
+
+func wavHeader(rate int, size int64) []byte {
+	h := make([]byte, 44)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], headerSize(36 + size))
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(h[22:], 1) // Mono
+	binary.LittleEndian.PutUint32(h[24:], uint32(rate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(rate * 2))
+	binary.LittleEndian.PutUint16(h[32:], 2)
+	binary.LittleEndian.PutUint16(h[34:], 16)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], headerSize(size))
+	return h
+}
+
+func WriteWav(w *WavFile, samples []int16) error {
+	w.Buffer = w.Buffer[:0]
+	for _, s := range samples {
+		w.Buffer = append(w.Buffer, byte(s), byte(uint16(s) >> 8))
+	}
+	n, err := w.File.Write(w.Buffer)
+	w.Bytes += int64(n)
+	return err
+}
+
+func CloseWav(w *WavFile) error {
+	if _, err := w.File.WriteAt(wavHeader(apu.SampleRate, w.Bytes), 0); err != nil {
+		w.File.Close()
+		return err
+	}
+	return w.File.Close()
+}
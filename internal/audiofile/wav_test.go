@@ -0,0 +1,54 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package audiofile
+
+import "encoding/binary"
+import "testing"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+
+// The 32-bit sizes of the WAV and VGM headers hold recordings past 2GB and
+// saturate past 4GB, on any int size.
+func TestHeaderSizes(t *testing.T) {
+	tests := []struct {
+		size int64
+		want uint32
+	}{
+		{0, 0},
+		{1 << 31, 1 << 31},
+		{0xFFFFFFFF, 0xFFFFFFFF},
+		{1 << 32, 0xFFFFFFFF},
+		{5 << 30, 0xFFFFFFFF},
+	}
+	for _, test := range tests {
+		h := wavHeader(apu.SampleRate, test.size)
+		if got := binary.LittleEndian.Uint32(h[40:]); got != test.want {
+			t.Errorf("WAV data of %d bytes: size %d, want %d", test.size, got, test.want)
+		}
+		h = vgmHeader(&VgmFile{Clock: 1789773, Rate: 60}, test.size, test.size)
+		if got := binary.LittleEndian.Uint32(h[0x18:]); got != test.want {
+			t.Errorf("VGM of %d samples: count %d, want %d", test.size, got, test.want)
+		}
+	}
+
+	h := wavHeader(apu.SampleRate, 3 << 30)
+	if got := binary.LittleEndian.Uint32(h[4:]); got != 36 + 3 << 30 {
+		t.Errorf("RIFF size %d for 3GB of data", got)
+	}
+}
@@ -17,7 +17,7 @@ This file is part of Alphanes.
     along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
 */
 package cpu
-import "zerojnt/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
 
 // Relative
 func Rel(cpu *CPU, cart *cartridge.Cartridge) uint16 {
@@ -41,12 +41,22 @@ func Abs(cpu *CPU, cart *cartridge.Cartridge) uint16 {
 
 // Absolute-X
 func AbsX(cpu *CPU, cart *cartridge.Cartridge) uint16 {
-	return uint16(Abs(cpu, cart)+ uint16(cpu.X))
+	return indexed(cpu, Abs(cpu, cart), cpu.X)
 }
 
 // Absolute-Y
 func AbsY(cpu *CPU, cart *cartridge.Cartridge) uint16 {
-	return uint16(Abs(cpu, cart)+ uint16(cpu.Y))
+	return indexed(cpu, Abs(cpu, cart), cpu.Y)
+}
+
+// Adds the index to a base address, noting when it crosses a page.
+func indexed(cpu *CPU, base uint16, index byte) uint16 {
+	addr := base + uint16(index)
+	cpu.PageCrossed = 0
+	if H(base) != H(addr) {
+		cpu.PageCrossed = 1
+	}
+	return addr
 }
 
 // Zero Page
@@ -20,47 +20,15 @@ package cpu
 
 import "fmt"
 import "strconv"
-import "zerojnt/debug"
-import "zerojnt/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
 import "log"
 
-func DebugA(cpu *CPU, cart *cartridge.Cartridge) byte {
-        debugLine := cpu.D.Lines[cpu.SwitchTimes+1]
-	A, errA := strconv.ParseUint( debug.GetA(debugLine), 0, 64 )
-        if errA != nil { log.Fatal(errA) }
-        return byte(A)
-}
-
-func DebugX(cpu *CPU, cart *cartridge.Cartridge) byte {
-        debugLine := cpu.D.Lines[cpu.SwitchTimes+1]
-	X, errX := strconv.ParseUint( debug.GetX(debugLine), 0, 64 )
-        if errX != nil { log.Fatal(errX) }
-        return byte(X)
-}
-
-
-func DebugY(cpu *CPU, cart *cartridge.Cartridge) byte {
-        debugLine := cpu.D.Lines[cpu.SwitchTimes+1]
-	Y, errY := strconv.ParseUint( debug.GetY(debugLine), 0, 64 )
-        if errY != nil { log.Fatal(errY) }
-        return byte(Y)
-}
-
-func DebugP(cpu *CPU, cart *cartridge.Cartridge) byte {
-        debugLine := cpu.D.Lines[cpu.SwitchTimes+1]
-	P, errP := strconv.ParseUint( debug.GetP(debugLine), 0, 64 )
-        if errP != nil { log.Fatal(errP) }
-        return byte(P)
-}
-
-func DebugOp(cpu *CPU, cart *cartridge.Cartridge) byte {
-        debugLine := cpu.D.Lines[cpu.SwitchTimes+1]
-	OP, errPC := strconv.ParseUint( debug.GetOpcode(debugLine), 0, 64 )
-        if errPC != nil { log.Fatal(errPC) }
-        return byte(OP)
-}
-
-
+// Compares the registers with the line of the .debug trace for the
+// instruction about to run and stops the CPU when they differ. Reads of
+// the PPU and I/O registers are not substituted here; cmd/tracecmp does
+// that outside the CPU.
 func DebugCompare(cpu *CPU, cart *cartridge.Cartridge) {
 	
         debugLine := cpu.D.Lines[cpu.SwitchTimes]
@@ -116,6 +84,8 @@ func DebugCompare(cpu *CPU, cart *cartridge.Cartridge) {
 
 	if err {
             fmt.Printf("Error at line: %d -- %X %X %X - SwitchTime: %d\n", cpu.SwitchTimes, RM(cpu, cart, cpu.PC), RM(cpu, cart, cpu.PC+1), RM(cpu, cart, cpu.PC+2), cpu.SwitchTimes )
+            cycles, scanline, dot := ioports.Timestamp(&cpu.IO)
+            fmt.Printf("At CPU cycle: %d -- Scanline: %d Dot: %d\n", cycles, scanline, dot)
 
 
 
@@ -19,9 +19,9 @@ This file is part of Alphanes.
 package cpu
 
 import "fmt"
-import "zerojnt/cartridge"
-import "zerojnt/debug"
-import "zerojnt/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
 
 type CPU struct {
 	Name string
@@ -36,12 +36,36 @@ type CPU struct {
 	CYC uint16
 	CYCSpecial uint16 // For cases when we need to add more cycles for an operation
 	PageCrossed byte // Only the addressing methods change this property
+	polledI byte // I flag before the last instruction, see irqMasked
+	delayI bool // The last instruction was CLI, SEI or PLP
 	Running bool
 	Start int
 	End int
 	SwitchTimes int
 	D debug.Debug
 	IO ioports.IOPorts
+
+	Instructions uint64 // Executed since power up
+	PCHistory [PC_HISTORY]uint16 // Addresses of the last instructions, see RecentPCs
+	PeripheralReads uint64 // Reads of the PPU, APU and controller registers since power up
+
+	FlatBus bool // CPU_RAM is the whole address space, for the CPU tests
+	BusLog []BusAccess // Accesses made on the flat bus
+}
+
+const PC_HISTORY = 64
+
+// Addresses of the last instructions run, the oldest first.
+func RecentPCs(cpu *CPU) []uint16 {
+	n := cpu.Instructions
+	if n > PC_HISTORY {
+		n = PC_HISTORY
+	}
+	pcs := make([]uint16, 0, n)
+	for i := cpu.Instructions - n; i < cpu.Instructions; i++ {
+		pcs = append(pcs, cpu.PCHistory[i % PC_HISTORY])
+	}
+	return pcs
 }
 
 func StartCPU() CPU {
@@ -73,6 +97,16 @@ func ResetCPU(cpu *CPU) {
 	cpu.SwitchTimes = -1
 }
 
+// Reset button: unlike power up the registers keep their values, the stack
+// pointer moves down three bytes as for an interrupt without the writes and
+// interrupts are disabled.
+func SoftResetCPU(cpu *CPU) {
+	cpu.SP -= 3
+	SetI(cpu, 1)
+	cpu.CYCSpecial = 0
+	cpu.Running = true
+}
+
 func SetResetVector (cpu *CPU, cart *cartridge.Cartridge) {
 	cpu.PC = LE( RM(cpu, cart, 0xFFFC), RM(cpu, cart, 0xFFFD) )
 }
@@ -80,10 +114,17 @@ func SetResetVector (cpu *CPU, cart *cartridge.Cartridge) {
 func Process(cpu *CPU, cart *cartridge.Cartridge) {
 
 	if cpu.Running {
+		cpu.IO.CLOCK.CPU_CYCLES++
 		emulate(cpu, cart)		
 	}
 }
 
+// True when the next Process call starts an instruction or an interrupt
+// instead of spending a cycle of the previous one.
+func InstructionDue(cpu *CPU) bool {
+	return cpu.CYC == 0 && cpu.IO.CPU_CYC_INCREASE == 0
+}
+
 func ZeroFlag(cpu *CPU, value uint16) {
 	if byte(value) == 0 {
 		SetZ(cpu, 1)
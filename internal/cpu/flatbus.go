@@ -0,0 +1,61 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package cpu
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+
+// Flat bus used to run the CPU alone against instruction tests: the 64KB
+// of CPU_RAM are the whole address space, without the PPU, the mapper or
+// the cartridge, and every access is logged.
+
+// One read or write on the flat bus. The log keeps them in the order the
+// core makes them, one per cycle on an exact core.
+type BusAccess struct {
+	Addr uint16
+	Value byte
+	Write bool
+}
+
+func StartFlatCPU() CPU {
+	var cpu CPU
+	cpu.Name = "Ricoh 2A03 (flat bus)"
+	ResetCPU(&cpu)
+	cpu.Start = 0
+	cpu.End = 0x10000
+	cpu.IO.CPU_RAM = make([]byte, 0x10000)
+	cpu.IO.CLOCK = new(ioports.MASTER_CLOCK)
+	cpu.FlatBus = true
+	return cpu
+}
+
+func busAccess(cpu *CPU, addr uint16, value byte, write bool) {
+	if cpu.FlatBus {
+		cpu.BusLog = append(cpu.BusLog, BusAccess{addr, value, write})
+	}
+}
+
+// Runs the instruction at PC right away and returns the cycles it takes.
+func RunInstruction(cpu *CPU) int {
+	cpu.CYC = 0
+	cpu.CYCSpecial = 0
+	cpu.IO.CPU_CYC_INCREASE = 0
+	cpu.BusLog = cpu.BusLog[:0]
+	emulate(cpu, nil)
+	return int(cpu.CYC)
+}
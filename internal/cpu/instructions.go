@@ -18,7 +18,7 @@ This file is part of Alphanes.
 */
 package cpu
 
-import "zerojnt/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
 
 //This instruction adds the contents of a memory location to the accumulator together with the carry bit. If overflow occurs the carry bit is set, this enables multiple byte addition to be performed.
 func iADC (cpu *CPU, value uint16) {
@@ -175,10 +175,10 @@ func BPL(cpu *CPU, value uint16) {
 
 // The BRK instruction forces the generation of an interrupt request. The program counter and processor status are pushed on the stack then the IRQ interrupt vector at $FFFE/F is loaded into the PC and the break flag in the status set to one.
 func BRK(cpu *CPU, cart *cartridge.Cartridge) {
-        PushWord(cpu, cpu.PC)
-	PushMemory (cpu, cpu.P)
+        PushWord(cpu, cpu.PC + 2)
+	PushMemory (cpu, SetBit(SetBit(cpu.P, 4, 1), 5, 1))
 	cpu.PC = LE( RM(cpu, cart, 0xFFFE), RM(cpu, cart, 0xFFFF))
-	SetB(cpu, 1)
+	SetI(cpu, 1)
 }
 
 
@@ -435,7 +435,7 @@ func ROL (cpu *CPU, cart *cartridge.Cartridge, value uint16, op byte) {
 
     switch(op) {
 
-    case 0x26:  // Zp
+    default:  // Memory
         var result uint16 = uint16(RM(cpu, cart, value))
         var tmp = (result >> 7) & 0x1
         result = (result << 1) | uint16(FlagC(cpu))
@@ -461,7 +461,7 @@ func ROR (cpu *CPU, cart *cartridge.Cartridge, value uint16, op byte) {
 
     switch(op) {
 
-    case 0x66:
+    default:  // Memory
 
         var result uint16 = uint16(RM(cpu, cart, value))
         tmp := (result & 0x1)
@@ -0,0 +1,180 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package cpu
+
+import "fmt"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+
+// Stops the CPU when the board cannot map its bus; the frontends report
+// COMPAT.CRASH like a jam.
+func busFault(cpu *CPU, err error) {
+	if cpu.Running {
+		cpu.IO.COMPAT.CRASH = fmt.Sprintf("%v: mapper %d", err, cpu.IO.BOARD.Mapper)
+	}
+	cpu.Running = false
+}
+
+func RM(cpu *CPU, cart *cartridge.Cartridge, addr uint16) byte {
+
+	if cpu.FlatBus {
+		value := cpu.IO.CPU_RAM[addr]
+		busAccess(cpu, addr, value, false)
+		return value
+	}
+
+	ppu_handle := addr >= 0x2000 && addr <= 0x3FFF 
+	region, newaddr, err := mapper.MemoryMapper(&cpu.IO.BOARD, cart, addr)
+	if err != nil {
+		busFault(cpu, err)
+		return 0
+	}
+	
+	
+
+	if region == mapper.RegionPRG {
+		return cartridge.ReadPRG(cart, newaddr)
+	}
+
+	if newaddr >= 0x2000 && newaddr < 0x2008 && ppu_handle {
+		value := ioports.RMPPU(&cpu.IO, cart, uint16(newaddr))
+		cpu.PeripheralReads++
+		ioports.LogAccess(&cpu.IO, uint16(newaddr), value, false)
+		return value
+	}
+
+	if newaddr == 0x4015 {
+		value := apu.ReadStatus(&cpu.IO.APU)
+		cpu.PeripheralReads++
+		ioports.LogAccess(&cpu.IO, uint16(newaddr), value, false)
+		return value
+	}
+
+	if newaddr == 0x4016 || newaddr == 0x4017 {
+		value := ioports.READ_JOYPAD(&cpu.IO, int(newaddr - 0x4016))
+		cpu.PeripheralReads++
+		ioports.LogAccess(&cpu.IO, uint16(newaddr), value, false)
+		return value
+	}
+
+	if newaddr >= 0x4018 && newaddr <= 0x401F {
+		value := ioports.READ_TEST_REGISTER(&cpu.IO, uint16(newaddr))
+		ioports.LogAccess(&cpu.IO, uint16(newaddr), value, false)
+		return value
+	}
+
+	if newaddr == ioports.OAM_PAGE_REGISTER && cpu.IO.OAM_EXTENSION {
+		value := ioports.READ_OAMPAGE(&cpu.IO)
+		ioports.LogAccess(&cpu.IO, uint16(newaddr), value, false)
+		return value
+	}
+
+	if newaddr >= 0x4020 && newaddr < 0x6000 {
+		ioports.NoteUnknownRead(&cpu.IO, uint16(newaddr))
+	}
+	return ioports.ReadRAM(&cpu.IO, newaddr)
+}
+
+func WM(cpu *CPU, cart *cartridge.Cartridge, addr uint16, value byte) {
+
+	if cpu.FlatBus {
+		cpu.IO.CPU_RAM[addr] = value
+		busAccess(cpu, addr, value, true)
+		return
+	}
+
+	ppu_handle := (addr >= 0x2000 && addr <= 0x3FFF) || (addr == 0x4014)
+	region, newaddr, err := mapper.MemoryMapper(&cpu.IO.BOARD, cart, addr)
+	if err != nil {
+		busFault(cpu, err)
+		return
+	}
+	if region == mapper.RegionPRG {
+		// The PRG-ROM itself is never written, the boards with registers
+		// latch them here
+		ioports.LogAccess(&cpu.IO, addr, value, true)
+		if ioports.WriteMapper(&cpu.IO, addr, value) == false {
+			ioports.NoteROMWrite(&cpu.IO)
+		}
+		return
+	}
+
+	ioports.LogAccess(&cpu.IO, uint16(newaddr), value, true)
+	if ((newaddr >= 0x2000 && newaddr < 0x2008) || (newaddr == 0x4014) && ppu_handle) {
+		ioports.WMPPU(&cpu.IO, cart, uint16(newaddr), value)
+		return
+	}
+
+	if newaddr == 0x4016 {
+		ioports.WRITE_JOYSTROBE(&cpu.IO, value)
+		return
+	}
+
+	if (newaddr >= 0x4000 && newaddr <= 0x4013) || newaddr == 0x4015 || newaddr == 0x4017 {
+		apu.WriteRegister(&cpu.IO.APU, uint16(newaddr), value)
+		return
+	}
+
+	// The CPU test registers are disabled
+	if newaddr >= 0x4018 && newaddr <= 0x401F {
+		return
+	}
+
+	if newaddr == ioports.OAM_PAGE_REGISTER && cpu.IO.OAM_EXTENSION {
+		ioports.WRITE_OAMPAGE(&cpu.IO, value)
+		return
+	}
+
+	// Some boards have their registers at $6000-$7FFF instead of PRG-RAM
+	if newaddr >= 0x6000 && ioports.WriteMapper(&cpu.IO, addr, value) {
+		return
+	}
+	if newaddr >= 0x4020 && newaddr < 0x6000 {
+		ioports.NoteUnknownWrite(&cpu.IO, uint16(newaddr))
+	}
+	
+	ioports.WriteRAM(&cpu.IO, newaddr, value)
+}
+
+func PushWord(cpu *CPU, v uint16) {
+  PushMemory(cpu, byte(v >> 8))
+  PushMemory(cpu, byte(v))
+}
+
+func PopWord(cpu *CPU) uint16 {
+    var lo, hi byte
+    hi = PopMemory(cpu);
+    lo = PopMemory(cpu);
+    return LE(hi, lo)
+}
+
+
+// The stack goes through the bus like any other access, so the access log
+// and the flat bus of the CPU tests see it.
+func PushMemory(cpu *CPU, v byte) {
+	WM(cpu, cpu.IO.CART, 0x0100 + uint16(cpu.SP), v)
+	cpu.SP--
+}
+
+func PopMemory(cpu *CPU) byte {
+	cpu.SP++
+	return RM(cpu, cpu.IO.CART, 0x0100 + uint16(cpu.SP))
+}
@@ -0,0 +1,333 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package cpu
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "fmt"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+
+func nmi(cpu *CPU, cart *cartridge.Cartridge) {
+	
+
+        PushWord(cpu, cpu.lastPC)
+	PushMemory (cpu, cpu.P)
+	cpu.PC = LE(RM(cpu, cart, 0xFFFA), RM(cpu, cart, 0xFFFB))
+	SetI(cpu, 1)
+	cpu.IO.PPUSTATUS.WRITTEN =0
+	cpu.CYC = 7
+	cpu.IO.PPU_MEMORY_STEP = 0
+        cpu.IO.VRAM_ADDRESS = 0
+}
+
+// Maskable interrupt: like BRK, but PC is not advanced and the pushed
+// status has B clear.
+func irq(cpu *CPU, cart *cartridge.Cartridge) {
+	PushWord(cpu, cpu.PC)
+	PushMemory(cpu, SetBit(SetBit(cpu.P, 4, 0), 5, 1))
+	cpu.PC = LE(RM(cpu, cart, 0xFFFE), RM(cpu, cart, 0xFFFF))
+	SetI(cpu, 1)
+	cpu.CYC = 7
+}
+
+// The IRQ line is polled at the end of each instruction. CLI, SEI and PLP
+// change the I flag after the poll, so the flag they found is the one
+// that masks until the next instruction.
+func irqMasked(cpu *CPU) bool {
+	if cpu.delayI {
+		return cpu.polledI == 1
+	}
+	return FlagI(cpu) == 1
+}
+
+func emulate (cpu *CPU, cart *cartridge.Cartridge) {
+
+        // Handle IO operations that takes CPU cycles
+        cpu.CYC = cpu.CYC + cpu.IO.CPU_CYC_INCREASE
+        cpu.IO.CPU_CYC_INCREASE = 0
+
+	
+	if cpu.CYC != 0 {
+		cpu.CYC--
+		return
+	}
+	
+
+
+
+	if cpu.D.Verbose && cpu.D.Enable { 
+		Verbose(cpu, cart)
+	}
+	
+	cpu.SwitchTimes++
+	
+	if cpu.D.Enable {
+		DebugCompare(cpu, cart)
+	}
+	
+	
+	// Check the limit of opcodes (Debug function)
+	cpu.Start = int(cpu.PC)
+	if cpu.Start >= cpu.End {
+		cpu.Running = false
+		return
+	}
+	
+	// Handle NMI Interruption
+	if cpu.IO.NMI {
+		nmi(cpu, cart)
+		cpu.IO.NMI = false
+		return	
+	}
+
+	if ioports.IRQ(&cpu.IO) && irqMasked(cpu) == false {
+		cpu.delayI = false
+		irq(cpu, cart)
+		return
+	}
+
+        cpu.lastPC = cpu.PC
+        cpu.IO.ACTIVITY.INSTRUCTIONS++
+        cpu.PCHistory[cpu.Instructions % PC_HISTORY] = cpu.PC
+        cpu.Instructions++
+
+	
+	code := RM(cpu, cart, cpu.PC)
+	cpu.polledI = FlagI(cpu)
+	cpu.delayI = code == 0x58 || code == 0x78 || code == 0x28
+	if !runOpcode(cpu, cart, code) {
+		fmt.Printf("Opcode not supported: %X \n", code)
+		cpu.IO.COMPAT.CRASH = fmt.Sprintf("opcode %02X not supported at $%04X", code, cpu.PC)
+		if cpu.D.Enable {
+			fmt.Printf("%s\n",cpu.D.Lines[cpu.SwitchTimes])
+		}
+		cpu.Running = false
+	}
+}
+
+// An entry of the dispatch table: the addressing mode, the size and the
+// cycles, and the instruction that gets the effective address.
+type opcode struct {
+	Name string
+	Mode func(cpu *CPU, cart *cartridge.Cartridge) uint16 // nil for implied
+	Size uint16 // 0 when the instruction sets PC itself
+	Cycles uint16
+	PageCycle bool // One more cycle when the indexing crosses a page
+	Branch bool // Plus the cycles of a taken branch (CYCSpecial)
+	Run func(cpu *CPU, cart *cartridge.Cartridge, addr uint16)
+}
+
+var opcodes [256]*opcode
+
+// Address of the operand of an immediate instruction.
+func ImmAddr(cpu *CPU, cart *cartridge.Cartridge) uint16 {
+	return cpu.PC + 1
+}
+
+// Runs the opcode from the table. Returns false when it has no entry.
+func runOpcode(cpu *CPU, cart *cartridge.Cartridge, code byte) bool {
+	op := opcodes[code]
+	if op == nil {
+		return false
+	}
+	var addr uint16
+	cpu.PageCrossed = 0
+	if op.Mode != nil {
+		addr = op.Mode(cpu, cart)
+	}
+	op.Run(cpu, cart, addr)
+	cpu.CYC = op.Cycles
+	if op.PageCycle && cpu.PageCrossed == 1 {
+		cpu.CYC++
+	}
+	if op.Branch {
+		cpu.CYC += cpu.CYCSpecial
+	}
+	if cpu.Running {
+		cpu.PC += op.Size
+	}
+	return true
+}
+
+// The official opcodes. The unofficial ones are in unofficial.go.
+func init() {
+	set := func(code byte, name string, mode func(*CPU, *cartridge.Cartridge) uint16, size uint16, cycles uint16, pageCycle bool, run func(*CPU, *cartridge.Cartridge, uint16)) {
+		opcodes[code] = &opcode{name, mode, size, cycles, pageCycle, false, run}
+	}
+
+	// Instructions that take the value at the address.
+	read := func(f func(*CPU, uint16)) func(*CPU, *cartridge.Cartridge, uint16) {
+		return func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+			f(cpu, uint16(RM(cpu, cart, addr)))
+		}
+	}
+	implied := func(f func(*CPU)) func(*CPU, *cartridge.Cartridge, uint16) {
+		return func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+			f(cpu)
+		}
+	}
+	// ROL and ROR tell the accumulator from the memory by the opcode.
+	rotate := func(f func(*CPU, *cartridge.Cartridge, uint16, byte), op byte) func(*CPU, *cartridge.Cartridge, uint16) {
+		return func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+			f(cpu, cart, addr, op)
+		}
+	}
+
+	// Columns: (ind,X), zp, #imm, abs, (ind),Y, zp,X, abs,Y, abs,X
+	alu := func(name string, base byte, f func(*CPU, uint16)) {
+		set(base + 0x01, name, IndX, 2, 6, false, read(f))
+		set(base + 0x05, name, Zp, 2, 3, false, read(f))
+		set(base + 0x09, name, ImmAddr, 2, 2, false, read(f))
+		set(base + 0x0D, name, Abs, 3, 4, false, read(f))
+		set(base + 0x11, name, IndY, 2, 5, true, read(f))
+		set(base + 0x15, name, ZpX, 2, 4, false, read(f))
+		set(base + 0x19, name, AbsY, 3, 4, true, read(f))
+		set(base + 0x1D, name, AbsX, 3, 4, true, read(f))
+	}
+	alu("ORA", 0x00, ORA)
+	alu("AND", 0x20, AND)
+	alu("EOR", 0x40, EOR)
+	alu("ADC", 0x60, ADC)
+	alu("LDA", 0xA0, LDA)
+	alu("CMP", 0xC0, CMP)
+	alu("SBC", 0xE0, SBC)
+
+	set(0x81, "STA", IndX, 2, 6, false, STA)
+	set(0x85, "STA", Zp, 2, 3, false, STA)
+	set(0x8D, "STA", Abs, 3, 4, false, STA)
+	set(0x91, "STA", IndY, 2, 5, true, STA)
+	set(0x95, "STA", ZpX, 2, 4, false, STA)
+	set(0x99, "STA", AbsY, 3, 5, false, STA)
+	set(0x9D, "STA", AbsX, 3, 5, false, STA)
+
+	set(0x86, "STX", Zp, 2, 3, false, STX)
+	set(0x8E, "STX", Abs, 3, 4, false, STX)
+	set(0x96, "STX", ZpY, 2, 4, false, STX)
+	set(0x84, "STY", Zp, 2, 3, false, STY)
+	set(0x8C, "STY", Abs, 3, 4, false, STY)
+	set(0x94, "STY", ZpX, 2, 4, false, STY)
+
+	set(0xA2, "LDX", ImmAddr, 2, 2, false, read(LDX))
+	set(0xA6, "LDX", Zp, 2, 3, false, read(LDX))
+	set(0xAE, "LDX", Abs, 3, 4, false, read(LDX))
+	set(0xB6, "LDX", ZpY, 2, 4, false, read(LDX))
+	set(0xBE, "LDX", AbsY, 3, 4, true, read(LDX))
+	set(0xA0, "LDY", ImmAddr, 2, 2, false, read(LDY))
+	set(0xA4, "LDY", Zp, 2, 3, false, read(LDY))
+	set(0xAC, "LDY", Abs, 3, 4, false, read(LDY))
+	set(0xB4, "LDY", ZpX, 2, 4, false, read(LDY))
+	set(0xBC, "LDY", AbsX, 3, 3, true, read(LDY))
+
+	set(0xE0, "CPX", ImmAddr, 2, 2, false, read(CPX))
+	set(0xE4, "CPX", Zp, 2, 3, false, read(CPX))
+	set(0xEC, "CPX", Abs, 3, 4, false, read(CPX))
+	set(0xC0, "CPY", ImmAddr, 2, 2, false, read(CPY))
+	set(0xC4, "CPY", Zp, 2, 3, false, read(CPY))
+	set(0xCC, "CPY", Abs, 3, 4, false, read(CPY))
+
+	set(0x24, "BIT", Zp, 2, 3, false, BIT)
+	set(0x2C, "BIT", Abs, 3, 4, false, BIT)
+
+	// Read-modify-write. Columns: acc, zp, abs, zp,X, abs,X
+	set(0x0A, "ASL", nil, 1, 2, false, ASL)
+	set(0x06, "ASL", Zp, 2, 5, false, ASL)
+	set(0x0E, "ASL", Abs, 3, 6, false, ASL)
+	set(0x16, "ASL", ZpX, 2, 6, false, ASL)
+	set(0x1E, "ASL", AbsX, 3, 7, false, ASL)
+	set(0x4A, "LSR", nil, 1, 2, false, LSR)
+	set(0x46, "LSR", Zp, 2, 5, false, LSR)
+	set(0x4E, "LSR", Abs, 3, 6, false, LSR)
+	set(0x56, "LSR", ZpX, 2, 6, false, LSR)
+	set(0x5E, "LSR", AbsX, 3, 7, false, LSR)
+	set(0x2A, "ROL", nil, 1, 2, false, rotate(ROL, 0x2A))
+	set(0x26, "ROL", Zp, 2, 5, false, rotate(ROL, 0x26))
+	set(0x2E, "ROL", Abs, 3, 6, false, rotate(ROL, 0x2E))
+	set(0x36, "ROL", ZpX, 2, 6, false, rotate(ROL, 0x36))
+	set(0x3E, "ROL", AbsX, 3, 7, false, rotate(ROL, 0x3E))
+	set(0x6A, "ROR", nil, 1, 2, false, rotate(ROR, 0x6A))
+	set(0x66, "ROR", Zp, 2, 5, false, rotate(ROR, 0x66))
+	set(0x6E, "ROR", Abs, 3, 6, false, rotate(ROR, 0x6E))
+	set(0x76, "ROR", ZpX, 2, 6, false, rotate(ROR, 0x76))
+	set(0x7E, "ROR", AbsX, 3, 7, false, rotate(ROR, 0x7E))
+	set(0xC6, "DEC", Zp, 2, 5, false, DEC)
+	set(0xCE, "DEC", Abs, 3, 6, false, DEC)
+	set(0xD6, "DEC", ZpX, 2, 6, false, DEC)
+	set(0xDE, "DEC", AbsX, 3, 7, false, DEC)
+	set(0xE6, "INC", Zp, 2, 5, false, INC)
+	set(0xEE, "INC", Abs, 3, 6, false, INC)
+	set(0xF6, "INC", ZpX, 2, 6, false, INC)
+	set(0xFE, "INC", AbsX, 3, 7, false, INC)
+
+	branch := func(code byte, name string, f func(*CPU, uint16)) {
+		opcodes[code] = &opcode{name, Rel, 0, 2, false, true, func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+			f(cpu, addr)
+		}}
+	}
+	branch(0x10, "BPL", BPL)
+	branch(0x30, "BMI", BMI)
+	branch(0x50, "BVC", BVC)
+	branch(0x70, "BVS", BVS)
+	branch(0x90, "BCC", BCC)
+	branch(0xB0, "BCS", BCS)
+	branch(0xD0, "BNE", BNE)
+	branch(0xF0, "BEQ", BEQ)
+
+	// Jumps set PC themselves, so their size is 0.
+	set(0x00, "BRK", nil, 0, 7, false, func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+		BRK(cpu, cart)
+	})
+	set(0x20, "JSR", Abs, 0, 6, false, func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+		JSR(cpu, addr)
+	})
+	set(0x4C, "JMP", Abs, 0, 3, false, func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+		JMP(cpu, addr)
+	})
+	set(0x6C, "JMP", Ind, 0, 3, false, func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+		JMP(cpu, addr)
+	})
+	set(0x40, "RTI", nil, 0, 6, false, implied(RTI))
+	set(0x60, "RTS", nil, 0, 6, false, implied(RTS))
+
+	set(0x08, "PHP", nil, 1, 3, false, implied(PHP))
+	set(0x28, "PLP", nil, 1, 4, false, implied(PLP))
+	set(0x48, "PHA", nil, 1, 3, false, implied(PHA))
+	set(0x68, "PLA", nil, 1, 4, false, implied(PLA))
+	set(0x18, "CLC", nil, 1, 2, false, implied(CLC))
+	set(0x38, "SEC", nil, 1, 2, false, implied(SEC))
+	set(0x58, "CLI", nil, 1, 2, false, implied(CLI))
+	set(0x78, "SEI", nil, 1, 2, false, implied(SEI))
+	set(0xB8, "CLV", nil, 1, 2, false, implied(CLV))
+	set(0xD8, "CLD", nil, 1, 2, false, implied(CLD))
+	set(0xF8, "SED", nil, 1, 2, false, implied(SED))
+	set(0xAA, "TAX", nil, 1, 2, false, implied(TAX))
+	set(0xA8, "TAY", nil, 1, 2, false, implied(TAY))
+	set(0xBA, "TSX", nil, 1, 2, false, implied(TSX))
+	set(0x8A, "TXA", nil, 1, 2, false, implied(TXA))
+	set(0x9A, "TXS", nil, 1, 2, false, implied(TXS))
+	set(0x98, "TYA", nil, 1, 2, false, implied(TYA))
+	set(0xCA, "DEX", nil, 1, 2, false, implied(DEX))
+	set(0x88, "DEY", nil, 1, 2, false, implied(DEY))
+	set(0xE8, "INX", nil, 1, 2, false, implied(INX))
+	set(0xC8, "INY", nil, 1, 2, false, implied(INY))
+	set(0xEA, "NOP", nil, 1, 2, false, func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {})
+}
+
+func Verbose(cpu *CPU, cart *cartridge.Cartridge) {
+	cycles, scanline, dot := ioports.Timestamp(&cpu.IO)
+	fmt.Printf("%4X  %2X  %2X %2X                       A:%2X X:%2X Y:%2X P:%2X SP:%2X CYC:%3d SL:%d CPU:%d\n", cpu.PC, RM(cpu, cart, cpu.PC), RM(cpu, cart, cpu.PC+1), RM(cpu, cart, cpu.PC+2), cpu.A, cpu.X, cpu.Y, cpu.P, cpu.SP, dot, scanline, cycles )
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package cpu
+
+import "testing"
+
+func TestEveryOpcodeHasAnEntry(t *testing.T) {
+	for code, op := range opcodes {
+		if op == nil {
+			t.Errorf("opcode %02X has no entry", code)
+			continue
+		}
+		if op.Run == nil || op.Cycles == 0 {
+			t.Errorf("opcode %02X (%s) is incomplete", code, op.Name)
+		}
+	}
+}
+
+func TestBranchCycles(t *testing.T) {
+	cpu := StartFlatCPU()
+	cpu.PC = 0x80F0
+	cpu.IO.CPU_RAM[0x80F0] = 0xD0 // BNE +4
+	cpu.IO.CPU_RAM[0x80F1] = 0x04
+	SetZ(&cpu, 0)
+	if cycles := RunInstruction(&cpu); cycles != 3 || cpu.PC != 0x80F6 {
+		t.Errorf("taken: %d cycles, PC %04X", cycles, cpu.PC)
+	}
+	cpu.PC = 0x80F0
+	SetZ(&cpu, 1)
+	if cycles := RunInstruction(&cpu); cycles != 2 || cpu.PC != 0x80F2 {
+		t.Errorf("not taken: %d cycles, PC %04X", cycles, cpu.PC)
+	}
+}
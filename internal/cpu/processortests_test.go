@@ -0,0 +1,176 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package cpu
+
+import "encoding/json"
+import "flag"
+import "fmt"
+import "os"
+import "path/filepath"
+import "strings"
+import "testing"
+
+// Runs the ProcessorTests 6502 suite (the nes6502 set, one JSON file per
+// opcode) through the core on the flat bus. Registers, memory, the cycle
+// count and every read and write on the bus must match. testdata holds a
+// few cases in the same format; the whole suite runs with
+//
+//	go test ./internal/cpu -processortests ProcessorTests/nes6502/v1
+
+var processorTests = flag.String("processortests", "", "directory of the ProcessorTests JSON files")
+
+type processorState struct {
+	PC uint16 `json:"pc"`
+	S byte `json:"s"`
+	A byte `json:"a"`
+	X byte `json:"x"`
+	Y byte `json:"y"`
+	P byte `json:"p"`
+	RAM [][2]int `json:"ram"`
+}
+
+type processorTest struct {
+	Name string `json:"name"`
+	Initial processorState `json:"initial"`
+	Final processorState `json:"final"`
+	Cycles [][3]interface{} `json:"cycles"`
+}
+
+func TestProcessorTests(t *testing.T) {
+	dir := *processorTests
+	if dir == "" {
+		dir = "testdata"
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) == 0 {
+		t.Fatalf("no JSON files in %s", dir)
+	}
+	for _, file := range files {
+		file := file
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			runProcessorFile(t, file)
+		})
+	}
+}
+
+func runProcessorFile(t *testing.T, file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests []processorTest
+	if err := json.Unmarshal(data, &tests); err != nil {
+		t.Fatal(err)
+	}
+
+	cpu := StartFlatCPU()
+	failed := 0
+	for _, test := range tests {
+		problem := runProcessorTest(&cpu, &test)
+		if cpu.Running == false {
+			// The JAM opcodes halt the CPU like the console
+			t.Skip("halts the CPU")
+		}
+		if problem == "" {
+			continue
+		}
+		if failed == 0 {
+			t.Errorf("%s: %s", test.Name, problem)
+		}
+		failed++
+	}
+	if failed > 1 {
+		t.Errorf("%d of %d cases differ", failed, len(tests))
+	}
+}
+
+// Returns the differences in the final state and on the bus, "" when the
+// instruction matched the test.
+func runProcessorTest(cpu *CPU, test *processorTest) string {
+	for _, cell := range test.Initial.RAM {
+		cpu.IO.CPU_RAM[cell[0]] = byte(cell[1])
+	}
+	cpu.PC = test.Initial.PC
+	cpu.SP = test.Initial.S
+	cpu.A = test.Initial.A
+	cpu.X = test.Initial.X
+	cpu.Y = test.Initial.Y
+	cpu.P = test.Initial.P
+	cpu.Running = true
+
+	cycles := RunInstruction(cpu)
+
+	var problems []string
+	expect := func(what string, got int, want int) {
+		if got != want {
+			problems = append(problems, fmt.Sprintf("%s %X (want %X)", what, got, want))
+		}
+	}
+	expect("PC", int(cpu.PC), int(test.Final.PC))
+	expect("S", int(cpu.SP), int(test.Final.S))
+	expect("A", int(cpu.A), int(test.Final.A))
+	expect("X", int(cpu.X), int(test.Final.X))
+	expect("Y", int(cpu.Y), int(test.Final.Y))
+	expect("P", int(cpu.P), int(test.Final.P))
+	for _, cell := range test.Final.RAM {
+		expect(fmt.Sprintf("[%04X]", cell[0]), int(cpu.IO.CPU_RAM[cell[0]]), cell[1])
+	}
+	expect("cycles", cycles, len(test.Cycles))
+	if bus := compareBus(cpu.BusLog, test.Cycles); bus != "" {
+		problems = append(problems, bus)
+	}
+
+	// Leave the flat RAM clean for the next case.
+	for _, access := range cpu.BusLog {
+		cpu.IO.CPU_RAM[access.Addr] = 0
+	}
+	for _, cell := range test.Initial.RAM {
+		cpu.IO.CPU_RAM[cell[0]] = 0
+	}
+
+	return strings.Join(problems, ", ")
+}
+
+// Compares the accesses the core made with the cycles array of a test and
+// describes the first cycle that differs.
+func compareBus(log []BusAccess, cycles [][3]interface{}) string {
+	for i, cycle := range cycles {
+		addr, _ := cycle[0].(float64)
+		value, _ := cycle[1].(float64)
+		kind, _ := cycle[2].(string)
+		want := BusAccess{Addr: uint16(addr), Value: byte(value), Write: kind == "write"}
+		if i >= len(log) {
+			return fmt.Sprintf("cycle %d: nothing (want %s)", i + 1, describeAccess(want))
+		}
+		if log[i] != want {
+			return fmt.Sprintf("cycle %d: %s (want %s)", i + 1, describeAccess(log[i]), describeAccess(want))
+		}
+	}
+	if len(log) > len(cycles) {
+		return fmt.Sprintf("cycle %d: %s (want nothing)", len(cycles) + 1, describeAccess(log[len(cycles)]))
+	}
+	return ""
+}
+
+func describeAccess(access BusAccess) string {
+	if access.Write {
+		return fmt.Sprintf("write %04X=%02X", access.Addr, access.Value)
+	}
+	return fmt.Sprintf("read %04X=%02X", access.Addr, access.Value)
+}
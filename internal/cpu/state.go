@@ -0,0 +1,51 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package cpu
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/snapshot"
+
+// Savestate encoding of the registers. The bus is encoded by ioports and
+// the debugger state is not saved.
+func EncodeState(cpu *CPU, e *snapshot.Encoder) {
+	snapshot.PutByte(e, cpu.A)
+	snapshot.PutByte(e, cpu.X)
+	snapshot.PutByte(e, cpu.Y)
+	snapshot.PutByte(e, cpu.P)
+	snapshot.PutUint16(e, cpu.PC)
+	snapshot.PutUint16(e, cpu.lastPC)
+	snapshot.PutByte(e, cpu.SP)
+	snapshot.PutUint16(e, cpu.CYC)
+	snapshot.PutUint16(e, cpu.CYCSpecial)
+	snapshot.PutByte(e, cpu.PageCrossed)
+	snapshot.PutBool(e, cpu.Running)
+}
+
+func DecodeState(cpu *CPU, d *snapshot.Decoder) {
+	cpu.A = snapshot.Byte(d)
+	cpu.X = snapshot.Byte(d)
+	cpu.Y = snapshot.Byte(d)
+	cpu.P = snapshot.Byte(d)
+	cpu.PC = snapshot.Uint16(d)
+	cpu.lastPC = snapshot.Uint16(d)
+	cpu.SP = snapshot.Byte(d)
+	cpu.CYC = snapshot.Uint16(d)
+	cpu.CYCSpecial = snapshot.Uint16(d)
+	cpu.PageCrossed = snapshot.Byte(d)
+	cpu.Running = snapshot.Bool(d)
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package cpu
+
+import "fmt"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+
+// Unofficial opcodes: the combined read-modify-write instructions (SLO,
+// RLA, SRE, RRA, DCP, ISB), LAX and SAX, the immediate ones (ANC, ALR,
+// ARR, AXS, the $EB SBC), the NOPs, the unstable stores of the high byte
+// (SHA, SHX, SHY, TAS) and the JAMs that halt the CPU. XAA and LXA take
+// $EE as the magic constant of the unstable bits, like most emulators and
+// the ProcessorTests suite.
+//
+// They go in the same dispatch table as the official opcodes, see
+// runOpcode.
+
+func init() {
+	set := func(code byte, name string, mode func(*CPU, *cartridge.Cartridge) uint16, size uint16, cycles uint16, pageCycle bool, run func(*CPU, *cartridge.Cartridge, uint16)) {
+		opcodes[code] = &opcode{name, mode, size, cycles, pageCycle, false, run}
+	}
+
+	// Read-modify-write then an accumulator operation. Columns: (ind,X),
+	// zp, abs, (ind),Y, zp,X, abs,Y, abs,X
+	rmw := func(name string, base byte, run func(*CPU, *cartridge.Cartridge, uint16)) {
+		set(base + 0x03, name, IndX, 2, 8, false, run)
+		set(base + 0x07, name, Zp, 2, 5, false, run)
+		set(base + 0x0F, name, Abs, 3, 6, false, run)
+		set(base + 0x13, name, IndY, 2, 8, false, run)
+		set(base + 0x17, name, ZpX, 2, 6, false, run)
+		set(base + 0x1B, name, AbsY, 3, 7, false, run)
+		set(base + 0x1F, name, AbsX, 3, 7, false, run)
+	}
+	rmw("SLO", 0x00, SLO)
+	rmw("RLA", 0x20, RLA)
+	rmw("SRE", 0x40, SRE)
+	rmw("RRA", 0x60, RRA)
+	rmw("DCP", 0xC0, DCP)
+	rmw("ISB", 0xE0, ISB)
+
+	set(0x83, "SAX", IndX, 2, 6, false, SAX)
+	set(0x87, "SAX", Zp, 2, 3, false, SAX)
+	set(0x8F, "SAX", Abs, 3, 4, false, SAX)
+	set(0x97, "SAX", ZpY, 2, 4, false, SAX)
+
+	set(0xA3, "LAX", IndX, 2, 6, false, LAX)
+	set(0xA7, "LAX", Zp, 2, 3, false, LAX)
+	set(0xAF, "LAX", Abs, 3, 4, false, LAX)
+	set(0xB3, "LAX", IndY, 2, 5, true, LAX)
+	set(0xB7, "LAX", ZpY, 2, 4, false, LAX)
+	set(0xBF, "LAX", AbsY, 3, 4, true, LAX)
+	set(0xAB, "LXA", ImmAddr, 2, 2, false, LXA)
+
+	set(0x0B, "ANC", ImmAddr, 2, 2, false, ANC)
+	set(0x2B, "ANC", ImmAddr, 2, 2, false, ANC)
+	set(0x4B, "ALR", ImmAddr, 2, 2, false, ALR)
+	set(0x6B, "ARR", ImmAddr, 2, 2, false, ARR)
+	set(0x8B, "XAA", ImmAddr, 2, 2, false, XAA)
+	set(0xCB, "AXS", ImmAddr, 2, 2, false, AXS)
+	set(0xEB, "SBC", ImmAddr, 2, 2, false, func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+		SBC(cpu, uint16(RM(cpu, cart, addr)))
+	})
+	set(0xBB, "LAS", AbsY, 3, 4, true, LAS)
+
+	set(0x93, "SHA", IndY, 2, 6, false, SHA)
+	set(0x9F, "SHA", AbsY, 3, 5, false, SHA)
+	set(0x9E, "SHX", AbsY, 3, 5, false, SHX)
+	set(0x9C, "SHY", AbsX, 3, 5, false, SHY)
+	set(0x9B, "TAS", AbsY, 3, 5, false, TAS)
+
+	nop := func(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {}
+	for _, code := range []byte{0x82, 0x89, 0xC2, 0xE2} {
+		set(code, "NOP", ImmAddr, 2, 2, false, nop)
+	}
+	// These skip their operand without reading it.
+	for _, code := range []byte{0x1A, 0x3A, 0x5A, 0x7A, 0xDA, 0xFA} {
+		set(code, "NOP", nil, 1, 2, false, nop)
+	}
+	for _, code := range []byte{0x04, 0x14, 0x34, 0x44, 0x54, 0x64, 0x74, 0x80, 0xD4, 0xF4} {
+		set(code, "NOP", nil, 2, 2, false, nop)
+	}
+	for _, code := range []byte{0x0C, 0x1C, 0x3C, 0x5C, 0x7C, 0xDC, 0xFC} {
+		set(code, "NOP", nil, 3, 2, false, nop)
+	}
+
+	for _, code := range []byte{0x02, 0x12, 0x22, 0x32, 0x42, 0x52, 0x62, 0x72, 0x92, 0xB2, 0xD2, 0xF2} {
+		set(code, "JAM", nil, 1, 2, false, JAM)
+	}
+}
+
+// ASL of the memory, then ORA with the result.
+func SLO(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	ASL(cpu, cart, addr)
+	ORA(cpu, uint16(RM(cpu, cart, addr)))
+}
+
+// ROL of the memory, then AND with the result.
+func RLA(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	ROL(cpu, cart, addr, RM(cpu, cart, cpu.PC))
+	AND(cpu, uint16(RM(cpu, cart, addr)))
+}
+
+// LSR of the memory, then EOR with the result.
+func SRE(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	LSR(cpu, cart, addr)
+	EOR(cpu, uint16(RM(cpu, cart, addr)))
+}
+
+// ROR of the memory, then ADC of the result with the carry it shifted out.
+func RRA(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	ROR(cpu, cart, addr, RM(cpu, cart, cpu.PC))
+	ADC(cpu, uint16(RM(cpu, cart, addr)))
+}
+
+// DEC of the memory, then CMP with the result.
+func DCP(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	DEC(cpu, cart, addr)
+	CMP(cpu, uint16(RM(cpu, cart, addr)))
+}
+
+// INC of the memory, then SBC of the result.
+func ISB(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	INC(cpu, cart, addr)
+	SBC(cpu, uint16(RM(cpu, cart, addr)))
+}
+
+// Stores A AND X, the flags are left alone.
+func SAX(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	WM(cpu, cart, addr, cpu.A & cpu.X)
+}
+
+// LDA and LDX of the same byte.
+func LAX(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	LDA(cpu, uint16(RM(cpu, cart, addr)))
+	cpu.X = cpu.A
+}
+
+func LXA(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	LDA(cpu, uint16((cpu.A | 0xEE) & RM(cpu, cart, addr)))
+	cpu.X = cpu.A
+}
+
+// AND, with bit 7 of the result copied to the carry.
+func ANC(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	AND(cpu, uint16(RM(cpu, cart, addr)))
+	SetC(cpu, Bit7(cpu.A))
+}
+
+// AND, then LSR of the accumulator.
+func ALR(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	AND(cpu, uint16(RM(cpu, cart, addr)))
+	SetC(cpu, Bit0(cpu.A))
+	cpu.A >>= 1
+	ZeroFlag(cpu, uint16(cpu.A))
+	SetN(cpu, 0)
+}
+
+// AND, then ROR of the accumulator. The carry is bit 6 of the result and
+// the overflow bit 6 XOR bit 5.
+func ARR(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	cpu.A = ((cpu.A & RM(cpu, cart, addr)) >> 1) | (FlagC(cpu) << 7)
+	ZeroFlag(cpu, uint16(cpu.A))
+	SetN(cpu, Bit7(cpu.A))
+	SetC(cpu, Bit6(cpu.A))
+	SetV(cpu, Bit6(cpu.A) ^ Bit5(cpu.A))
+}
+
+func XAA(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	LDA(cpu, uint16((cpu.A | 0xEE) & cpu.X & RM(cpu, cart, addr)))
+}
+
+// X gets A AND X minus the operand, without borrow. The carry is set as
+// by CMP.
+func AXS(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	ax := cpu.A & cpu.X
+	value := RM(cpu, cart, addr)
+	SetC(cpu, 0)
+	if ax >= value {
+		SetC(cpu, 1)
+	}
+	cpu.X = ax - value
+	ZeroFlag(cpu, uint16(cpu.X))
+	SetN(cpu, Bit7(cpu.X))
+}
+
+// A, X and the stack pointer get the memory AND the stack pointer.
+func LAS(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	LDA(cpu, uint16(RM(cpu, cart, addr) & cpu.SP))
+	cpu.X = cpu.A
+	cpu.SP = cpu.A
+}
+
+// Stores value AND the high byte of the base address plus one. When the
+// indexing crosses a page the high byte of the address is the stored
+// value.
+func storeHigh(cpu *CPU, cart *cartridge.Cartridge, addr uint16, index byte, value byte) {
+	base := addr - uint16(index)
+	value &= H(base) + 1
+	if H(base) != H(addr) {
+		addr = uint16(value) << 8 | uint16(L(addr))
+	}
+	WM(cpu, cart, addr, value)
+}
+
+func SHA(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	storeHigh(cpu, cart, addr, cpu.Y, cpu.A & cpu.X)
+}
+
+func SHX(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	storeHigh(cpu, cart, addr, cpu.Y, cpu.X)
+}
+
+func SHY(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	storeHigh(cpu, cart, addr, cpu.X, cpu.Y)
+}
+
+// The stack pointer gets A AND X, then it is stored like SHA.
+func TAS(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	cpu.SP = cpu.A & cpu.X
+	storeHigh(cpu, cart, addr, cpu.Y, cpu.SP)
+}
+
+// Halts the CPU until a reset, like the console.
+func JAM(cpu *CPU, cart *cartridge.Cartridge, addr uint16) {
+	code := RM(cpu, cart, cpu.PC)
+	fmt.Printf("CPU jammed by opcode %02X at $%04X\n", code, cpu.PC)
+	cpu.IO.COMPAT.CRASH = fmt.Sprintf("jammed by opcode %02X at $%04X", code, cpu.PC)
+	cpu.Running = false
+}
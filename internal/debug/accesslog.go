@@ -0,0 +1,142 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package debug
+
+import "fmt"
+import "io"
+import "strconv"
+import "strings"
+
+// Register access log. Reads and writes that match one of the filters are
+// kept in a ring buffer that can be dumped at any time.
+
+type Access struct {
+	Cycle uint64 // CPU cycle
+	Scanline int
+	Dot int
+	Addr uint16
+	Value byte
+	Write bool
+	Event string // Set for events that are not accesses, Addr and Value are unused
+}
+
+type AddrRange struct {
+	First uint16
+	Last uint16
+}
+
+type AccessLog struct {
+	Enable bool
+	Entries []Access
+	Next int
+	Count int // Accesses recorded since the log was started
+	Filters []AddrRange
+}
+
+// PPU registers, APU and I/O registers and the mapper registers.
+var DefaultAccessFilters = []AddrRange{{0x2000, 0x2007}, {0x4000, 0x401F}, {0x8000, 0xFFFF}}
+
+func StartAccessLog(size int, filters []AddrRange) AccessLog {
+	var l AccessLog
+	l.Enable = true
+	l.Entries = make([]Access, size)
+	l.Next = 0
+	l.Count = 0
+	l.Filters = filters
+	if len(l.Filters) == 0 {
+		l.Filters = DefaultAccessFilters
+	}
+	return l
+}
+
+// Parses filters like "2000-2007,4016".
+func ParseAccessFilters(text string) ([]AddrRange, error) {
+	var filters []AddrRange
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		bounds := strings.SplitN(item, "-", 2)
+		first, err := strconv.ParseUint(strings.TrimPrefix(bounds[0], "$"), 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s", bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.ParseUint(strings.TrimPrefix(bounds[1], "$"), 16, 16)
+			if err != nil || last < first {
+				return nil, fmt.Errorf("invalid range %s", item)
+			}
+		}
+		filters = append(filters, AddrRange{uint16(first), uint16(last)})
+	}
+	return filters, nil
+}
+
+func AccessMatches(l *AccessLog, addr uint16) bool {
+	for _, f := range l.Filters {
+		if addr >= f.First && addr <= f.Last {
+			return true
+		}
+	}
+	return false
+}
+
+func RecordAccess(l *AccessLog, a Access) {
+	if l.Enable == false || AccessMatches(l, a.Addr) == false {
+		return
+	}
+	l.Entries[l.Next] = a
+	l.Next = (l.Next + 1) % len(l.Entries)
+	l.Count++
+}
+
+// Records an event whatever the filters are.
+func RecordEvent(l *AccessLog, a Access) {
+	if l.Enable == false {
+		return
+	}
+	l.Entries[l.Next] = a
+	l.Next = (l.Next + 1) % len(l.Entries)
+	l.Count++
+}
+
+// Writes the buffered accesses, oldest first.
+func DumpAccessLog(l *AccessLog, w io.Writer) {
+	size := len(l.Entries)
+	start := 0
+	if l.Count >= size {
+		start = l.Next
+	} else {
+		size = l.Count
+	}
+	for i := 0; i < size; i++ {
+		a := l.Entries[(start + i) % len(l.Entries)]
+		if a.Event != "" {
+			fmt.Fprintf(w, "CPU:%d SL:%d DOT:%d %s\n", a.Cycle, a.Scanline, a.Dot, a.Event)
+			continue
+		}
+		op := "R"
+		if a.Write {
+			op = "W"
+		}
+		fmt.Fprintf(w, "CPU:%d SL:%d DOT:%d %s $%04X = $%02X\n", a.Cycle, a.Scanline, a.Dot, op, a.Addr, a.Value)
+	}
+}
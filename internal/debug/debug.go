@@ -21,7 +21,7 @@ package debug
 import "fmt"
 import "io/ioutil"
 import "strings"
-//import "zerojnt/ppu"
+//import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
 import "log"
 
 type Debug struct {
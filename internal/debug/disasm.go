@@ -0,0 +1,191 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package debug
+
+import "fmt"
+import "io"
+
+// 6502 disassembler for the official opcodes. Other bytes are listed as
+// data with .db.
+
+const (
+	modeImplied = iota
+	modeAccumulator
+	modeImmediate
+	modeZeroPage
+	modeZeroPageX
+	modeZeroPageY
+	modeAbsolute
+	modeAbsoluteX
+	modeAbsoluteY
+	modeIndirect
+	modeIndirectX
+	modeIndirectY
+	modeRelative
+)
+
+// Instruction size of each addressing mode.
+var modeSizes = [...]int{1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 2, 2, 2}
+
+type opcodeInfo struct {
+	Name string
+	Mode int
+}
+
+var opcodeTable [256]opcodeInfo
+
+func init() {
+	set := func(name string, mode int, opcodes ...byte) {
+		for _, op := range opcodes {
+			opcodeTable[op] = opcodeInfo{name, mode}
+		}
+	}
+
+	// Opcodes by mode: immediate, zero page, zero page indexed, absolute,
+	// absolute,X, absolute,Y, (indirect,X), (indirect),Y
+	group := func(name string, ops [8]int) {
+		modes := [8]int{modeImmediate, modeZeroPage, modeZeroPageX, modeAbsolute, modeAbsoluteX, modeAbsoluteY, modeIndirectX, modeIndirectY}
+		for i, op := range ops {
+			if op >= 0 {
+				set(name, modes[i], byte(op))
+			}
+		}
+	}
+	group("ORA", [8]int{0x09, 0x05, 0x15, 0x0D, 0x1D, 0x19, 0x01, 0x11})
+	group("AND", [8]int{0x29, 0x25, 0x35, 0x2D, 0x3D, 0x39, 0x21, 0x31})
+	group("EOR", [8]int{0x49, 0x45, 0x55, 0x4D, 0x5D, 0x59, 0x41, 0x51})
+	group("ADC", [8]int{0x69, 0x65, 0x75, 0x6D, 0x7D, 0x79, 0x61, 0x71})
+	group("STA", [8]int{-1, 0x85, 0x95, 0x8D, 0x9D, 0x99, 0x81, 0x91})
+	group("LDA", [8]int{0xA9, 0xA5, 0xB5, 0xAD, 0xBD, 0xB9, 0xA1, 0xB1})
+	group("CMP", [8]int{0xC9, 0xC5, 0xD5, 0xCD, 0xDD, 0xD9, 0xC1, 0xD1})
+	group("SBC", [8]int{0xE9, 0xE5, 0xF5, 0xED, 0xFD, 0xF9, 0xE1, 0xF1})
+	group("ASL", [8]int{-1, 0x06, 0x16, 0x0E, 0x1E, -1, -1, -1})
+	group("ROL", [8]int{-1, 0x26, 0x36, 0x2E, 0x3E, -1, -1, -1})
+	group("LSR", [8]int{-1, 0x46, 0x56, 0x4E, 0x5E, -1, -1, -1})
+	group("ROR", [8]int{-1, 0x66, 0x76, 0x6E, 0x7E, -1, -1, -1})
+	group("DEC", [8]int{-1, 0xC6, 0xD6, 0xCE, 0xDE, -1, -1, -1})
+	group("INC", [8]int{-1, 0xE6, 0xF6, 0xEE, 0xFE, -1, -1, -1})
+	group("LDY", [8]int{0xA0, 0xA4, 0xB4, 0xAC, 0xBC, -1, -1, -1})
+	group("STY", [8]int{-1, 0x84, 0x94, 0x8C, -1, -1, -1, -1})
+	group("CPY", [8]int{0xC0, 0xC4, -1, 0xCC, -1, -1, -1, -1})
+	group("CPX", [8]int{0xE0, 0xE4, -1, 0xEC, -1, -1, -1, -1})
+	group("BIT", [8]int{-1, 0x24, -1, 0x2C, -1, -1, -1, -1})
+
+	// LDX and STX index zero page with Y
+	set("LDX", modeImmediate, 0xA2)
+	set("LDX", modeZeroPage, 0xA6)
+	set("LDX", modeZeroPageY, 0xB6)
+	set("LDX", modeAbsolute, 0xAE)
+	set("LDX", modeAbsoluteY, 0xBE)
+	set("STX", modeZeroPage, 0x86)
+	set("STX", modeZeroPageY, 0x96)
+	set("STX", modeAbsolute, 0x8E)
+
+	set("ASL", modeAccumulator, 0x0A)
+	set("ROL", modeAccumulator, 0x2A)
+	set("LSR", modeAccumulator, 0x4A)
+	set("ROR", modeAccumulator, 0x6A)
+
+	set("JMP", modeAbsolute, 0x4C)
+	set("JMP", modeIndirect, 0x6C)
+	set("JSR", modeAbsolute, 0x20)
+
+	branches := []string{"BPL", "BMI", "BVC", "BVS", "BCC", "BCS", "BNE", "BEQ"}
+	for i, name := range branches {
+		set(name, modeRelative, byte(0x10 + i * 0x20))
+	}
+
+	implied := map[byte]string{
+		0x00: "BRK", 0x08: "PHP", 0x18: "CLC", 0x28: "PLP", 0x38: "SEC",
+		0x40: "RTI", 0x48: "PHA", 0x58: "CLI", 0x60: "RTS", 0x68: "PLA",
+		0x78: "SEI", 0x88: "DEY", 0x8A: "TXA", 0x98: "TYA", 0x9A: "TXS",
+		0xA8: "TAY", 0xAA: "TAX", 0xB8: "CLV", 0xBA: "TSX", 0xC8: "INY",
+		0xCA: "DEX", 0xD8: "CLD", 0xE8: "INX", 0xEA: "NOP", 0xF8: "SED",
+	}
+	for op, name := range implied {
+		set(name, modeImplied, op)
+	}
+}
+
+// Disassembles the instruction at the start of code, which the CPU sees at
+// address pc. Returns the text and the number of bytes used.
+func Disassemble(code []byte, pc uint16) (string, int) {
+	if len(code) == 0 {
+		return "", 0
+	}
+	info := opcodeTable[code[0]]
+	if info.Name == "" {
+		return fmt.Sprintf(".db $%02X", code[0]), 1
+	}
+	size := modeSizes[info.Mode]
+	if len(code) < size {
+		return fmt.Sprintf(".db $%02X", code[0]), 1
+	}
+
+	var operand uint16
+	if size == 2 {
+		operand = uint16(code[1])
+	} else if size == 3 {
+		operand = uint16(code[1]) | uint16(code[2]) << 8
+	}
+
+	switch info.Mode {
+		case modeAccumulator:
+			return info.Name + " A", size
+		case modeImmediate:
+			return fmt.Sprintf("%s #$%02X", info.Name, operand), size
+		case modeZeroPage:
+			return fmt.Sprintf("%s $%02X", info.Name, operand), size
+		case modeZeroPageX:
+			return fmt.Sprintf("%s $%02X,X", info.Name, operand), size
+		case modeZeroPageY:
+			return fmt.Sprintf("%s $%02X,Y", info.Name, operand), size
+		case modeAbsolute:
+			return fmt.Sprintf("%s $%04X", info.Name, operand), size
+		case modeAbsoluteX:
+			return fmt.Sprintf("%s $%04X,X", info.Name, operand), size
+		case modeAbsoluteY:
+			return fmt.Sprintf("%s $%04X,Y", info.Name, operand), size
+		case modeIndirect:
+			return fmt.Sprintf("%s ($%04X)", info.Name, operand), size
+		case modeIndirectX:
+			return fmt.Sprintf("%s ($%02X,X)", info.Name, operand), size
+		case modeIndirectY:
+			return fmt.Sprintf("%s ($%02X),Y", info.Name, operand), size
+		case modeRelative:
+			target := pc + 2 + uint16(int8(code[1]))
+			return fmt.Sprintf("%s $%04X", info.Name, target), size
+	}
+	return info.Name, size
+}
+
+// Writes a listing of code loaded at address base, one instruction per
+// line with its address and bytes, like the trace logs.
+func WriteListing(w io.Writer, code []byte, base uint16) {
+	for i := 0; i < len(code); {
+		pc := base + uint16(i)
+		text, size := Disassemble(code[i:], pc)
+		bytes := ""
+		for _, b := range code[i:i+size] {
+			bytes += fmt.Sprintf("%02X ", b)
+		}
+		fmt.Fprintf(w, "%04X  %-9s %s\n", pc, bytes, text)
+		i += size
+	}
+}
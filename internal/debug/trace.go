@@ -0,0 +1,140 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package debug
+
+import "bufio"
+import "os"
+import "strconv"
+import "strings"
+
+// Trace logs of other emulators. Nintendulator (nestest.log), FCEUX and
+// Mesen all write one line per instruction with the program counter first
+// and the registers as NAME:VALUE fields before the instruction runs:
+//
+//	C000  4C F5 C5  JMP $C5F5     A:00 X:00 Y:00 P:24 SP:FD PPU:  0, 21 CYC:7
+//	$C000:4C F5 C5  JMP $C5F5     A:00 X:00 Y:00 S:FD P:nvubdIzc
+//	C000 $4C $F5 $C5  JMP $C5F5   A:00 X:00 Y:00 S:FD P:nvUbdIzc Cy:7
+//
+// Lines that do not start with an address, like the interrupt markers of
+// some loggers, are skipped.
+
+type TraceLine struct {
+	Line int // In the file, from 1
+	Text string
+	PC uint16
+	A byte
+	X byte
+	Y byte
+	P byte
+	SP byte
+	Cycles uint64 // CPU cycles since power up, when HasCycles
+	HasCycles bool
+}
+
+// Flag letters of P from bit 7 to bit 0, uppercase when set.
+const traceFlags = "NV-BDIZC"
+
+func ReadTrace(filename string) ([]TraceLine, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []TraceLine
+	scanner := bufio.NewScanner(file)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line, found := ParseTraceLine(scanner.Text())
+		if found {
+			line.Line = n
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// Parses a line of a trace. Returns false if it does not hold an
+// instruction with its registers.
+func ParseTraceLine(text string) (TraceLine, bool) {
+	var t TraceLine
+	t.Text = text
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return t, false
+	}
+
+	address := strings.SplitN(strings.TrimPrefix(fields[0], "$"), ":", 2)[0]
+	pc, err := strconv.ParseUint(address, 16, 16)
+	if err != nil || len(address) != 4 {
+		return t, false
+	}
+	t.PC = uint16(pc)
+
+	registers := 0
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch(kv[0]) {
+			case "A":
+				t.A, registers = traceByte(kv[1], registers)
+			case "X":
+				t.X, registers = traceByte(kv[1], registers)
+			case "Y":
+				t.Y, registers = traceByte(kv[1], registers)
+			case "S", "SP":
+				t.SP, registers = traceByte(kv[1], registers)
+			case "P":
+				if len(kv[1]) == len(traceFlags) {
+					t.P = traceFlagBits(kv[1])
+					registers++
+				} else {
+					t.P, registers = traceByte(kv[1], registers)
+				}
+			case "CYC", "Cy":
+				cycles, err := strconv.ParseUint(kv[1], 10, 64)
+				if err == nil {
+					t.Cycles = cycles
+					t.HasCycles = true
+				}
+		}
+	}
+	return t, registers == 5
+}
+
+func traceByte(text string, registers int) (byte, int) {
+	v, err := strconv.ParseUint(text, 16, 8)
+	if err != nil {
+		return 0, registers
+	}
+	return byte(v), registers + 1
+}
+
+func traceFlagBits(text string) byte {
+	var p byte
+	for i, c := range text {
+		if c >= 'A' && c <= 'Z' {
+			p |= 0x80 >> uint(i)
+		}
+	}
+	return p
+}
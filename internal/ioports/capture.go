@@ -0,0 +1,63 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+// PPU register accesses of one frame, stamped with the PPU dot they
+// happened on, for replaying the frame through the PPU alone. Reads are
+// kept because $2002 and $2007 reads change the PPU state too.
+
+type PPU_ACCESS struct {
+	DOT uint64 // PPU dots since the capture started
+	ADDR uint16 // $2000-$2007 or $4014
+	VALUE byte
+	WRITE bool
+	OAM []byte // Page copied by a $4014 DMA
+}
+
+type PPU_CAPTURE struct {
+	START uint64 // PPU_DOTS of the clock when the capture started
+	ACCESSES []PPU_ACCESS
+}
+
+func capturePPUAccess(IO *IOPorts, addr uint16, value byte, write bool) {
+	var a PPU_ACCESS
+	a.DOT = IO.CLOCK.PPU_DOTS - IO.PPU_CAPTURE.START
+	a.ADDR = addr
+	a.VALUE = value
+	a.WRITE = write
+	if addr == 0x4014 {
+		page := oamIndex(IO, 0)
+		a.OAM = append([]byte(nil), IO.PPU_OAM[page:page+OAM_PAGE_SIZE]...)
+	}
+	IO.PPU_CAPTURE.ACCESSES = append(IO.PPU_CAPTURE.ACCESSES, a)
+}
+
+// Repeats a captured access. A DMA copies the page it copied then, the
+// CPU memory may hold something else now.
+func ReplayPPUAccess(IO *IOPorts, a PPU_ACCESS) {
+	if a.ADDR == 0x4014 {
+		copy(IO.PPU_OAM[oamIndex(IO, 0):], a.OAM)
+		return
+	}
+	if a.WRITE {
+		WMPPU(IO, IO.CART, a.ADDR, a.VALUE)
+	} else {
+		RMPPU(IO, IO.CART, a.ADDR)
+	}
+}
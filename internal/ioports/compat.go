@@ -0,0 +1,49 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+// What the game tried that the emulation has nothing for, kept for the
+// compatibility report. The maps are made on the first unknown access,
+// most games never need them.
+type COMPAT_LOG struct {
+	UNKNOWN_READS map[uint16]int // Addresses in $4020-$5FFF nothing answers, with the count
+	UNKNOWN_WRITES map[uint16]int
+	ROM_WRITES int // Writes to PRG-ROM on a board without registers, often a wrong mapper in the header
+	CRASH string // Why the CPU stopped, "" while it runs
+}
+
+// Reads and writes to the expansion area, $4020-$5FFF, that no register
+// handled.
+func NoteUnknownRead(IO *IOPorts, addr uint16) {
+	if IO.COMPAT.UNKNOWN_READS == nil {
+		IO.COMPAT.UNKNOWN_READS = make(map[uint16]int)
+	}
+	IO.COMPAT.UNKNOWN_READS[addr]++
+}
+
+func NoteUnknownWrite(IO *IOPorts, addr uint16) {
+	if IO.COMPAT.UNKNOWN_WRITES == nil {
+		IO.COMPAT.UNKNOWN_WRITES = make(map[uint16]int)
+	}
+	IO.COMPAT.UNKNOWN_WRITES[addr]++
+}
+
+func NoteROMWrite(IO *IOPorts) {
+	IO.COMPAT.ROM_WRITES++
+}
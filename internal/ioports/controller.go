@@ -0,0 +1,149 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+// Standard controller button bits, in the order they are shifted out.
+const (
+	BUTTON_A byte = 0
+	BUTTON_B byte = 1
+	BUTTON_SELECT byte = 2
+	BUTTON_START byte = 3
+	BUTTON_UP byte = 4
+	BUTTON_DOWN byte = 5
+	BUTTON_LEFT byte = 6
+	BUTTON_RIGHT byte = 7
+)
+
+// Button names indexed by the BUTTON_ bits.
+var ButtonNames = []string{"a", "b", "select", "start", "up", "down", "left", "right"}
+
+type CONTROLLER struct {
+	BUTTONS byte // Live state of the buttons, one bit per button
+	SHIFT byte // Shift register read through $4016/$4017
+	STROBE bool // Last value written to bit 0 of $4016
+	LATCHED byte // Buttons loaded into the shift register by the last strobe
+}
+
+// Controller ports of the console model, see SetRevision of the console.
+// The zero value is the NES, with two detachable controllers.
+type PORT_WIRING struct {
+	MICROPHONE bool // Famicom second controller with the microphone, read in bit 2 of $4016
+	FAMICOM_PAD2 bool // Hardwired Famicom second controller, without Select and Start
+}
+
+// Buttons of both controllers at each latch of a frame, for movies of games
+// that read the controllers more than once per frame. A latch is a write
+// that raises the strobe.
+type POLL_LOG struct {
+	RECORD bool // Append the buttons of each latch to LATCHES
+	REPLAY bool // Take the buttons of each latch from LATCHES
+	LATCHES [][2]byte
+	NEXT int // Next latch to replay; past the end the live buttons are kept
+}
+
+// Frames run, lag frames, where the game did not read the controllers, and
+// controller latches since power up, the counters of TAS tools.
+type COUNTERS struct {
+	FRAMES uint64
+	LAG_FRAMES uint64
+	LATCHES uint64
+	POLLED bool // The controllers were read during the current frame
+}
+
+// Ends a frame of the counters, at the start of the vertical blank.
+func CountFrame(IO *IOPorts) {
+	IO.COUNTERS.FRAMES++
+	if IO.COUNTERS.POLLED == false {
+		IO.COUNTERS.LAG_FRAMES++
+	}
+	IO.COUNTERS.POLLED = false
+}
+
+func SetButton(IO *IOPorts, port int, button byte, pressed bool) {
+	if pressed {
+		IO.JOYPAD[port].BUTTONS |= 1 << button
+	} else {
+		IO.JOYPAD[port].BUTTONS &^= 1 << button
+	}
+}
+
+func WRITE_JOYSTROBE(IO *IOPorts, value byte) {
+	if value & 1 == 1 && IO.JOYPAD[0].STROBE == false {
+		pollLatch(IO)
+	}
+	// While the strobe is high the shift register follows the buttons, so
+	// it holds the ones of the moment the strobe goes low
+	for i := 0; i < 2; i++ {
+		pad := &IO.JOYPAD[i]
+		held := pad.STROBE
+		pad.STROBE = (value & 1) == 1
+		if pad.STROBE || held {
+			pad.SHIFT = wiredButtons(IO, i)
+			pad.LATCHED = pad.SHIFT
+		}
+	}
+}
+
+// Buttons the console can see on a port.
+func wiredButtons(IO *IOPorts, port int) byte {
+	buttons := IO.JOYPAD[port].BUTTONS
+	if port == 1 && IO.WIRING.FAMICOM_PAD2 {
+		buttons &^= 1 << BUTTON_SELECT | 1 << BUTTON_START
+	}
+	return buttons
+}
+
+func pollLatch(IO *IOPorts) {
+	IO.COUNTERS.LATCHES++
+	log := &IO.POLLS
+	if log.REPLAY && log.NEXT < len(log.LATCHES) {
+		IO.JOYPAD[0].BUTTONS = log.LATCHES[log.NEXT][0]
+		IO.JOYPAD[1].BUTTONS = log.LATCHES[log.NEXT][1]
+		log.NEXT++
+	}
+	if log.RECORD {
+		log.LATCHES = append(log.LATCHES, [2]byte{IO.JOYPAD[0].BUTTONS, IO.JOYPAD[1].BUTTONS})
+	}
+}
+
+func READ_JOYPAD(IO *IOPorts, port int) byte {
+
+	pad := &IO.JOYPAD[port]
+	IO.COUNTERS.POLLED = true
+
+	// While the strobe is high the register keeps returning A as it is
+	// now, and reads do not shift
+	if pad.STROBE {
+		if port == 0 && IO.MICROPHONE && IO.WIRING.MICROPHONE {
+			return 0x44 | (pad.BUTTONS & 1)
+		}
+		return 0x40 | (pad.BUTTONS & 1)
+	}
+
+	var result byte = pad.SHIFT & 1
+	// After 8 reads an official controller returns 1. Bits 5-7 are open
+	// bus, the $40 of the address high byte.
+	pad.SHIFT = (pad.SHIFT >> 1) | 0x80
+
+	// The microphone of the Famicom second controller is read in bit 2 of $4016
+	if port == 0 && IO.MICROPHONE && IO.WIRING.MICROPHONE {
+		result |= 0x04
+	}
+	return 0x40 | result
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+import "testing"
+
+// Buttons pressed in the tests: A, Start, Up and Right.
+const testButtons byte = 1 << BUTTON_A | 1 << BUTTON_START | 1 << BUTTON_UP | 1 << BUTTON_RIGHT
+
+// While the strobe is high every read returns the live A button.
+func TestStrobeHeldReturnsA(t *testing.T) {
+	var IO IOPorts
+	WRITE_JOYSTROBE(&IO, 1)
+	for _, buttons := range []byte{testButtons, 0, testButtons} {
+		IO.JOYPAD[0].BUTTONS = buttons
+		for i := 0; i < 10; i++ {
+			if got := READ_JOYPAD(&IO, 0); got != 0x40 | (buttons & 1) {
+				t.Fatalf("read %d with buttons %02X returned %02X", i, buttons, got)
+			}
+		}
+	}
+}
+
+// After the falling edge of the strobe eight reads shift out the buttons
+// in order, A first, and the reads after them return 1.
+func TestStrobeFallingEdge(t *testing.T) {
+	var IO IOPorts
+	IO.JOYPAD[0].BUTTONS = testButtons
+	WRITE_JOYSTROBE(&IO, 1)
+	WRITE_JOYSTROBE(&IO, 0)
+
+	// Presses after the strobe went low are not seen
+	IO.JOYPAD[0].BUTTONS = 0xFF
+	for button := byte(0); button < 8; button++ {
+		want := 0x40 | (testButtons >> button & 1)
+		if got := READ_JOYPAD(&IO, 0); got != want {
+			t.Errorf("read %d returned %02X, want %02X", button + 1, got, want)
+		}
+	}
+	IO.JOYPAD[0].BUTTONS = 0
+	for i := 9; i <= 20; i++ {
+		if got := READ_JOYPAD(&IO, 0); got != 0x41 {
+			t.Errorf("read %d returned %02X, want 41", i, got)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Foobar is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Foobar.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+
+type PPU_STATUS struct {
+	WRITTEN byte // Least significant bits previously written into a PPU register
+	SPRITE_OVERFLOW bool // More then 8 sprites in a scanline SPRITE_0_BIT bool // Set when a nonzero pixel of sprite 0 overlaps
+         				// a nonzero background pixel; cleared at dot 1 of the pre-render line.  Used for raster timing.
+	SPRITE_0_BIT bool // Sprite colides.
+	VBLANK bool // Vertical Blank
+	NMI_OCCURRED bool
+
+	
+}
+
+type PPU_SCROLL struct {
+	X byte
+	Y byte
+}
+
+type PPU_MASK struct {
+
+	GREYSCALE bool // 0: false (normal color) 1: true (grayscale)
+	SHOW_LEFTMOST_8_BACKGROUND bool // Show background in leftmost 8 pixels of screen, 0: Hide
+	SHOW_LEFTMOST_8_SPRITE bool // 1: Show sprites in leftmost 8 pixels of screen, 0: Hide
+	SHOW_BACKGROUND bool // 0: false 1: true
+	SHOW_SPRITE bool // 0: false 1: true
+	RED_BOOST bool
+	GREEN_BOOST bool
+	BLUE_BOOST bool
+}
+
+type PPU_CTRL struct {
+
+	BASE_NAMETABLE_ADDR uint16 // Determined by Bit 1 ** 0 = 0x2000 1 = 0x2400
+	VRAM_INCREMENT uint16 // if BIT 2 of 0x2000 is 0; increment = 1 if it's 1 so incrment = 32
+	SPRITE_8_ADDR uint16 // 0: 0x0000 1: 0x1000 (ignored if sprite mode 16 is true)
+	BACKGROUND_ADDR uint16 // 0: 0x0000 1: 0x1000
+	SPRITE_SIZE uint16 // 0: 8x8 1: 8x16
+	MASTER_SLAVE_SWITCH uint16 // (0: read backdrop from EXT pins; 1: output color on EXT pins)
+	GEN_NMI bool // Generate an NMI at the start of the vertical blanking interval (0: off; 1: on)
+}
+
+// Master clock shared by the CPU and the PPU. It is owned by the console
+// and reached through the bus so traces and the debugger can timestamp
+// every instruction.
+type MASTER_CLOCK struct {
+	CPU_CYCLES uint64 // Total CPU cycles since power up
+	SCANLINE int // Current PPU scanline (-1 is the pre-render line)
+	DOT int // Current PPU dot inside the scanline
+	PPU_DOTS uint64 // Total PPU dots since power up
+}
+
+// Counters the core updates every frame for the diagnostic overlay.
+type ACTIVITY struct {
+	INSTRUCTIONS int // CPU instructions executed in the current frame
+	MAPPER_IRQS int // IRQs raised by the mapper in the current frame
+	AUDIO_FILL float64 // Fill level of the audio buffer, 0.0 - 1.0
+	SPRITES_PER_SCANLINE [240]byte // All the sprites on the line, past the limit of 8 too
+	SECONDARY_OAM [240][32]byte // The first 8 sprites of each line, $FF after the last one
+	SECONDARY_SLOTS [240][8]int // OAM slot of each sprite of SECONDARY_OAM
+}
+
+type IOPorts struct {
+	CPU_RAM []byte
+	PPU_RAM []byte
+	NAMETABLE_MEMORY []byte // Pool of 1KB nametable pages
+	NAMETABLE_PAGE [4]int // Page used by each logical nametable
+	MIRRORING int
+	MIRRORING_CHANGES []MIRRORING_CHANGE // Switches made by the mapper since the frame started
+
+	PPU_MEMORY_STEP byte // Used in 0x2006 to specify if it's need to record the lower or higher byte.
+	PPU_MEMORY_LOWER byte
+	PPU_MEMORY_HIGHER byte
+	VRAM_ADDRESS uint16
+	PPU_A12 bool // A12 of the address the CPU left on the PPU bus, see cpuA12
+	A12_LOW_CYCLE uint64 // CPU cycle A12 went low
+	FETCH_LINE bool // The PPU is on a visible or the pre-render line, where it fetches while rendering is on
+	
+	PPU_OAM []byte // 64 sprites, more with the OAM extension
+	PPU_OAM_ADDRESS byte
+	OAM_EXTENSION bool // See EnableOAMExtension
+	OAM_PAGE byte // Page of the extended OAM used by $2004 and $4014
+	OAM_DECAY uint64 // CPU cycles an OAM row lasts without refresh, 0 for no decay
+	OAM_REFRESH [OAM_ROWS]uint64 // CPU cycle each row was last refreshed by the CPU
+	OAM_RENDERED uint64 // CPU cycle of the last rendered line, which refreshes every row
+	PPUCTRL PPU_CTRL
+	PPUMASK PPU_MASK
+	PPUSTATUS PPU_STATUS
+	PPUSCROLL PPU_SCROLL
+	SCROLL_T uint16 // Scroll address being written by the CPU (loopy t)
+	SCROLL_V uint16 // Scroll address used for rendering (loopy v)
+	FINE_X byte
+	NMI bool
+	PREVIOUS_READ byte
+
+        CART *cartridge.Cartridge
+	BOARD mapper.Board // Bank registers of the cartridge
+
+        CPU_CYC_INCREASE uint16
+	OAM_DMA_END uint64 // CPU cycle the last $4014 DMA ends at
+
+	CLOCK *MASTER_CLOCK
+
+	PPU_WARMUP uint64 // CPU cycles after power up that ignore writes to $2000, $2001, $2005 and $2006
+
+	JOYPAD [2]CONTROLLER
+	POLLS POLL_LOG
+	COUNTERS COUNTERS
+	MICROPHONE bool // Famicom second controller microphone is picking up sound
+	WIRING PORT_WIRING
+
+	APU apu.APU
+	APU_TEST bool // $4018-$401A read the channel outputs, see READ_TEST_REGISTER
+
+	ACTIVITY ACTIVITY
+	COMPAT COMPAT_LOG
+
+	ACCESS_LOG debug.AccessLog
+	PPU_CAPTURE *PPU_CAPTURE // Accesses of the frame being captured, nil if none
+}
+
+func StartIOPorts(cart *cartridge.Cartridge) IOPorts {
+	var io IOPorts
+	io.CPU_RAM = make([]byte, 0x10000)
+
+        io.CART = cart
+	io.BOARD = mapper.StartBoard(cart)
+	io.CLOCK = new(MASTER_CLOCK)
+	io.APU = apu.StartAPU(apu.REGION_NTSC, apu.SampleRate)
+
+	
+	// TODO: make dynamic memory reserve
+	io.PPU_RAM = make([]byte, 0x10000)
+	startNametables(&io)
+	
+	
+	io.NMI = false
+	
+	io.PPUSTATUS.NMI_OCCURRED = false
+	io.PPUSTATUS.SPRITE_0_BIT = false
+	io.PPUSTATUS.SPRITE_OVERFLOW = false
+	io.PREVIOUS_READ = 0
+	io.PPUCTRL.VRAM_INCREMENT = 1 // PPUCTRL is 0 at power up
+	io.PPUCTRL.BASE_NAMETABLE_ADDR = 0x2000
+	io.PPU_OAM = make([]byte, 256)
+	return io
+}
+
+func RMPPU(IO *IOPorts, cart *cartridge.Cartridge, addr uint16) byte {
+
+	if IO.PPU_CAPTURE != nil && (addr == 0x2002 || addr == 0x2007) {
+		capturePPUAccess(IO, addr, 0, false)
+	}
+
+	switch(addr) {
+	
+		case 0x2002:
+			return READ_PPUSTATUS(IO)
+		break
+		
+		case 0x2004:
+			return READ_OAMDATA(IO)
+		break
+		
+		case 0x2007:
+			return READ_PPUDATA(IO, cart)
+		break
+			
+	
+	}
+	return 0
+}
+
+
+func WMPPU(IO *IOPorts, cart *cartridge.Cartridge, addr uint16, value byte) {
+
+			
+
+	
+
+	if IO.PPU_CAPTURE != nil && addr != 0x4014 {
+		capturePPUAccess(IO, addr, value, true)
+	}
+
+	// Last bytes written
+	IO.PPUSTATUS.WRITTEN = value
+
+	// Until the end of the first pre-render line the PPU ignores writes
+	// to some of its registers
+	if IO.CLOCK.CPU_CYCLES < IO.PPU_WARMUP {
+		switch(addr) {
+			case 0x2000, 0x2001, 0x2005, 0x2006:
+				return
+		}
+	}
+
+	switch(addr) {
+	
+		case 0x4014:
+                        // This transaction takes ~513 CPY Cycles
+                        IO.CPU_CYC_INCREASE = 513
+			IO.OAM_DMA_END = IO.CLOCK.CPU_CYCLES + 513
+			WRITE_OAMDMA(IO, cart, value)
+			if IO.PPU_CAPTURE != nil {
+				capturePPUAccess(IO, addr, value, true)
+			}
+		break
+	
+		case 0x2000:
+			WRITE_PPUCTRL(IO, value)
+		break
+		
+		case 0x2001:
+			WRITE_PPUMASK(IO, value)
+		break
+		
+		case 0x2003:
+			WRITE_OAMADDR(IO, value)
+		break
+		
+		case 0x2004:
+			WRITE_OAMDATA(IO, value)
+		break
+		
+		case 0x2005:
+			WRITE_PPUSCROLL(IO, value)
+		break
+		
+		case 0x2006:
+			WRITE_PPUADDR(IO, value)
+		break
+		
+		case 0x2007:
+			WRITE_PPUDATA(IO, cart, value)
+		break
+		
+	
+	}
+}
+
+// Reset button: $2000 and $2001 are cleared, and so are the scroll, the
+// $2005/$2006 write toggle and the $2007 read buffer. The VRAM address,
+// OAM, the palette and the nametables keep their contents.
+func ResetPPU(IO *IOPorts) {
+	WRITE_PPUCTRL(IO, 0)
+	WRITE_PPUMASK(IO, 0)
+	IO.PPU_MEMORY_STEP = 0
+	IO.PPUSCROLL = PPU_SCROLL{}
+	IO.SCROLL_T = 0
+	IO.FINE_X = 0
+	IO.PREVIOUS_READ = 0
+	IO.NMI = false
+}
+
+func SetNMI(IO *IOPorts) {
+	IO.NMI = true
+}
+
+// Returns the total CPU cycles, the PPU scanline and the PPU dot.
+func Timestamp(IO *IOPorts) (uint64, int, int) {
+	return IO.CLOCK.CPU_CYCLES, IO.CLOCK.SCANLINE, IO.CLOCK.DOT
+}
+
+// Records a register access with the current timestamp.
+func LogAccess(IO *IOPorts, addr uint16, value byte, write bool) {
+	if IO.ACCESS_LOG.Enable == false {
+		return
+	}
+	var a debug.Access
+	a.Cycle, a.Scanline, a.Dot = Timestamp(IO)
+	a.Addr = addr
+	a.Value = value
+	a.Write = write
+	debug.RecordAccess(&IO.ACCESS_LOG, a)
+}
+
+// Records something that is not a register access, like a mirroring switch.
+func LogEvent(IO *IOPorts, text string) {
+	if IO.ACCESS_LOG.Enable == false {
+		return
+	}
+	var a debug.Access
+	a.Cycle, a.Scanline, a.Dot = Timestamp(IO)
+	a.Event = text
+	debug.RecordEvent(&IO.ACCESS_LOG, a)
+}
+
+// Level of the IRQ line of the CPU: the APU and the board can each hold
+// it low until the game acknowledges them.
+func IRQ(IO *IOPorts) bool {
+	return apu.IRQ(&IO.APU) || IO.BOARD.IRQ
+}
+
+// Bounds-safe accessors for the CPU memory. Addresses outside the 64KB
+// address space read as 0 and are not written.
+func ReadRAM(IO *IOPorts, addr uint32) byte {
+	if addr >= uint32(len(IO.CPU_RAM)) {
+		return 0
+	}
+	return IO.CPU_RAM[addr]
+}
+
+func WriteRAM(IO *IOPorts, addr uint32, value byte) {
+	if addr >= uint32(len(IO.CPU_RAM)) {
+		return
+	}
+	IO.CPU_RAM[addr] = value
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+
+// Nametable page mapping. The nametable memory is a pool of 1KB pages:
+// pages 0 and 1 are the 2KB of VRAM inside the console, the following
+// ones come from the cartridge (four-screen boards) or from a mapper.
+// Each of the four logical nametables at $2000/$2400/$2800/$2C00 points
+// at one page of the pool.
+
+const NAMETABLE_PAGE_SIZE = 0x400
+
+const (
+	MIRROR_HORIZONTAL = mapper.MirrorHorizontal // $2000 = $2400, $2800 = $2C00
+	MIRROR_VERTICAL = mapper.MirrorVertical // $2000 = $2800, $2400 = $2C00
+	MIRROR_SINGLE_LOW = mapper.MirrorSingleLow // All the nametables use page 0
+	MIRROR_SINGLE_HIGH = mapper.MirrorSingleHigh // All the nametables use page 1
+	MIRROR_FOUR_SCREEN = mapper.MirrorFourScreen // Pages 0-3, needs two pages of cartridge RAM
+)
+
+// A mirroring switch made by the mapper, kept for the debugger.
+type MIRRORING_CHANGE struct {
+	MODE int
+	CYCLE uint64
+	SCANLINE int
+	DOT int
+}
+
+func MirroringName(mode int) string {
+	switch(mode) {
+		case MIRROR_HORIZONTAL:
+			return "horizontal"
+		case MIRROR_VERTICAL:
+			return "vertical"
+		case MIRROR_SINGLE_LOW:
+			return "single screen low"
+		case MIRROR_SINGLE_HIGH:
+			return "single screen high"
+		case MIRROR_FOUR_SCREEN:
+			return "four screen"
+	}
+	return "unknown"
+}
+
+func startNametables(IO *IOPorts) {
+	IO.NAMETABLE_MEMORY = make([]byte, 2*NAMETABLE_PAGE_SIZE)
+
+	if IO.CART.Header.RomType.FourScreenVRAM {
+		AddNametableMemory(IO, 2)
+		SetMirroring(IO, MIRROR_FOUR_SCREEN)
+	} else if IO.CART.Header.RomType.VerticalMirroring {
+		SetMirroring(IO, MIRROR_VERTICAL)
+	} else {
+		SetMirroring(IO, MIRROR_HORIZONTAL)
+	}
+	syncBoardMirroring(IO)
+	// The mirroring at power up is not a switch
+	IO.MIRRORING_CHANGES = IO.MIRRORING_CHANGES[:0]
+}
+
+// Appends pages to the pool and returns the index of the first new page.
+func AddNametableMemory(IO *IOPorts, pages int) int {
+	first := len(IO.NAMETABLE_MEMORY) / NAMETABLE_PAGE_SIZE
+	IO.NAMETABLE_MEMORY = append(IO.NAMETABLE_MEMORY, make([]byte, pages*NAMETABLE_PAGE_SIZE)...)
+	return first
+}
+
+// Points a logical nametable (0-3) at a page of the pool.
+func MapNametable(IO *IOPorts, table int, page int) {
+	if page < 0 || page*NAMETABLE_PAGE_SIZE >= len(IO.NAMETABLE_MEMORY) {
+		return
+	}
+	IO.NAMETABLE_PAGE[table & 3] = page
+}
+
+func SetMirroring(IO *IOPorts, mode int) {
+	var pages [4]int
+	switch(mode) {
+		case MIRROR_HORIZONTAL:
+			pages = [4]int{0, 0, 1, 1}
+		case MIRROR_VERTICAL:
+			pages = [4]int{0, 1, 0, 1}
+		case MIRROR_SINGLE_LOW:
+			pages = [4]int{0, 0, 0, 0}
+		case MIRROR_SINGLE_HIGH:
+			pages = [4]int{1, 1, 1, 1}
+		case MIRROR_FOUR_SCREEN:
+			if len(IO.NAMETABLE_MEMORY) < 4*NAMETABLE_PAGE_SIZE {
+				return
+			}
+			pages = [4]int{0, 1, 2, 3}
+		default:
+			return
+	}
+	if mode != IO.MIRRORING {
+		var change MIRRORING_CHANGE
+		change.MODE = mode
+		change.CYCLE, change.SCANLINE, change.DOT = Timestamp(IO)
+		IO.MIRRORING_CHANGES = append(IO.MIRRORING_CHANGES, change)
+		LogEvent(IO, "mirroring " + MirroringName(mode))
+	}
+	IO.MIRRORING = mode
+	for table := 0; table < 4; table++ {
+		MapNametable(IO, table, pages[table])
+	}
+}
+
+// Offset inside the pool of a PPU address in $2000-$2FFF.
+func nametableOffset(IO *IOPorts, addr uint16) int {
+	table := int((addr - 0x2000) / NAMETABLE_PAGE_SIZE) & 3
+	return IO.NAMETABLE_PAGE[table]*NAMETABLE_PAGE_SIZE + int(addr % NAMETABLE_PAGE_SIZE)
+}
+
+func IsNametable(addr uint16) bool {
+	return addr >= 0x2000 && addr < 0x3000
+}
+
+// Boards like Sunsoft-4 can put CHR-ROM in place of the pages, the
+// mirroring then chooses which of its two ROM pages each nametable shows.
+func ReadNametable(IO *IOPorts, addr uint16) byte {
+	if IO.BOARD.NametableCHR {
+		table := int((addr - 0x2000) / NAMETABLE_PAGE_SIZE) & 3
+		return mapper.ReadNametableCHR(&IO.BOARD, IO.CART, IO.NAMETABLE_PAGE[table], int(addr % NAMETABLE_PAGE_SIZE))
+	}
+	return IO.NAMETABLE_MEMORY[nametableOffset(IO, addr)]
+}
+
+func WriteNametable(IO *IOPorts, addr uint16, value byte) {
+	if IO.BOARD.NametableCHR {
+		return
+	}
+	IO.NAMETABLE_MEMORY[nametableOffset(IO, addr)] = value
+}
+
+// A CPU write to $4020-$FFFF seen by the board, see mapper.WriteRegister.
+// The mirroring it chose is applied right away.
+func WriteMapper(IO *IOPorts, addr uint16, value byte) bool {
+	handled := mapper.WriteRegister(&IO.BOARD, IO.CART, addr, value)
+	syncBoardMirroring(IO)
+	return handled
+}
+
+// Applies the mirroring chosen by the board, if it chose one.
+func syncBoardMirroring(IO *IOPorts) {
+	if IO.BOARD.Mirroring != mapper.MirrorHeader && IO.BOARD.Mirroring != IO.MIRRORING {
+		SetMirroring(IO, IO.BOARD.Mirroring)
+	}
+}
+
+// Reset button as seen by the board, see mapper.ResetBoard.
+func ResetMapper(IO *IOPorts) {
+	mapper.ResetBoard(&IO.BOARD)
+	syncBoardMirroring(IO)
+}
+
+// Clocks the scanline counter of the board.
+func ClockMapperScanline(IO *IOPorts) {
+	if mapper.ClockScanline(&IO.BOARD) {
+		IO.ACTIVITY.MAPPER_IRQS++
+	}
+}
+
+// CPU cycles A12 must stay low before a rise counts, the filter of the
+// boards; ppu/a12.go applies the same one to the rendering fetches.
+const A12_FILTER_CYCLES = 3
+
+// Outside rendering the PPU bus holds the address of $2006 and $2007, so
+// a game can clock the scanline counter by moving it across $1000. While
+// the PPU renders its own fetches drive A12 and the CPU ones are ignored.
+func cpuA12(IO *IOPorts, addr uint16) {
+	high := addr & 0x1000 != 0
+	if high == IO.PPU_A12 {
+		return
+	}
+	IO.PPU_A12 = high
+	if high == false {
+		IO.A12_LOW_CYCLE = IO.CLOCK.CPU_CYCLES
+		return
+	}
+	rendering := (IO.PPUMASK.SHOW_BACKGROUND || IO.PPUMASK.SHOW_SPRITE) && IO.FETCH_LINE
+	if rendering || mapper.CountsScanlines(&IO.BOARD) == false {
+		return
+	}
+	if IO.CLOCK.CPU_CYCLES - IO.A12_LOW_CYCLE >= A12_FILTER_CYCLES {
+		ClockMapperScanline(IO)
+	}
+}
+
+// Clocks the CPU cycle counter of the board.
+func ClockMapperCPU(IO *IOPorts) {
+	if mapper.ClockCPU(&IO.BOARD) {
+		IO.ACTIVITY.MAPPER_IRQS++
+	}
+}
+
+// State of the mapper with the current mirroring.
+func MapperStatus(IO *IOPorts) mapper.Status {
+	s := mapper.GetStatus(&IO.BOARD, IO.CART)
+	s.Mirroring = MirroringName(IO.MIRRORING)
+	return s
+}
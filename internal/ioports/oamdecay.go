@@ -0,0 +1,67 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+// OAM decay, off by default. The OAM is dynamic RAM refreshed by the
+// sprite evaluation of every rendered line; with rendering off its rows,
+// 8 bytes each, fade after a while unless the CPU touches them. A row not
+// refreshed for more than OAM_DECAY CPU cycles decays when it is next
+// read, by $2004 or by rendering. Real chips fade to values that vary with
+// the console and the temperature; the decayed bytes read $10 here, the
+// value the reference emulators settled on.
+
+const OAM_ROWS = OAM_PAGE_SIZE*OAM_EXTENDED_PAGES/8
+const OAM_DECAYED = 0x10
+
+// A row the CPU read or wrote is refreshed.
+func refreshOAMRow(IO *IOPorts, index int) {
+	if IO.OAM_DECAY == 0 {
+		return
+	}
+	decayOAMRow(IO, index/8)
+	IO.OAM_REFRESH[index/8] = IO.CLOCK.CPU_CYCLES
+}
+
+// Called by the PPU at each rendered line: the rows that faded while
+// rendering was off decay, and the evaluation refreshes them all.
+func RefreshOAM(IO *IOPorts) {
+	if IO.OAM_DECAY == 0 {
+		return
+	}
+	if IO.CLOCK.CPU_CYCLES - IO.OAM_RENDERED > IO.OAM_DECAY {
+		for row := 0; row < len(IO.PPU_OAM)/8; row++ {
+			decayOAMRow(IO, row)
+		}
+	}
+	IO.OAM_RENDERED = IO.CLOCK.CPU_CYCLES
+}
+
+func decayOAMRow(IO *IOPorts, row int) {
+	last := IO.OAM_REFRESH[row]
+	if IO.OAM_RENDERED > last {
+		last = IO.OAM_RENDERED
+	}
+	if IO.CLOCK.CPU_CYCLES - last <= IO.OAM_DECAY {
+		return
+	}
+	for i := row*8; i < row*8 + 8 && i < len(IO.PPU_OAM); i++ {
+		IO.PPU_OAM[i] = OAM_DECAYED
+	}
+	IO.OAM_REFRESH[row] = IO.CLOCK.CPU_CYCLES
+}
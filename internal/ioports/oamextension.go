@@ -0,0 +1,49 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+// Non-standard OAM extension for homebrew experiments, off by default.
+// The OAM grows to OAM_EXTENDED_PAGES pages of 64 sprites and the unused
+// register at $4020 selects the page that $2004 and the $4014 DMA use.
+// Page 0 is the standard OAM, so games that never write $4020 see the
+// console they expect. The renderer evaluates the sprites of every page in
+// slot order.
+
+const OAM_PAGE_SIZE = 256
+const OAM_EXTENDED_PAGES = 8 // 512 sprites
+const OAM_PAGE_REGISTER = 0x4020
+
+func EnableOAMExtension(IO *IOPorts) {
+	IO.OAM_EXTENSION = true
+	IO.OAM_PAGE = 0
+	IO.PPU_OAM = make([]byte, OAM_PAGE_SIZE*OAM_EXTENDED_PAGES)
+}
+
+func WRITE_OAMPAGE(IO *IOPorts, value byte) {
+	IO.OAM_PAGE = value % OAM_EXTENDED_PAGES
+}
+
+func READ_OAMPAGE(IO *IOPorts) byte {
+	return IO.OAM_PAGE
+}
+
+// Index in PPU_OAM of the OAM address in the selected page.
+func oamIndex(IO *IOPorts, addr byte) int {
+	return int(IO.OAM_PAGE)*OAM_PAGE_SIZE + int(addr)
+}
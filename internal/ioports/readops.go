@@ -0,0 +1,118 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+//import "fmt"
+
+func READ_PPUSTATUS(IO *IOPorts) byte {
+
+	var result byte = 0
+	result = SetBit(result, 0, Bit0(IO.PPUSTATUS.WRITTEN))
+	result = SetBit(result, 1, Bit1(IO.PPUSTATUS.WRITTEN))
+	result = SetBit(result, 2, Bit2(IO.PPUSTATUS.WRITTEN))
+	result = SetBit(result, 3, Bit3(IO.PPUSTATUS.WRITTEN))
+	result = SetBit(result, 4, Bit4(IO.PPUSTATUS.WRITTEN))
+	
+	if IO.PPUSTATUS.SPRITE_OVERFLOW == true {
+		result = SetBit(result, 5,1)
+	}
+	
+	if IO.PPUSTATUS.SPRITE_0_BIT == true {
+		result = SetBit(result, 6,1)
+	}
+		
+	if IO.PPUSTATUS.NMI_OCCURRED == true {
+		result = SetBit(result, 7,1)
+	}
+	IO.PPUSTATUS.NMI_OCCURRED = false
+	
+	IO.PPUSCROLL.X = 0
+	IO.PPUSTATUS.SPRITE_0_BIT = false
+	IO.PPUSCROLL.Y = 0
+	IO.PPU_MEMORY_STEP = 0
+	//IO.VRAM_ADDRESS = 0
+	
+	return result	
+}
+
+func READ_OAMDATA(IO *IOPorts) byte {
+
+		refreshOAMRow(IO, oamIndex(IO, IO.PPU_OAM_ADDRESS))
+		var result byte = IO.PPU_OAM[oamIndex(IO, IO.PPU_OAM_ADDRESS)]
+		return result
+}
+
+// $2007 reads are buffered: a read returns what the previous one fetched
+// and the byte at the address goes into the buffer. The palette is the
+// exception, its entries come back right away (only the grey column with
+// the greyscale bit on) and the buffer gets the nametable byte the
+// palette covers, $3F00-$3FFF being a mirror of $2F00-$2FFF underneath.
+// Either way the address then moves by 1 or 32, as PPUCTRL says.
+func READ_PPUDATA(IO *IOPorts, cart *cartridge.Cartridge) byte {
+
+	var addr uint16 = IO.VRAM_ADDRESS & 0x3FFF
+	var result byte = IO.PREVIOUS_READ
+
+	if addr >= 0x3F00 {
+		result = readPPUMemory(IO, cart, addr)
+		if IO.PPUMASK.GREYSCALE {
+			result &= 0x30
+		}
+		IO.PREVIOUS_READ = readPPUMemory(IO, cart, addr - 0x1000)
+	} else {
+		IO.PREVIOUS_READ = readPPUMemory(IO, cart, addr)
+	}
+	incrementVRAMAddress(IO)
+	return result
+}
+
+// Byte at a PPU address as the CPU sees it through $2007.
+func readPPUMemory(IO *IOPorts, cart *cartridge.Cartridge, addr uint16) byte {
+	var newaddr uint16 = mapper.PPU(cart, addr)
+	if IsNametable(newaddr) {
+		return ReadNametable(IO, newaddr)
+	}
+	if newaddr < 0x2000 && len(cart.CHR) > 0 {
+		return mapper.ReadCHR(&IO.BOARD, cart, newaddr)
+	}
+	return IO.PPU_RAM[newaddr]
+}
+
+// After each $2007 access. The address is 15 bits wide, the PPU sees the
+// low 14.
+func incrementVRAMAddress(IO *IOPorts) {
+	IO.VRAM_ADDRESS = (IO.VRAM_ADDRESS + IO.PPUCTRL.VRAM_INCREMENT) & 0x7FFF
+	cpuA12(IO, IO.VRAM_ADDRESS)
+}
+
+// CPU test registers at $4018-$401F. They are disabled on retail consoles:
+// reads return open bus, the high byte of the address as left by absolute
+// addressing, and writes do nothing. With APU_TEST the first ones read the
+// channel outputs as with the test mode enabled.
+func READ_TEST_REGISTER(IO *IOPorts, addr uint16) byte {
+	if IO.APU_TEST {
+		if value, found := apu.ReadTestRegister(&IO.APU, addr); found {
+			return value
+		}
+	}
+	return byte(addr >> 8)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+import "testing"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+
+func setPPUAddress(IO *IOPorts, addr uint16) {
+	WRITE_PPUADDR(IO, byte(addr >> 8))
+	WRITE_PPUADDR(IO, byte(addr))
+}
+
+// A palette read through $2007 returns the entry at once and fills the
+// read buffer with the nametable byte under it, $3F00-$3FFF over
+// $2F00-$2FFF; the next read of another address returns that byte.
+func TestPaletteReadBuffer(t *testing.T) {
+	var cart cartridge.Cartridge
+	IO := StartIOPorts(&cart)
+	setPPUAddress(&IO, 0x2F05)
+	WRITE_PPUDATA(&IO, &cart, 0xAB)
+	setPPUAddress(&IO, 0x2F06)
+	WRITE_PPUDATA(&IO, &cart, 0xCD)
+	setPPUAddress(&IO, 0x3F05)
+	WRITE_PPUDATA(&IO, &cart, 0x25)
+	WRITE_PPUDATA(&IO, &cart, 0x16)
+
+	setPPUAddress(&IO, 0x3F05)
+	if got := READ_PPUDATA(&IO, &cart); got != 0x25 {
+		t.Errorf("$3F05 read %02X, want 25 without a dummy read", got)
+	}
+	if IO.PREVIOUS_READ != 0xAB {
+		t.Errorf("buffer %02X after reading $3F05, want AB from $2F05", IO.PREVIOUS_READ)
+	}
+	if got := READ_PPUDATA(&IO, &cart); got != 0x16 {
+		t.Errorf("$3F06 read %02X, want 16", got)
+	}
+	if IO.PREVIOUS_READ != 0xCD {
+		t.Errorf("buffer %02X after reading $3F06, want CD from $2F06", IO.PREVIOUS_READ)
+	}
+
+	setPPUAddress(&IO, 0x2000)
+	if got := READ_PPUDATA(&IO, &cart); got != 0xCD {
+		t.Errorf("$2000 read %02X, want the buffered CD", got)
+	}
+
+	// Greyscale keeps the grey column of the entry only
+	WRITE_PPUMASK(&IO, 0x01)
+	setPPUAddress(&IO, 0x3F05)
+	if got := READ_PPUDATA(&IO, &cart); got != 0x20 {
+		t.Errorf("$3F05 read %02X with greyscale, want 20", got)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+// Scroll registers as the PPU keeps them internally: t is the address the
+// CPU builds through $2000, $2005 and $2006, v is the address used while
+// rendering and FINE_X the pixel offset inside the tile. Both addresses are
+// laid out as yyy NN YYYYY XXXXX (fine Y, nametable, coarse Y, coarse X).
+// The write toggle is shared with $2006 through PPU_MEMORY_STEP.
+
+func scrollCtrl(IO *IOPorts, value byte) {
+	IO.SCROLL_T = (IO.SCROLL_T & 0xF3FF) | (uint16(value & 0x03) << 10)
+}
+
+func scrollWrite(IO *IOPorts, value byte, first bool) {
+	if first {
+		IO.SCROLL_T = (IO.SCROLL_T & 0xFFE0) | uint16(value >> 3)
+		IO.FINE_X = value & 0x07
+	} else {
+		IO.SCROLL_T = (IO.SCROLL_T & 0x8C1F) | (uint16(value & 0x07) << 12) | (uint16(value & 0xF8) << 2)
+	}
+}
+
+// The second $2006 write also reloads v, which is how games split the
+// screen vertically in the middle of a frame.
+func scrollAddr(IO *IOPorts, value byte, first bool) {
+	if first {
+		IO.SCROLL_T = (IO.SCROLL_T & 0x80FF) | (uint16(value & 0x3F) << 8)
+	} else {
+		IO.SCROLL_T = (IO.SCROLL_T & 0xFF00) | uint16(value)
+		IO.SCROLL_V = IO.SCROLL_T
+	}
+}
+
+func RenderingEnabled(IO *IOPorts) bool {
+	return IO.PPUMASK.SHOW_BACKGROUND || IO.PPUMASK.SHOW_SPRITE
+}
+
+// Pre-render scanline: v gets all of t.
+func ReloadScroll(IO *IOPorts) {
+	if RenderingEnabled(IO) {
+		IO.SCROLL_V = IO.SCROLL_T
+	}
+}
+
+// Dot 257 of the previous line: v gets the horizontal bits of t.
+func ReloadScrollX(IO *IOPorts) {
+	if RenderingEnabled(IO) {
+		IO.SCROLL_V = (IO.SCROLL_V & 0xFBE0) | (IO.SCROLL_T & 0x041F)
+	}
+}
+
+// Dot 256: moves v one pixel row down, wrapping into the next nametable
+// after row 29.
+func IncrementScrollY(IO *IOPorts) {
+	if RenderingEnabled(IO) == false {
+		return
+	}
+	v := IO.SCROLL_V
+	if v & 0x7000 != 0x7000 {
+		v += 0x1000
+	} else {
+		v &^= 0x7000
+		y := (v & 0x03E0) >> 5
+		if y == 29 {
+			y = 0
+			v ^= 0x0800
+		} else if y == 31 {
+			y = 0
+		} else {
+			y++
+		}
+		v = (v &^ 0x03E0) | (y << 5)
+	}
+	IO.SCROLL_V = v
+}
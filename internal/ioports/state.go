@@ -0,0 +1,165 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/snapshot"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+
+// Savestate encoding of the bus. The cartridge, the clock, the activity
+// counters, the compatibility log and the access log belong to the console
+// and are not saved.
+func EncodeState(IO *IOPorts, e *snapshot.Encoder) {
+	snapshot.PutBytes(e, IO.CPU_RAM)
+	snapshot.PutBytes(e, IO.PPU_RAM)
+	snapshot.PutBytes(e, IO.PPU_OAM)
+	snapshot.PutBytes(e, IO.NAMETABLE_MEMORY)
+	for _, page := range IO.NAMETABLE_PAGE {
+		snapshot.PutInt(e, page)
+	}
+	snapshot.PutInt(e, IO.MIRRORING)
+
+	snapshot.PutByte(e, IO.PPU_MEMORY_STEP)
+	snapshot.PutByte(e, IO.PPU_MEMORY_LOWER)
+	snapshot.PutByte(e, IO.PPU_MEMORY_HIGHER)
+	snapshot.PutUint16(e, IO.VRAM_ADDRESS)
+	snapshot.PutByte(e, IO.PPU_OAM_ADDRESS)
+	snapshot.PutByte(e, IO.OAM_PAGE)
+	for row := 0; row < len(IO.PPU_OAM)/8; row++ {
+		snapshot.PutUint64(e, IO.OAM_REFRESH[row])
+	}
+	snapshot.PutUint64(e, IO.OAM_RENDERED)
+
+	snapshot.PutUint16(e, IO.PPUCTRL.BASE_NAMETABLE_ADDR)
+	snapshot.PutUint16(e, IO.PPUCTRL.VRAM_INCREMENT)
+	snapshot.PutUint16(e, IO.PPUCTRL.SPRITE_8_ADDR)
+	snapshot.PutUint16(e, IO.PPUCTRL.BACKGROUND_ADDR)
+	snapshot.PutUint16(e, IO.PPUCTRL.SPRITE_SIZE)
+	snapshot.PutUint16(e, IO.PPUCTRL.MASTER_SLAVE_SWITCH)
+	snapshot.PutBool(e, IO.PPUCTRL.GEN_NMI)
+
+	snapshot.PutBool(e, IO.PPUMASK.GREYSCALE)
+	snapshot.PutBool(e, IO.PPUMASK.SHOW_LEFTMOST_8_BACKGROUND)
+	snapshot.PutBool(e, IO.PPUMASK.SHOW_LEFTMOST_8_SPRITE)
+	snapshot.PutBool(e, IO.PPUMASK.SHOW_BACKGROUND)
+	snapshot.PutBool(e, IO.PPUMASK.SHOW_SPRITE)
+	snapshot.PutBool(e, IO.PPUMASK.RED_BOOST)
+	snapshot.PutBool(e, IO.PPUMASK.GREEN_BOOST)
+	snapshot.PutBool(e, IO.PPUMASK.BLUE_BOOST)
+
+	snapshot.PutByte(e, IO.PPUSTATUS.WRITTEN)
+	snapshot.PutBool(e, IO.PPUSTATUS.SPRITE_OVERFLOW)
+	snapshot.PutBool(e, IO.PPUSTATUS.SPRITE_0_BIT)
+	snapshot.PutBool(e, IO.PPUSTATUS.VBLANK)
+	snapshot.PutBool(e, IO.PPUSTATUS.NMI_OCCURRED)
+
+	snapshot.PutByte(e, IO.PPUSCROLL.X)
+	snapshot.PutByte(e, IO.PPUSCROLL.Y)
+	snapshot.PutUint16(e, IO.SCROLL_T)
+	snapshot.PutUint16(e, IO.SCROLL_V)
+	snapshot.PutByte(e, IO.FINE_X)
+	snapshot.PutBool(e, IO.NMI)
+	snapshot.PutByte(e, IO.PREVIOUS_READ)
+	snapshot.PutUint16(e, IO.CPU_CYC_INCREASE)
+	snapshot.PutUint64(e, IO.OAM_DMA_END)
+
+	for _, pad := range IO.JOYPAD {
+		snapshot.PutByte(e, pad.BUTTONS)
+		snapshot.PutByte(e, pad.SHIFT)
+		snapshot.PutBool(e, pad.STROBE)
+		snapshot.PutByte(e, pad.LATCHED)
+	}
+	snapshot.PutBool(e, IO.MICROPHONE)
+	snapshot.PutUint64(e, IO.COUNTERS.FRAMES)
+	snapshot.PutUint64(e, IO.COUNTERS.LAG_FRAMES)
+	snapshot.PutUint64(e, IO.COUNTERS.LATCHES)
+	snapshot.PutBool(e, IO.COUNTERS.POLLED)
+	mapper.EncodeState(&IO.BOARD, e)
+	apu.EncodeState(&IO.APU, e)
+}
+
+func DecodeState(IO *IOPorts, d *snapshot.Decoder) {
+	IO.CPU_RAM = snapshot.Bytes(d, IO.CPU_RAM)
+	IO.PPU_RAM = snapshot.Bytes(d, IO.PPU_RAM)
+	IO.PPU_OAM = snapshot.Bytes(d, IO.PPU_OAM)
+	IO.NAMETABLE_MEMORY = snapshot.Bytes(d, IO.NAMETABLE_MEMORY)
+	for i := range IO.NAMETABLE_PAGE {
+		IO.NAMETABLE_PAGE[i] = snapshot.Int(d)
+	}
+	IO.MIRRORING = snapshot.Int(d)
+
+	IO.PPU_MEMORY_STEP = snapshot.Byte(d)
+	IO.PPU_MEMORY_LOWER = snapshot.Byte(d)
+	IO.PPU_MEMORY_HIGHER = snapshot.Byte(d)
+	IO.VRAM_ADDRESS = snapshot.Uint16(d)
+	IO.PPU_OAM_ADDRESS = snapshot.Byte(d)
+	IO.OAM_PAGE = snapshot.Byte(d)
+	for row := 0; row < len(IO.PPU_OAM)/8 && row < OAM_ROWS; row++ {
+		IO.OAM_REFRESH[row] = snapshot.Uint64(d)
+	}
+	IO.OAM_RENDERED = snapshot.Uint64(d)
+
+	IO.PPUCTRL.BASE_NAMETABLE_ADDR = snapshot.Uint16(d)
+	IO.PPUCTRL.VRAM_INCREMENT = snapshot.Uint16(d)
+	IO.PPUCTRL.SPRITE_8_ADDR = snapshot.Uint16(d)
+	IO.PPUCTRL.BACKGROUND_ADDR = snapshot.Uint16(d)
+	IO.PPUCTRL.SPRITE_SIZE = snapshot.Uint16(d)
+	IO.PPUCTRL.MASTER_SLAVE_SWITCH = snapshot.Uint16(d)
+	IO.PPUCTRL.GEN_NMI = snapshot.Bool(d)
+
+	IO.PPUMASK.GREYSCALE = snapshot.Bool(d)
+	IO.PPUMASK.SHOW_LEFTMOST_8_BACKGROUND = snapshot.Bool(d)
+	IO.PPUMASK.SHOW_LEFTMOST_8_SPRITE = snapshot.Bool(d)
+	IO.PPUMASK.SHOW_BACKGROUND = snapshot.Bool(d)
+	IO.PPUMASK.SHOW_SPRITE = snapshot.Bool(d)
+	IO.PPUMASK.RED_BOOST = snapshot.Bool(d)
+	IO.PPUMASK.GREEN_BOOST = snapshot.Bool(d)
+	IO.PPUMASK.BLUE_BOOST = snapshot.Bool(d)
+
+	IO.PPUSTATUS.WRITTEN = snapshot.Byte(d)
+	IO.PPUSTATUS.SPRITE_OVERFLOW = snapshot.Bool(d)
+	IO.PPUSTATUS.SPRITE_0_BIT = snapshot.Bool(d)
+	IO.PPUSTATUS.VBLANK = snapshot.Bool(d)
+	IO.PPUSTATUS.NMI_OCCURRED = snapshot.Bool(d)
+
+	IO.PPUSCROLL.X = snapshot.Byte(d)
+	IO.PPUSCROLL.Y = snapshot.Byte(d)
+	IO.SCROLL_T = snapshot.Uint16(d)
+	IO.SCROLL_V = snapshot.Uint16(d)
+	IO.FINE_X = snapshot.Byte(d)
+	IO.NMI = snapshot.Bool(d)
+	IO.PREVIOUS_READ = snapshot.Byte(d)
+	IO.CPU_CYC_INCREASE = snapshot.Uint16(d)
+	IO.OAM_DMA_END = snapshot.Uint64(d)
+
+	for i := range IO.JOYPAD {
+		pad := &IO.JOYPAD[i]
+		pad.BUTTONS = snapshot.Byte(d)
+		pad.SHIFT = snapshot.Byte(d)
+		pad.STROBE = snapshot.Bool(d)
+		pad.LATCHED = snapshot.Byte(d)
+	}
+	IO.MICROPHONE = snapshot.Bool(d)
+	IO.COUNTERS.FRAMES = snapshot.Uint64(d)
+	IO.COUNTERS.LAG_FRAMES = snapshot.Uint64(d)
+	IO.COUNTERS.LATCHES = snapshot.Uint64(d)
+	IO.COUNTERS.POLLED = snapshot.Bool(d)
+	mapper.DecodeState(&IO.BOARD, d)
+	apu.DecodeState(&IO.APU, d)
+}
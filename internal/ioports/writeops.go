@@ -18,8 +18,9 @@ This file is part of Alphanes.
 */
 package ioports
 
-import "zerojnt/cartridge"
-import "zerojnt/mapper"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
 //import "fmt"
 
 
@@ -73,6 +74,7 @@ func WRITE_PPUCTRL(IO *IOPorts, value byte) {
 	} else {
 	IO.PPUCTRL.GEN_NMI = true
 	}
+	scrollCtrl(IO, value)
 }
 
 func WRITE_PPUMASK(IO *IOPorts, value byte) {
@@ -131,17 +133,20 @@ func WRITE_OAMADDR(IO *IOPorts, value byte) {
 }
 
 func WRITE_OAMDATA(IO *IOPorts, value byte) {
-		IO.PPU_OAM[IO.PPU_OAM_ADDRESS] = value
+		refreshOAMRow(IO, oamIndex(IO, IO.PPU_OAM_ADDRESS))
+		IO.PPU_OAM[oamIndex(IO, IO.PPU_OAM_ADDRESS)] = value
 		IO.PPU_OAM_ADDRESS++
 }
 
 func WRITE_PPUSCROLL(IO *IOPorts, value byte) {
 
+	// The first write is the horizontal scroll
+	scrollWrite(IO, value, IO.PPU_MEMORY_STEP == 0)
 	if IO.PPU_MEMORY_STEP == 0 {
-		IO.PPUSCROLL.Y = value
+		IO.PPUSCROLL.X = value
 		IO.PPU_MEMORY_STEP = 1		
 	} else {
-		IO.PPUSCROLL.X = value
+		IO.PPUSCROLL.Y = value
 		IO.PPU_MEMORY_STEP = 0		
 	}
 	
@@ -149,6 +154,7 @@ func WRITE_PPUSCROLL(IO *IOPorts, value byte) {
 
 func WRITE_PPUADDR(IO *IOPorts, value byte) {
 
+	scrollAddr(IO, value, IO.PPU_MEMORY_STEP == 0)
 	if IO.PPU_MEMORY_STEP == 0 {
 		// Records the lower byte
 		IO.PPU_MEMORY_HIGHER = value
@@ -158,6 +164,7 @@ func WRITE_PPUADDR(IO *IOPorts, value byte) {
 		IO.PPU_MEMORY_LOWER = value
 		IO.PPU_MEMORY_STEP = 0
 		IO.VRAM_ADDRESS = LE(IO.PPU_MEMORY_LOWER, IO.PPU_MEMORY_HIGHER)
+		cpuA12(IO, IO.VRAM_ADDRESS)
 	}
 }
 
@@ -166,21 +173,58 @@ func WRITE_PPUDATA(IO *IOPorts, cart *cartridge.Cartridge, value byte) {
 	//if (IO.VRAM_ADDRESS >= 0x23C0) && (IO.VRAM_ADDRESS <=  0x23C0+0xFF) {
 		//fmt.Printf("%X : %X\n", IO.VRAM_ADDRESS, value)	
 	//}
-	IO.PPU_RAM[ mapper.PPU(cart, IO.VRAM_ADDRESS) ] = value
-	IO.VRAM_ADDRESS += IO.PPUCTRL.VRAM_INCREMENT
+	var newaddr uint16 = mapper.PPU(cart, IO.VRAM_ADDRESS)
+	if IsNametable(newaddr) {
+		WriteNametable(IO, newaddr, value)
+	} else {
+		IO.PPU_RAM[newaddr] = value
+	}
+	incrementVRAMAddress(IO)
+}
+
+// Read of the DMA units. Every byte goes through the mapper, an OAM page
+// may cross a mirror. A board without a mapper reads 0; the CPU stops on
+// its own next access.
+func dmaRead(IO *IOPorts, cart *cartridge.Cartridge, cpuaddr uint16) byte {
+	region, finaladdr, err := mapper.MemoryMapper(&IO.BOARD, cart, cpuaddr)
+	if err != nil {
+		return 0
+	}
+	if region == mapper.RegionPRG {
+		return cartridge.ReadPRG(cart, finaladdr)
+	}
+	return ReadRAM(IO, finaladdr)
 }
 
 func WRITE_OAMDMA(IO *IOPorts, cart *cartridge.Cartridge, value byte) {
 	
 	for i:=0; i<256; i++ {
-		cpuaddr := uint16( uint16(value) << 8)
-		prgrom, finaladdr := mapper.MemoryMapper(cart, cpuaddr)
-		var data byte
-		if prgrom == true {
-			data = cart.PRG[ finaladdr + uint16(i)]
-		} else {
-			data = IO.CPU_RAM[ finaladdr + uint16(i)]
-		}
-		IO.PPU_OAM[i] = data
+		cpuaddr := uint16( uint16(value) << 8) + uint16(i)
+		data := dmaRead(IO, cart, cpuaddr)
+		refreshOAMRow(IO, oamIndex(IO, byte(i)))
+		IO.PPU_OAM[oamIndex(IO, byte(i))] = data
+	}
+}
+
+// CPU cycles a DMC sample fetch takes from the CPU. On the console it is 1
+// to 4: 3 or 4 while the CPU reads, depending on the alignment of the
+// fetch, fewer when the halt lands on write cycles, and 2 during an OAM
+// DMA, which already holds the CPU. The core runs each instruction at
+// once and does not know its write cycles nor the alignment, so outside
+// an OAM DMA it takes DMC_STALL, an approximation of the usual case.
+const DMC_STALL = 4
+const DMC_STALL_OAM_DMA = 2
+
+// Fetches the byte the DMC is waiting for, halting the CPU. Runs every CPU
+// cycle after the APU.
+func ClockDMC(IO *IOPorts) {
+	if apu.SampleDue(&IO.APU.DMC) == false {
+		return
+	}
+	apu.FillSample(&IO.APU.DMC, dmaRead(IO, IO.CART, IO.APU.DMC.CurrentAddr))
+	if IO.CLOCK.CPU_CYCLES < IO.OAM_DMA_END {
+		IO.CPU_CYC_INCREASE += DMC_STALL_OAM_DMA
+	} else {
+		IO.CPU_CYC_INCREASE += DMC_STALL
 	}
 }
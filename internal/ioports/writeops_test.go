@@ -0,0 +1,52 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ioports
+
+import "testing"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+
+// A DMC fetch halts the CPU for DMC_STALL cycles, fewer while the CPU is
+// already held by an OAM DMA.
+func TestDMCStall(t *testing.T) {
+	var cart cartridge.Cartridge
+	IO := StartIOPorts(&cart)
+	apu.WriteRegister(&IO.APU, 0x4013, 0x01)
+	apu.WriteRegister(&IO.APU, 0x4015, 0x10)
+
+	ClockDMC(&IO)
+	if IO.CPU_CYC_INCREASE != DMC_STALL {
+		t.Errorf("fetch stalled %d cycles, want %d", IO.CPU_CYC_INCREASE, DMC_STALL)
+	}
+	ClockDMC(&IO)
+	if IO.CPU_CYC_INCREASE != DMC_STALL {
+		t.Errorf("a full sample buffer stalled %d cycles", IO.CPU_CYC_INCREASE - DMC_STALL)
+	}
+
+	IO.CPU_CYC_INCREASE = 0
+	IO.APU.DMC.BufferEmpty = true
+	IO.CLOCK.CPU_CYCLES = 1000
+	WMPPU(&IO, &cart, 0x4014, 0x02)
+	IO.CPU_CYC_INCREASE = 0
+	IO.CLOCK.CPU_CYCLES += 100
+	ClockDMC(&IO)
+	if IO.CPU_CYC_INCREASE != DMC_STALL_OAM_DMA {
+		t.Errorf("fetch during an OAM DMA stalled %d cycles, want %d", IO.CPU_CYC_INCREASE, DMC_STALL_OAM_DMA)
+	}
+}
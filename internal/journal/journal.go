@@ -0,0 +1,253 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package journal
+
+import "bufio"
+import "errors"
+import "fmt"
+import "io"
+import "os"
+import "path/filepath"
+import "strings"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/movie"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/snapshot"
+
+// Session journal. The input of every frame and a savestate every Interval
+// frames are appended to a file next to the ROM, so a later session can
+// resume at the last frame with the whole rewind history, and any part of
+// it can be exported as a movie.
+//
+// The file is a header followed by records:
+//
+//	'I' frame input (3 bytes, see movie.PutFrame), one per frame in order
+//	'K' frame number (8 bytes), length (8 bytes), savestate taken before
+//	    that frame ran
+
+const journalMagic = "ANJ1"
+const DefaultInterval = 600 // A keyframe every 10 seconds
+
+var ErrCartridge = errors.New("the journal belongs to another cartridge")
+
+type Keyframe struct {
+	Frame int
+	Offset int64 // Position of the savestate in the file
+	Size int
+}
+
+type Journal struct {
+	Path string
+	Interval int
+	Frames []movie.Frame // Input of every frame since the journal started
+	Offsets []int64 // File position of the record of each frame
+	Keyframes []Keyframe
+
+	file *os.File
+	writer *bufio.Writer
+	size int64 // Bytes in the file, buffered ones included
+	record snapshot.Encoder
+}
+
+func JournalPath(romfile string) string {
+	return strings.TrimSuffix(romfile, filepath.Ext(romfile)) + ".journal"
+}
+
+// Opens the journal of a cartridge, creating it if needed. A record cut
+// short by a crash is dropped.
+func OpenJournal(path string, hash string) (*Journal, error) {
+	j := new(Journal)
+	j.Path = path
+	j.Interval = DefaultInterval
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j.file = file
+
+	var header snapshot.Encoder
+	header.Buf = append(header.Buf, journalMagic...)
+	snapshot.PutString(&header, hash)
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() == 0 {
+		if _, err = file.Write(header.Buf); err != nil {
+			file.Close()
+			return nil, err
+		}
+		j.size = int64(len(header.Buf))
+	} else if err = scanJournal(j, header.Buf); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if _, err = file.Seek(j.size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	j.writer = bufio.NewWriterSize(file, 64*1024)
+	return j, nil
+}
+
+func scanJournal(j *Journal, header []byte) error {
+	reader := bufio.NewReader(j.file)
+
+	existing := make([]byte, len(header))
+	if _, err := io.ReadFull(reader, existing); err != nil || string(existing[:len(journalMagic)]) != journalMagic {
+		return fmt.Errorf("%s is not a session journal", j.Path)
+	}
+	if string(existing) != string(header) {
+		return ErrCartridge
+	}
+
+	offset := int64(len(header))
+	var buf [16]byte
+	for {
+		kind, err := reader.ReadByte()
+		if err != nil {
+			break
+		}
+		if kind == 'I' {
+			if _, err = io.ReadFull(reader, buf[:3]); err != nil {
+				break
+			}
+			d := snapshot.StartDecoder(buf[:3])
+			j.Frames = append(j.Frames, movie.GetFrame(&d))
+			j.Offsets = append(j.Offsets, offset)
+			offset += 4
+		} else if kind == 'K' {
+			if _, err = io.ReadFull(reader, buf[:16]); err != nil {
+				break
+			}
+			d := snapshot.StartDecoder(buf[:16])
+			frame := snapshot.Int(&d)
+			size := snapshot.Int(&d)
+			if size < 0 || frame != len(j.Frames) {
+				break
+			}
+			if _, err = reader.Discard(size); err != nil {
+				break
+			}
+			j.Keyframes = append(j.Keyframes, Keyframe{frame, offset + 17, size})
+			offset += 17 + int64(size)
+		} else {
+			break
+		}
+	}
+
+	j.size = offset
+	return j.file.Truncate(offset)
+}
+
+// Frame number of the next frame to run.
+func FrameCount(j *Journal) int {
+	return len(j.Frames)
+}
+
+// True when a keyframe has to be added before the next frame runs.
+func NeedsKeyframe(j *Journal) bool {
+	n := len(j.Frames)
+	if n % j.Interval != 0 {
+		return false
+	}
+	return len(j.Keyframes) == 0 || j.Keyframes[len(j.Keyframes)-1].Frame != n
+}
+
+func AddKeyframe(j *Journal, state []byte) error {
+	snapshot.Reset(&j.record)
+	snapshot.PutByte(&j.record, 'K')
+	snapshot.PutInt(&j.record, len(j.Frames))
+	snapshot.PutInt(&j.record, len(state))
+	if _, err := j.writer.Write(j.record.Buf); err != nil {
+		return err
+	}
+	if _, err := j.writer.Write(state); err != nil {
+		return err
+	}
+	j.Keyframes = append(j.Keyframes, Keyframe{len(j.Frames), j.size + 17, len(state)})
+	j.size += 17 + int64(len(state))
+
+	// A keyframe is a good point to make the history so far durable
+	return j.writer.Flush()
+}
+
+// Records the input of the frame about to run. Subframe input is not kept.
+func Record(j *Journal, f movie.Frame) error {
+	f.Polls = nil
+	snapshot.Reset(&j.record)
+	snapshot.PutByte(&j.record, 'I')
+	movie.PutFrame(&j.record, f)
+	if _, err := j.writer.Write(j.record.Buf); err != nil {
+		return err
+	}
+	j.Frames = append(j.Frames, f)
+	j.Offsets = append(j.Offsets, j.size)
+	j.size += 4
+	return nil
+}
+
+// Returns the last keyframe at or before frame and its savestate.
+func KeyframeBefore(j *Journal, frame int) (Keyframe, []byte, error) {
+	for i := len(j.Keyframes) - 1; i >= 0; i-- {
+		k := j.Keyframes[i]
+		if k.Frame > frame {
+			continue
+		}
+		if err := j.writer.Flush(); err != nil {
+			return k, nil, err
+		}
+		state := make([]byte, k.Size)
+		_, err := j.file.ReadAt(state, k.Offset)
+		return k, state, err
+	}
+	return Keyframe{}, nil, fmt.Errorf("no keyframe before frame %d", frame)
+}
+
+// Drops the history after frame, so play continues from there.
+func Truncate(j *Journal, frame int) error {
+	if frame >= len(j.Frames) {
+		return nil
+	}
+	if err := j.writer.Flush(); err != nil {
+		return err
+	}
+	j.size = j.Offsets[frame]
+	j.Frames = j.Frames[:frame]
+	j.Offsets = j.Offsets[:frame]
+	for len(j.Keyframes) > 0 && j.Keyframes[len(j.Keyframes)-1].Frame > frame {
+		j.Keyframes = j.Keyframes[:len(j.Keyframes)-1]
+	}
+	if err := j.file.Truncate(j.size); err != nil {
+		return err
+	}
+	_, err := j.file.Seek(j.size, io.SeekStart)
+	return err
+}
+
+func CloseJournal(j *Journal) error {
+	if err := j.writer.Flush(); err != nil {
+		j.file.Close()
+		return err
+	}
+	return j.file.Close()
+}
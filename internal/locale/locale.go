@@ -0,0 +1,85 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package locale
+
+import "fmt"
+import "os"
+import "strings"
+
+// Translations of the messages shown to the user. The English text is the
+// key, so a message missing from a table is shown in English:
+//
+//	fmt.Println(locale.T("Loading %s", path))
+
+var Language = "en"
+
+var tables = map[string]map[string]string{
+	"pt-BR": portuguese,
+}
+
+// Accepts "en", "pt-BR" and the forms found in LANG, like "pt_BR.UTF-8".
+func SetLanguage(name string) bool {
+	name = normalize(name)
+	if _, found := tables[name]; found == false && name != "en" {
+		return false
+	}
+	Language = name
+	return true
+}
+
+// Language of the environment, or "en" if there is no table for it.
+func FromEnvironment() string {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		name := normalize(os.Getenv(v))
+		if name == "" {
+			continue
+		}
+		if _, found := tables[name]; found {
+			return name
+		}
+		return "en"
+	}
+	return "en"
+}
+
+func normalize(name string) string {
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.Replace(name, "_", "-", 1)
+	if strings.EqualFold(name, "pt-br") || strings.EqualFold(name, "pt") {
+		return "pt-BR"
+	}
+	if strings.EqualFold(name, "en") || strings.HasPrefix(strings.ToLower(name), "en-") || name == "C" || name == "POSIX" {
+		return "en"
+	}
+	return name
+}
+
+// Translates a message and formats it with fmt.Sprintf when there are arguments.
+func T(message string, args ...interface{}) string {
+	if translated, found := tables[Language][message]; found {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
@@ -0,0 +1,120 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package locale
+
+// Brazilian Portuguese.
+var portuguese = map[string]string{
+	"  controller %d, %s, frames %d-%d": "  controle %d, %s, quadros %d-%d",
+	"%d PPU accesses captured, the frame is in %s and its replay in %s": "%d acessos à PPU capturados, o quadro está em %s e a sua repetição em %s",
+	"%d differences": "%d diferenças",
+	"%d frames and %d re-records written to %s": "%d quadros e %d regravações gravados em %s",
+	"%d frames in %.3fs: %.1f fps, %.3fms per frame": "%d quadros em %.3fs: %.1f fps, %.3fms por quadro",
+	"%d frames played, audio SHA-1 %x": "%d quadros reproduzidos, SHA-1 do áudio %x",
+	"%d lag frames, %d controller latches": "%d quadros de lag, %d leituras dos controles",
+	"Adaptive quality: %.1f fps, switching to the performance options": "Qualidade adaptativa: %.1f fps, mudando para as opções de desempenho",
+	"Adaptive quality: back to the %s options": "Qualidade adaptativa: de volta às opções %s",
+	"Battery save loaded from %s": "Jogo salvo carregado de %s",
+	"Cannot compare the savestates: %v": "Não foi possível comparar os estados salvos: %v",
+	"Cannot finish the sound recording: %v": "Não foi possível concluir a gravação do som: %v",
+	"Cannot finish the sound register log: %v": "Não foi possível concluir o registro dos registradores de som: %v",
+	"Cannot keep the state over the reload: %v": "Não foi possível manter o estado na recarga: %v",
+	"Cannot load the movie start: %v": "Não foi possível carregar o início do filme: %v",
+	"Cannot load the savestate: %v": "Não foi possível carregar o estado salvo: %v",
+	"Cannot log the sound registers: %v": "Não foi possível registrar os registradores de som: %v",
+	"Cannot open the session journal: %v": "Não foi possível abrir o diário da sessão: %v",
+	"Cannot read %s: %v": "Não foi possível ler %s: %v",
+	"Cannot read the movie: %v": "Não foi possível ler o filme: %v",
+	"Cannot record the sound channels: %v": "Não foi possível gravar os canais de som: %v",
+	"Cannot record the sound: %v": "Não foi possível gravar o som: %v",
+	"Cannot save the gamepad mappings: %v": "Não foi possível salvar o mapeamento dos controles: %v",
+	"Cannot save the window position: %v": "Não foi possível salvar a posição da janela: %v",
+	"Cannot seek the session journal: %v": "Não foi possível posicionar o diário da sessão: %v",
+	"Cannot undo the load: %v": "Não foi possível desfazer o carregamento: %v",
+	"Cannot watch the ROM: %v": "Não foi possível observar a ROM: %v",
+	"Cannot write the battery save: %v": "Não foi possível gravar o jogo salvo: %v",
+	"Cannot write the compatibility report: %v": "Não foi possível gravar o relatório de compatibilidade: %v",
+	"Cannot write the crash dump: %v": "Não foi possível gravar o relatório de falha: %v",
+	"Cannot write the frame capture: %v": "Não foi possível gravar a captura do quadro: %v",
+	"Cannot write the movie: %v": "Não foi possível gravar o filme: %v",
+	"Cannot write the savestate: %v": "Não foi possível gravar o savestate: %v",
+	"Cannot write the session journal: %v": "Não foi possível gravar o diário da sessão: %v",
+	"Console: %s (%s) from %s": "Console: %s (%s) por %s",
+	"Crash dump written to %s, please attach it to the bug report": "Relatório de falha gravado em %s, anexe-o ao relatório do bug",
+	"Debug mode is off": "Modo de depuração desligado",
+	"Debug mode is on": "Modo de depuração ligado",
+	"Expansion audio: %s at %d mB (the chip is not emulated)": "Áudio de expansão: %s a %d mB (o chip não é emulado)",
+	"Frame captures are disabled in hardcore mode": "As capturas de quadro estão desativadas no modo hardcore",
+	"Frames %d-%d written to %s": "Quadros %d-%d gravados em %s",
+	"Hardcore mode: loading savestates, rewinding, movie playback and frame captures are disabled": "Modo hardcore: carregar savestates, voltar no tempo, reproduzir filmes e capturas de quadro estão desativados",
+	"Invalid --bank, the ROM has banks 0-%d": "--bank inválido, a ROM tem os bancos 0-%d",
+	"Invalid --bench frame count": "Número de quadros inválido em --bench",
+	"Invalid --iolog-filter: %v": "--iolog-filter inválido: %v",
+	"Invalid --mic threshold, use a level between 0 and 1": "Limiar de --mic inválido, use um nível entre 0 e 1",
+	"Invalid --oam-decay, use a time in milliseconds": "--oam-decay inválido, use um tempo em milissegundos",
+	"Invalid --replay-edit register %s": "Registrador inválido em --replay-edit: %s",
+	"Invalid --segment, the journal has frames 0-%d": "--segment inválido, o diário tem os quadros 0-%d",
+	"Invalid --watch-keep, use ram or state": "--watch-keep inválido, use ram ou state",
+	"Invalid --watchdog, use a number of seconds or 0 to disable it": "--watchdog inválido, use um número de segundos ou 0 para desligá-lo",
+	"Invalid dead zone: %s (use 0 to 99)": "Zona morta inválida: %s (use 0 a 99)",
+	"Invalid display: %s": "Monitor inválido: %s",
+	"Load undone, Shift+F11 again redoes it": "Carregamento desfeito, Shift+F11 de novo o refaz",
+	"Loading %s": "Carregando %s",
+	"Mapper %d is not supported": "O mapper %d não é suportado",
+	"Match": "Confere",
+	"Mismatch, expected %s": "Não confere, o esperado era %s",
+	"Movie playback is disabled in hardcore mode": "A reprodução de filmes está desativada no modo hardcore",
+	"Not reloading the ROM: %v": "A ROM não foi recarregada: %v",
+	"Per-game settings loaded from %s": "Configurações do jogo carregadas de %s",
+	"Picture SHA-1 after %d frames: %s": "SHA-1 da imagem após %d quadros: %s",
+	"Preset: %s": "Predefinição: %s",
+	"Re-record %d at frame %d": "Regravação %d no quadro %d",
+	"Region database: %v": "Banco de dados de regiões: %v",
+	"Region: %s from %s": "Região: %s por %s",
+	"Region: %s from the ROM": "Região: %s pela ROM",
+	"Reloaded %s": "%s recarregada",
+	"Resuming the session journal at frame %d": "Retomando o diário da sessão no quadro %d",
+	"Rewinding needs --journal": "Para voltar no tempo é preciso usar --journal",
+	"Savestate loaded from %s": "Savestate carregado de %s",
+	"Savestate saved in slot %d": "Savestate salvo no slot %d",
+	"Savestate slot %d (empty)": "Slot de savestate %d (vazio)",
+	"Savestate slot %d": "Slot de savestate %d",
+	"Session journal stopped: %v": "Diário da sessão interrompido: %v",
+	"Slot %d holds a savestate, press F10 again to overwrite it": "O slot %d já tem um savestate, pressione F10 de novo para sobrescrevê-lo",
+	"The ROM changed, the session journal is closed": "A ROM mudou, o diário da sessão foi fechado",
+	"The board of the ROM changed, starting from power up": "A placa da ROM mudou, reiniciando do zero",
+	"The console went back past the start of the movie, recording stopped": "O console voltou para antes do início do filme, gravação interrompida",
+	"The emulation has not finished a frame for %s:": "A emulação não termina um quadro há %s:",
+	"The movie %s starts at power on, it has no savestate": "O filme %s começa ao ligar o console, não tem estado salvo",
+	"The movie was recorded with another ROM": "O filme foi gravado com outra ROM",
+	"Unknown --replay-edit %s, use zero-scroll, no-dma or drop=register": "--replay-edit desconhecido %s, use zero-scroll, no-dma ou drop=registrador",
+	"Unknown audio quality %s, use linear or sinc": "Qualidade de áudio desconhecida %s, use linear ou sinc",
+	"Unknown button.%s=%s, use a, b, select, start, up, down, left or right and an SDL key name": "button.%s=%s desconhecido, use a, b, select, start, up, down, left ou right e um nome de tecla do SDL",
+	"Unknown expansion chip %s, use vrc6, n163, fds, 5b or mmc5": "Chip de expansão desconhecido %s, use vrc6, n163, fds, 5b ou mmc5",
+	"Unknown language %s, use en or pt-BR": "Idioma desconhecido %s, use en ou pt-BR",
+	"Unknown preset %s, use performance, balanced or accuracy": "Predefinição desconhecida %s, use performance, balanced ou accuracy",
+	"Unknown region %s, use ntsc, pal or dendy": "Região desconhecida %s, use ntsc, pal ou dendy",
+	"Unknown revision %s, use front-loader, top-loader, famicom, famicom-early or av-famicom": "Revisão desconhecida %s, use front-loader, top-loader, famicom, famicom-early ou av-famicom",
+	"Usage: alphanes [run|info|verify|disasm|statediff] game.nes [options]": "Uso: alphanes [run|info|verify|disasm|statediff] jogo.nes [opções]",
+	"Usage: alphanes statediff game.nes first second": "Uso: alphanes statediff jogo.nes primeiro segundo",
+	"Warning: the movie has input faster than a player can press, tagged as auto-fire:": "Aviso: o filme tem entradas mais rápidas do que um jogador consegue apertar, marcadas como tiro automático:",
+	"Warning: the movie was recorded with %d lag frames and %d latches, it may have desynchronized": "Aviso: o filme foi gravado com %d quadros de lag e %d leituras, ele pode ter perdido a sincronia",
+	"Watching %s for changes": "Observando alterações em %s",
+	"the game settings": "configurações do jogo",
+	"verify needs --frames with a frame count": "verify precisa de --frames com um número de quadros",
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package mapper
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/snapshot"
+
+// Registers of the cartridge board. Every mapper uses the same struct and
+// only the fields its board has; the console keeps it with the other
+// ports, so it goes in the savestates.
+type Board struct {
+	Mapper int
+	Submapper int // NES 2.0 submapper, 0 on iNES images unless the board guesses it
+	PRGCount int // 8 KB banks of PRG-ROM
+	CHRCount int // 1 KB banks of CHR-ROM, 0 with CHR-RAM
+	PRG [4]int // 8 KB PRG-ROM bank at $8000, $A000, $C000 and $E000
+	CHR [8]int // 1 KB CHR bank at $0000, $0400 ... $1C00
+	CHRDisabled bool // The protection of mapper 185 is keeping the CHR-ROM off the bus
+	Mirroring int // Set by the board, MirrorHeader until it does
+	NametableCHR bool // The nametables come from CHR-ROM instead of VRAM
+	Nametables [2]int // 1 KB CHR-ROM banks used as nametable pages 0 and 1 in that mode
+	MenuMode bool // Mapper 230 shows the multicart menu instead of Contra
+
+	Select byte // Bank select register of the MMC3-like boards
+	Registers [16]byte // Bank registers, numbered as the board does
+
+	IRQLatch byte // Value the counter is reloaded with
+	IRQCounter int
+	IRQReload bool // The next clock reloads the counter
+	IRQEnabled bool
+	IRQCycles bool // The counter is clocked by the CPU instead of the scanlines
+	IRQPrescaler int // CPU cycles counted toward the next clock in cycle mode
+	IRQ bool // The board is holding the IRQ line low
+}
+
+// Nametable mirroring of the board, numbered as in the ioports package.
+const (
+	MirrorHeader = -1 // The board does not control it, the header does
+	MirrorHorizontal = 0
+	MirrorVertical = 1
+	MirrorSingleLow = 2
+	MirrorSingleHigh = 3
+	MirrorFourScreen = 4
+)
+
+// Board at power up: the last PRG banks at the end of the address space,
+// like NROM, and the first 8 KB of CHR.
+func StartBoard(cart *cartridge.Cartridge) Board {
+	var b Board
+	b.Mapper = cart.Header.RomType.Mapper
+	if cart.Header.ROM_TYPE2 & 0x0C == 0x08 {
+		b.Submapper = int(cart.Header.ROM_BLANK[0] >> 4)
+	}
+	b.PRGCount = len(cart.PRG) / 0x2000
+	b.CHRCount = len(cart.CHR) / 0x400
+	for i := range b.PRG {
+		b.PRG[i] = i
+	}
+	for i := range b.CHR {
+		b.CHR[i] = i
+	}
+	b.Mirroring = MirrorHeader
+
+	switch b.Mapper {
+		case 64:
+			rambo1Banks(&b)
+		case 68:
+			b.PRG = [4]int{0, 1, b.PRGCount - 2, b.PRGCount - 1}
+		case 185:
+			// The protection only lets the CHR through after the game
+			// writes the right value
+			b.CHRDisabled = true
+		case 210:
+			b.PRG[3] = b.PRGCount - 1
+			if b.Submapper == 0 {
+				b.Submapper = guessNamco210(cart)
+			}
+	}
+	resetMulticart(&b)
+	return b
+}
+
+// Pressing reset. The multicarts go back to their menu, and mapper 230
+// switches between Contra and the menu; the other boards keep their
+// registers.
+func ResetBoard(b *Board) {
+	if b.Mapper == 230 {
+		b.MenuMode = !b.MenuMode
+	}
+	resetMulticart(b)
+}
+
+func resetMulticart(b *Board) {
+	switch b.Mapper {
+		case 225, 228:
+			b.Mirroring = MirrorVertical
+			prgMode16(b, 0, false)
+			SetCHR8(b, 0)
+		case 226:
+			b.Registers[0], b.Registers[1] = 0, 0
+			write226(b, 0x8000, 0)
+		case 230:
+			write230(b, 0)
+	}
+}
+
+// Offset inside PRG-ROM of a CPU address in $8000-$FFFF.
+func MapPRG(b *Board, addr uint16) uint32 {
+	return uint32(b.PRG[(addr - 0x8000) / 0x2000 & 3])*0x2000 + uint32(addr & 0x1FFF)
+}
+
+// Offset inside CHR-ROM of a PPU address in $0000-$1FFF.
+func MapCHR(b *Board, addr uint16) uint32 {
+	return uint32(b.CHR[addr / 0x400 & 7])*0x400 + uint32(addr & 0x3FF)
+}
+
+// CHR-ROM read through the bank registers. With the CHR disabled nothing
+// drives the PPU data bus and the low byte of the address, left there by
+// the address latch, is read back.
+func ReadCHR(b *Board, cart *cartridge.Cartridge, addr uint16) byte {
+	if b.CHRDisabled {
+		return byte(addr)
+	}
+	return cartridge.ReadCHR(cart, MapCHR(b, addr))
+}
+
+// Byte of a nametable page taken from CHR-ROM, see Board.NametableCHR.
+func ReadNametableCHR(b *Board, cart *cartridge.Cartridge, page int, offset int) byte {
+	return cartridge.ReadCHR(cart, uint32(b.Nametables[page & 1])*0x400 + uint32(offset))
+}
+
+// Points 8 KB of CHR at $0000-$1FFF.
+func SetCHR8(b *Board, bank int) {
+	for i := 0; i < 8; i++ {
+		b.CHR[i] = bank*8 + i
+	}
+}
+
+// Points 4 KB of CHR at $0000 (half 0) or $1000 (half 1).
+func SetCHR4(b *Board, half int, bank int) {
+	for i := 0; i < 4; i++ {
+		b.CHR[half*4 + i] = bank*4 + i
+	}
+}
+
+// A CPU write to $4020-$FFFF. Returns true when the board took it as a
+// register write, the caller handles the others (PRG-RAM, expansion). The
+// boards with registers take every write to PRG-ROM, NROM none.
+func WriteRegister(b *Board, cart *cartridge.Cartridge, addr uint16, value byte) bool {
+	switch b.Mapper {
+		case 64:
+			if addr >= 0x8000 {
+				writeRAMBO1(b, addr, value)
+				return true
+			}
+		case 68:
+			if addr >= 0x8000 {
+				writeSunsoft4(b, addr, value)
+				return true
+			}
+		case 210:
+			if addr >= 0x8000 {
+				writeNamco210(b, addr, value)
+				return true
+			}
+		case 225:
+			if addr >= 0x8000 {
+				write225(b, addr)
+				return true
+			}
+		case 226:
+			if addr >= 0x8000 {
+				write226(b, addr, value)
+				return true
+			}
+		case 228:
+			if addr >= 0x8000 {
+				writeAction52(b, addr, value)
+				return true
+			}
+		case 230:
+			if addr >= 0x8000 {
+				write230(b, value)
+				return true
+			}
+		case 3:
+			// CNROM: 8 KB CHR bank at $8000-$FFFF. Without a latch
+			// enable the ROM drives the bus too, so the value is ANDed
+			// with the byte at the address; NES 2.0 submapper 1 names
+			// the boards that avoid the conflict.
+			if addr >= 0x8000 {
+				if b.Submapper != 1 {
+					value &= cartridge.ReadPRG(cart, MapPRG(b, addr))
+				}
+				SetCHR8(b, int(value))
+				return true
+			}
+		case 87:
+			// Jaleco JF-xx and Konami boards: 8 KB CHR bank at
+			// $6000-$7FFF with the two bits swapped
+			if addr >= 0x6000 && addr < 0x8000 {
+				SetCHR8(b, int(value & 0x01) << 1 | int(value & 0x02) >> 1)
+				return true
+			}
+		case 184:
+			// Sunsoft-1: two 4 KB CHR banks at $6000-$7FFF, the low one in
+			// bits 0-2 and the high one in bits 4-6
+			if addr >= 0x6000 && addr < 0x8000 {
+				SetCHR4(b, 0, int(value & 0x07))
+				SetCHR4(b, 1, int(value >> 4 & 0x07))
+				return true
+			}
+		case 185:
+			// CNROM with a protection diode instead of CHR banking. The
+			// ROM drives the bus while it is written, so the value is ANDed
+			// with the byte at the address.
+			if addr >= 0x8000 {
+				value &= cartridge.ReadPRG(cart, MapPRG(b, addr))
+				b.CHRDisabled = !chrEnabled185(b.Submapper, value)
+				return true
+			}
+	}
+	return addr >= 0x8000 && b.Mapper != 0
+}
+
+// Boards whose IRQ counter is clocked by the rises of PPU A12.
+func CountsScanlines(b *Board) bool {
+	return b.Mapper == 64 && b.IRQCycles == false
+}
+
+// Called by the PPU on each rise of A12 the board sees, about once per
+// rendered scanline. Returns true when the board raised its IRQ.
+func ClockScanline(b *Board) bool {
+	switch b.Mapper {
+		case 64:
+			if b.IRQCycles == false {
+				return clockRAMBO1(b)
+			}
+	}
+	return false
+}
+
+// Called once per CPU cycle. Returns true when the board raised its IRQ.
+func ClockCPU(b *Board) bool {
+	switch b.Mapper {
+		case 64:
+			if b.IRQCycles {
+				b.IRQPrescaler = (b.IRQPrescaler + 1) & 3
+				if b.IRQPrescaler == 0 {
+					return clockRAMBO1(b)
+				}
+			}
+	}
+	return false
+}
+
+// NES 2.0 submappers 4-7 name the value that enables the CHR. The iNES
+// images use the rule that fits the known games: anything but 0 in the
+// low bits, and not $13, which one of them writes to turn the CHR off.
+func chrEnabled185(submapper int, value byte) bool {
+	if submapper >= 4 && submapper <= 7 {
+		return int(value & 0x03) == submapper - 4
+	}
+	return value & 0x0F != 0 && value != 0x13
+}
+
+// Savestate encoding of the registers. The mapper and the sizes come from
+// the cartridge and are not saved.
+func EncodeState(b *Board, e *snapshot.Encoder) {
+	for _, bank := range b.PRG {
+		snapshot.PutInt(e, bank)
+	}
+	for _, bank := range b.CHR {
+		snapshot.PutInt(e, bank)
+	}
+	snapshot.PutBool(e, b.CHRDisabled)
+	snapshot.PutInt(e, b.Mirroring)
+	snapshot.PutBool(e, b.NametableCHR)
+	snapshot.PutBool(e, b.MenuMode)
+	for _, bank := range b.Nametables {
+		snapshot.PutInt(e, bank)
+	}
+	snapshot.PutByte(e, b.Select)
+	for _, r := range b.Registers {
+		snapshot.PutByte(e, r)
+	}
+	snapshot.PutByte(e, b.IRQLatch)
+	snapshot.PutInt(e, b.IRQCounter)
+	snapshot.PutBool(e, b.IRQReload)
+	snapshot.PutBool(e, b.IRQEnabled)
+	snapshot.PutBool(e, b.IRQCycles)
+	snapshot.PutInt(e, b.IRQPrescaler)
+	snapshot.PutBool(e, b.IRQ)
+}
+
+func DecodeState(b *Board, d *snapshot.Decoder) {
+	for i := range b.PRG {
+		b.PRG[i] = snapshot.Int(d)
+	}
+	for i := range b.CHR {
+		b.CHR[i] = snapshot.Int(d)
+	}
+	b.CHRDisabled = snapshot.Bool(d)
+	b.Mirroring = snapshot.Int(d)
+	b.NametableCHR = snapshot.Bool(d)
+	b.MenuMode = snapshot.Bool(d)
+	for i := range b.Nametables {
+		b.Nametables[i] = snapshot.Int(d)
+	}
+	b.Select = snapshot.Byte(d)
+	for i := range b.Registers {
+		b.Registers[i] = snapshot.Byte(d)
+	}
+	b.IRQLatch = snapshot.Byte(d)
+	b.IRQCounter = snapshot.Int(d)
+	b.IRQReload = snapshot.Bool(d)
+	b.IRQEnabled = snapshot.Bool(d)
+	b.IRQCycles = snapshot.Bool(d)
+	b.IRQPrescaler = snapshot.Int(d)
+	b.IRQ = snapshot.Bool(d)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package mapper
+
+import "testing"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+
+var fuzzedMappers = []int{0, 3, 64, 68, 87, 184, 185, 210, 225, 226, 228, 230}
+
+// Cartridge of a mapper with the given number of 16 KB PRG and 8 KB CHR
+// banks, each byte holding the low bits of its offset.
+func testCartridge(number int, submapper byte, prgBanks byte, chrBanks byte) cartridge.Cartridge {
+	var cart cartridge.Cartridge
+	cart.Header.ROM_SIZE = prgBanks
+	cart.Header.VROM_SIZE = chrBanks
+	cart.Header.RomType.Mapper = number
+	if submapper != 0 {
+		cart.Header.ROM_TYPE2 = 0x08
+		cart.Header.ROM_BLANK[0] = submapper << 4
+	}
+	cart.PRG = make([]byte, int(prgBanks)*16384)
+	cart.CHR = make([]byte, int(chrBanks)*8192)
+	for i := range cart.PRG {
+		cart.PRG[i] = byte(i)
+	}
+	for i := range cart.CHR {
+		cart.CHR[i] = byte(i)
+	}
+	return cart
+}
+
+// Random register writes, 3 bytes each (address low, address high,
+// value), to every supported board. Afterwards the whole CPU and PPU
+// address spaces must map and read without a crash. Bank numbers past the
+// end of the image are fine, the reads wrap around.
+func FuzzWriteRegister(f *testing.F) {
+	f.Add(byte(1), byte(0), byte(2), byte(1), []byte{0x00, 0x80, 0x03})
+	f.Add(byte(2), byte(0), byte(8), byte(16), []byte{0x00, 0x80, 0x06, 0x01, 0x80, 0x07, 0x00, 0xC0, 0x10, 0x01, 0xE0, 0x00})
+	f.Add(byte(7), byte(5), byte(2), byte(1), []byte{0x00, 0x80, 0x11})
+	f.Add(byte(8), byte(2), byte(16), byte(32), []byte{0x00, 0x50, 0xFF, 0x00, 0xE0, 0x40})
+	f.Add(byte(10), byte(0), byte(0), byte(0), []byte{0xFF, 0xFF, 0xFF})
+
+	f.Fuzz(func(t *testing.T, which byte, submapper byte, prgBanks byte, chrBanks byte, writes []byte) {
+		number := fuzzedMappers[int(which) % len(fuzzedMappers)]
+		cart := testCartridge(number, submapper & 0x0F, prgBanks % 33, chrBanks % 65)
+		b := StartBoard(&cart)
+
+		for i := 0; i + 2 < len(writes); i += 3 {
+			addr := uint16(writes[i]) | uint16(writes[i + 1]) << 8
+			WriteRegister(&b, &cart, addr, writes[i + 2])
+			ClockScanline(&b)
+			ClockCPU(&b)
+			if writes[i + 2] == 0xFF {
+				ResetBoard(&b)
+			}
+		}
+
+		for addr := 0; addr < 0x10000; addr += 0x7F {
+			region, offset, err := MemoryMapper(&b, &cart, uint16(addr))
+			if err != nil {
+				t.Fatalf("mapper %d: %v", number, err)
+			}
+			if region == RegionPRG {
+				cartridge.ReadPRG(&cart, offset)
+			} else if offset >= 0x10000 {
+				t.Fatalf("mapper %d: $%04X maps to %d, outside the address space", number, addr, offset)
+			}
+		}
+		for addr := 0; addr < 0x2000; addr += 0x3F {
+			ReadCHR(&b, &cart, uint16(addr))
+		}
+		for page := 0; page < 2; page++ {
+			ReadNametableCHR(&b, &cart, page, 0x3FF)
+		}
+		GetStatus(&b, &cart)
+	})
+}
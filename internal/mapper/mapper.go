@@ -0,0 +1,110 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package mapper
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "errors"
+
+// Memory a CPU address lands in. The offsets that go with it are 32 bits
+// wide, PRG-ROM images are larger than the address space.
+type Region int
+
+const (
+	RegionRAM Region = iota // CPU_RAM, indexed by the address without mirrors: internal RAM, registers and PRG-RAM
+	RegionPRG // PRG-ROM of the cartridge
+)
+
+var ErrUnsupported = errors.New("memory mapper not supported")
+
+// Returns RegionPRG and the offset inside PRG-ROM for addresses >= 0x8000,
+// otherwise the CPU address with the RAM and PPU register mirrors removed.
+func Zero (addr uint16, prgsize byte) (Region, uint32) {
+
+	// 16KB images are mirrored at 0xC000, 32KB images fill the whole range
+	if addr >= 0x8000 {
+		var size uint32 = uint32(prgsize)*16384
+		if size == 0 {
+			return RegionPRG, 0
+		}
+		return RegionPRG, uint32(addr - 0x8000) % size
+	}
+	
+	// Check the three mirrors of (0x0000-0x07FF) at (0x0800 - 0x2000)
+
+	
+		if addr >= 0x0000 && addr < 0x2000 {
+			addr = addr % 0x0800
+		}
+			
+		
+	
+		// Check the mirrors of (02007-0x2007) to (0x2008 - 0x3FFF)
+		if addr >= 0x2008 && addr <= 0x3FFF {
+			addr = (addr % 8) + 0x2000
+		}
+	
+	return RegionRAM, uint32(addr)
+}
+
+// Mappers the emulator implements.
+func Supported(mapper int) bool {
+	switch mapper {
+		case 0, 3, 64, 68, 87, 184, 185, 210, 225, 226, 228, 230:
+			return true
+	}
+	return false
+}
+
+// Like Zero, with the PRG-ROM offset taken from the bank registers of the
+// board. Fails with ErrUnsupported on boards the emulator does not have.
+func MemoryMapper(b *Board, cart *cartridge.Cartridge, addr uint16) (Region, uint32, error) {
+	
+	if Supported(b.Mapper) == false {
+		return RegionRAM, 0, ErrUnsupported
+	}
+	region, newaddr := Zero(addr, cart.Header.ROM_SIZE)
+	if region == RegionPRG && len(cart.PRG) > 0 {
+		newaddr = MapPRG(b, addr)
+	}
+	return region, newaddr, nil
+}
+
+func PPU(cart *cartridge.Cartridge, addr uint16) uint16 {
+
+	// $3000-$3EFF mirrors $2000-$2EFF. The nametable mirroring itself is
+	// done by the nametable page mapping in the ioports package.
+	if (addr >= 0x3000) && (addr < 0x3F00) {
+		return addr - 0x1000
+	}
+
+	if (addr >= 0x3F00 && addr <= 0x3FFF) {
+		// Addresses $3F10/$3F14/$3F18/$3F1C are mirrors of $3F00/$3F04/$3F08/$3F0C.
+		// $3F04/$3F08/$3F0C are entries of their own: the renderer never
+		// draws them but they keep what is written and read back through $2007.
+		entry := addr % 32
+		if (entry >= 0x10) && (entry % 4 == 0) {
+			entry -= 0x10
+		}
+		return 0x3F00 + entry
+	}
+	
+	if (addr >= 0x4000) {
+		return PPU(cart, addr % 0x4000)
+	}
+	return addr
+}
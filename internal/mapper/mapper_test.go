@@ -0,0 +1,131 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package mapper
+
+import "testing"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+
+// The backdrop entries of the sprite palettes are the ones of the
+// background palettes, written from either side; the other entries are
+// their own.
+func TestPalettePPUMirrors(t *testing.T) {
+	tests := []struct {
+		write uint16
+		read uint16
+		shared bool
+	}{
+		{0x3F10, 0x3F00, true},
+		{0x3F14, 0x3F04, true},
+		{0x3F18, 0x3F08, true},
+		{0x3F1C, 0x3F0C, true},
+		{0x3F00, 0x3F10, true},
+		{0x3F04, 0x3F14, true},
+		{0x3F08, 0x3F18, true},
+		{0x3F0C, 0x3F1C, true},
+		{0x3F30, 0x3F00, true},
+		{0x3F3C, 0x3F1C, true},
+		{0x7F10, 0x3F00, true},
+		{0x3F11, 0x3F01, false},
+		{0x3F01, 0x3F11, false},
+		{0x3F04, 0x3F00, false},
+		{0x3F1F, 0x3F0F, false},
+	}
+
+	var cart cartridge.Cartridge
+	for _, test := range tests {
+		ram := make([]byte, 0x4000)
+		ram[PPU(&cart, test.write)] = 0x2A
+		got := ram[PPU(&cart, test.read)]
+		if test.shared && got != 0x2A {
+			t.Errorf("write to $%04X did not read back from $%04X", test.write, test.read)
+		}
+		if test.shared == false && got != 0 {
+			t.Errorf("write to $%04X read back from $%04X", test.write, test.read)
+		}
+	}
+}
+
+// Bank windows of large images end past 64KB and past 1MB; the offsets are
+// 32 bits wide on every platform.
+func TestLargeBankOffsets(t *testing.T) {
+	cart := testCartridge(0, 0, 128, 0)
+	b := StartBoard(&cart)
+	b.PRG = [4]int{8, 200, 255, 3}
+	tests := []struct {
+		addr uint16
+		want uint32
+	}{
+		{0x8000, 0x10000},
+		{0xBFFF, 200*0x2000 + 0x1FFF},
+		{0xC123, 255*0x2000 + 0x123},
+		{0xE000, 0x6000},
+	}
+	for _, test := range tests {
+		region, offset, err := MemoryMapper(&b, &cart, test.addr)
+		if err != nil || region != RegionPRG || offset != test.want {
+			t.Errorf("$%04X maps to %d %X, want PRG %X", test.addr, region, offset, test.want)
+		}
+		if got := cartridge.ReadPRG(&cart, offset); got != byte(test.want) {
+			t.Errorf("$%04X reads %02X, want %02X", test.addr, got, byte(test.want))
+		}
+	}
+}
+
+// An unsupported board is an error for the caller, not an exit.
+func TestMemoryMapperUnsupported(t *testing.T) {
+	cart := testCartridge(1, 0, 2, 1)
+	b := StartBoard(&cart)
+	if _, _, err := MemoryMapper(&b, &cart, 0x8000); err != ErrUnsupported {
+		t.Errorf("mapper 1 maps with error %v, want ErrUnsupported", err)
+	}
+}
+
+// CNROM switches 8 KB of CHR. The PRG-ROM drives the bus during the write,
+// so the bank is the value ANDed with the byte at the address; the test
+// image holds the low byte of its offset there. Submapper 1 boards have no
+// conflict and take the value as written.
+func TestCNROMBanks(t *testing.T) {
+	tests := []struct {
+		submapper byte
+		addr uint16
+		value byte
+		bank int
+	}{
+		{0, 0x8003, 0x03, 3},
+		{0, 0x8003, 0xFF, 3},
+		{0, 0x8001, 0x02, 0},
+		{0, 0xC002, 0x03, 2},
+		{1, 0x8001, 0x02, 2},
+		{1, 0x8000, 0x03, 3},
+	}
+	for _, test := range tests {
+		cart := testCartridge(3, test.submapper, 2, 4)
+		b := StartBoard(&cart)
+		if WriteRegister(&b, &cart, test.addr, test.value) == false {
+			t.Errorf("submapper %d: write to $%04X not taken", test.submapper, test.addr)
+			continue
+		}
+		for _, addr := range []uint16{0x0000, 0x1FFF} {
+			want := uint32(test.bank)*0x2000 + uint32(addr)
+			if got := MapCHR(&b, addr); got != want {
+				t.Errorf("submapper %d: $%02X to $%04X maps PPU $%04X to %X, want %X", test.submapper, test.value, test.addr, addr, got, want)
+			}
+		}
+	}
+}
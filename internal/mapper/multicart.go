@@ -0,0 +1,120 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package mapper
+
+// Multicart boards. Their menus pick a game by writing to $8000-$FFFF
+// and most of them take the bank from the address lines as much as from
+// the value written. The ROMs are large, up to 2 MB, so the banks go past
+// what the PRG registers of the usual boards hold.
+
+// Action 52 (mapper 228).
+//
+//	address  [..MC CPPP PPOD DDDD]: mirroring M (1 horizontal), PRG chip
+//	         C, 16 KB page P inside the chip, 16 KB mode O, CHR bank
+//	         bits 2-5 in D
+//	value    [.... ..DD]: CHR bank bits 0-1
+//
+// The chips are 512 KB and Action 52 has chips 0, 1 and 3, so the third
+// one sits right after the second in the image.
+func writeAction52(b *Board, addr uint16, value byte) {
+	chip := int(addr >> 11) & 3
+	if chip == 3 {
+		chip = 2
+	}
+	page := chip*32 + int(addr >> 6) & 0x1F
+	prgMode16(b, page, addr & 0x20 != 0)
+	SetCHR8(b, int(addr & 0x0F) << 2 | int(value & 0x03))
+	mirroringFromA13(b, addr)
+}
+
+// 64-in-1 and similar (mapper 225).
+//
+//	address  [.HMO PPPP PPCC CCCC]: high bank bit H, mirroring M (1
+//	         horizontal), 16 KB mode O, 16 KB page P and 8 KB CHR bank C
+func write225(b *Board, addr uint16) {
+	high := int(addr >> 14) & 1
+	prgMode16(b, high << 6 | int(addr >> 6) & 0x3F, addr & 0x1000 != 0)
+	SetCHR8(b, high << 6 | int(addr & 0x3F))
+	mirroringFromA13(b, addr)
+}
+
+// 76-in-1 and similar (mapper 226), with CHR-RAM.
+//
+//	$8000 even  [PMOP PPPP]: 16 KB page bits 0-4 and 5 (bit 7),
+//	            16 KB mode O, mirroring M (1 vertical)
+//	$8001 odd   [.... ...P]: 16 KB page bit 6
+func write226(b *Board, addr uint16, value byte) {
+	b.Registers[addr & 1] = value
+	r := &b.Registers
+	page := int(r[0] & 0x1F) | int(r[0] & 0x80) >> 2 | int(r[1] & 0x01) << 6
+	prgMode16(b, page, r[0] & 0x20 != 0)
+	if r[0] & 0x40 != 0 {
+		b.Mirroring = MirrorVertical
+	} else {
+		b.Mirroring = MirrorHorizontal
+	}
+}
+
+// 22-in-1 with Contra (mapper 230). After power up the board is an UNROM
+// with the first 128 KB, which boots Contra; each press of reset flips it
+// to the menu of the other 21 games and back.
+//
+//	Contra  [.... .PPP]: 16 KB page at $8000, page 7 fixed at $C000,
+//	        vertical mirroring
+//	menu    [.MOP PPPP]: 16 KB page after the first 128 KB, 16 KB
+//	        mode O, mirroring M (1 vertical)
+func write230(b *Board, value byte) {
+	if b.MenuMode == false {
+		SetPRG16(b, 0, int(value & 0x07))
+		SetPRG16(b, 1, 7)
+		b.Mirroring = MirrorVertical
+		return
+	}
+	prgMode16(b, int(value & 0x1F) + 8, value & 0x20 != 0)
+	if value & 0x40 != 0 {
+		b.Mirroring = MirrorVertical
+	} else {
+		b.Mirroring = MirrorHorizontal
+	}
+}
+
+// Points 16 KB of PRG at $8000 (half 0) or $C000 (half 1).
+func SetPRG16(b *Board, half int, page int) {
+	b.PRG[half*2] = page*2
+	b.PRG[half*2 + 1] = page*2 + 1
+}
+
+// The 16 KB page in both halves, or the 32 KB around it.
+func prgMode16(b *Board, page int, mode16 bool) {
+	if mode16 {
+		SetPRG16(b, 0, page)
+		SetPRG16(b, 1, page)
+	} else {
+		SetPRG16(b, 0, page &^ 1)
+		SetPRG16(b, 1, page | 1)
+	}
+}
+
+func mirroringFromA13(b *Board, addr uint16) {
+	if addr & 0x2000 != 0 {
+		b.Mirroring = MirrorHorizontal
+	} else {
+		b.Mirroring = MirrorVertical
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package mapper
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+
+// Namco 175 and 340 (mapper 210, submappers 1 and 2): the banking of the
+// Namco 163 without its sound, IRQ and nametable control.
+//
+//	$8000-$BFFF  1 KB CHR banks, one register per 2 KB of address space
+//	$C000-$C7FF  PRG-RAM enable on the 175, the RAM is always on here
+//	$E000-$E7FF  8 KB PRG bank at $8000; on the 340 bits 6-7 are the
+//	             mirroring: single low, vertical, single high, horizontal
+//	$E800-$EFFF  8 KB PRG bank at $A000
+//	$F000-$F7FF  8 KB PRG bank at $C000, the last bank is fixed at $E000
+//
+// The 175 has its mirroring wired on the board, so the header's is kept.
+
+var namco340Mirroring = [4]int{MirrorSingleLow, MirrorVertical, MirrorSingleHigh, MirrorHorizontal}
+
+func writeNamco210(b *Board, addr uint16, value byte) {
+	switch {
+		case addr < 0xC000:
+			b.CHR[(addr - 0x8000) >> 11] = int(value)
+		case addr >= 0xE000 && addr < 0xE800:
+			b.PRG[0] = int(value & 0x3F)
+			if b.Submapper == 2 {
+				b.Mirroring = namco340Mirroring[value >> 6]
+			}
+		case addr >= 0xE800 && addr < 0xF000:
+			b.PRG[1] = int(value & 0x3F)
+		case addr >= 0xF000 && addr < 0xF800:
+			b.PRG[2] = int(value & 0x3F)
+	}
+}
+
+// iNES images do not say which chip they have. The 175 games keep their
+// saves in battery backed RAM and the 340 has no RAM at all, so the
+// battery flag tells them apart.
+func guessNamco210(cart *cartridge.Cartridge) int {
+	if cart.Header.RomType.SRAM {
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package mapper
+
+// Tengen RAMBO-1 (mapper 64), an MMC3 with three more bank registers: a
+// third switchable PRG bank (R15), 1 KB CHR banks at $0400 and $0C00 (R8
+// and R9) and an IRQ counter that can count CPU cycles instead of
+// scanlines.
+//
+//	$8000 even  bank select: register in bits 0-3, 1 KB CHR mode (bit 5),
+//	            PRG mode (bit 6), CHR halves swapped (bit 7)
+//	$8001 odd   bank data
+//	$A000 even  mirroring, 0 vertical and 1 horizontal
+//	$C000 even  IRQ latch
+//	$C001 odd   IRQ mode (bit 0, 1 counts CPU cycles) and counter reload
+//	$E000 even  IRQ disable and acknowledge
+//	$E001 odd   IRQ enable
+
+func writeRAMBO1(b *Board, addr uint16, value byte) {
+	odd := addr & 1 == 1
+	switch addr & 0xE000 {
+		case 0x8000:
+			if odd {
+				b.Registers[b.Select & 0x0F] = value
+			} else {
+				b.Select = value
+			}
+			rambo1Banks(b)
+		case 0xA000:
+			if odd == false {
+				if value & 1 == 0 {
+					b.Mirroring = MirrorVertical
+				} else {
+					b.Mirroring = MirrorHorizontal
+				}
+			}
+		case 0xC000:
+			if odd {
+				b.IRQCycles = value & 1 == 1
+				if b.IRQCycles {
+					b.IRQPrescaler = 0
+				}
+				b.IRQReload = true
+			} else {
+				b.IRQLatch = value
+			}
+		case 0xE000:
+			b.IRQEnabled = odd
+			if odd == false {
+				b.IRQ = false
+			}
+	}
+}
+
+func rambo1Banks(b *Board) {
+	r := &b.Registers
+	last := b.PRGCount - 1
+	if b.Select & 0x40 == 0 {
+		b.PRG = [4]int{int(r[6]), int(r[7]), int(r[15]), last}
+	} else {
+		b.PRG = [4]int{int(r[15]), int(r[6]), int(r[7]), last}
+	}
+
+	var chr [8]int
+	if b.Select & 0x20 == 0 {
+		chr = [8]int{int(r[0] &^ 1), int(r[0] | 1), int(r[1] &^ 1), int(r[1] | 1)}
+	} else {
+		chr = [8]int{int(r[0]), int(r[8]), int(r[1]), int(r[9])}
+	}
+	chr[4], chr[5], chr[6], chr[7] = int(r[2]), int(r[3]), int(r[4]), int(r[5])
+	if b.Select & 0x80 != 0 {
+		chr = [8]int{chr[4], chr[5], chr[6], chr[7], chr[0], chr[1], chr[2], chr[3]}
+	}
+	b.CHR = chr
+}
+
+// A clock of the counter. A reload, requested by $C001 or by the counter
+// reaching 0, loads latch+1 and the decrement that follows leaves the
+// latch, so the IRQ comes latch+1 clocks later. Right after a $C001 write
+// the counter is one clock longer, except for latches of 0 and 1; Hard
+// Drivin' needs that.
+func clockRAMBO1(b *Board) bool {
+	if b.IRQReload {
+		b.IRQCounter = int(b.IRQLatch) + 1
+		if b.IRQLatch > 1 {
+			b.IRQCounter++
+		}
+		b.IRQReload = false
+	} else if b.IRQCounter == 0 {
+		b.IRQCounter = int(b.IRQLatch) + 1
+	}
+	b.IRQCounter--
+	if b.IRQCounter == 0 && b.IRQEnabled && b.IRQ == false {
+		b.IRQ = true
+		return true
+	}
+	return false
+}
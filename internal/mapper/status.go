@@ -0,0 +1,125 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package mapper
+
+import "fmt"
+import "io"
+import "strings"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+
+// Readable state of the board, for the debugger and bug reports. Each
+// mapper fills what its board has.
+type Status struct {
+	Board string `json:"board"`
+	PRGBanks []int `json:"prg_banks"` // 8 KB PRG-ROM banks seen at $8000, $A000, $C000 and $E000
+	CHRBanks []int `json:"chr_banks"` // 1 KB CHR banks seen at $0000-$1FFF
+	Mirroring string `json:"mirroring"` // Filled by the nametable mapping
+	IRQ *IRQStatus `json:"irq,omitempty"` // nil on boards without IRQ
+}
+
+type IRQStatus struct {
+	Counter int `json:"counter"`
+	Reload int `json:"reload"`
+	Enabled bool `json:"enabled"`
+	Pending bool `json:"pending"`
+}
+
+func GetStatus(b *Board, cart *cartridge.Cartridge) Status {
+	var s Status
+	switch cart.Header.RomType.Mapper {
+		case 0:
+			s.Board = "NROM"
+		case 64:
+			s.Board = "Tengen RAMBO-1 (mapper 64)"
+			s.IRQ = &IRQStatus{Counter: b.IRQCounter, Reload: int(b.IRQLatch), Enabled: b.IRQEnabled, Pending: b.IRQ}
+		case 68:
+			s.Board = "Sunsoft-4 (mapper 68)"
+			if b.NametableCHR {
+				s.Board += fmt.Sprintf(", nametables from CHR banks %d and %d", b.Nametables[0], b.Nametables[1])
+			}
+		case 210:
+			if b.Submapper == 1 {
+				s.Board = "Namco 175 (mapper 210)"
+			} else {
+				s.Board = "Namco 340 (mapper 210)"
+			}
+		case 225:
+			s.Board = "64-in-1 multicart (mapper 225)"
+		case 226:
+			s.Board = "76-in-1 multicart (mapper 226)"
+		case 228:
+			s.Board = "Action 52 (mapper 228)"
+		case 230:
+			if b.MenuMode {
+				s.Board = "22-in-1 multicart (mapper 230), menu"
+			} else {
+				s.Board = "22-in-1 multicart (mapper 230), Contra"
+			}
+		case 3:
+			s.Board = "CNROM (mapper 3)"
+		case 87:
+			s.Board = "Jaleco/Konami (mapper 87)"
+		case 184:
+			s.Board = "Sunsoft-1 (mapper 184)"
+		case 185:
+			s.Board = "CNROM with CHR protection (mapper 185)"
+			if b.CHRDisabled {
+				s.Board += ", CHR disabled"
+			}
+		default:
+			s.Board = fmt.Sprintf("mapper %d (not supported)", cart.Header.RomType.Mapper)
+			return s
+	}
+	for i := 0; i < 4; i++ {
+		if b.PRGCount > 0 {
+			s.PRGBanks = append(s.PRGBanks, b.PRG[i] % b.PRGCount)
+		}
+	}
+	for i := 0; i < 8; i++ {
+		if b.CHRCount > 0 {
+			s.CHRBanks = append(s.CHRBanks, b.CHR[i] % b.CHRCount)
+		} else {
+			s.CHRBanks = append(s.CHRBanks, i)
+		}
+	}
+	return s
+}
+
+func WriteStatus(w io.Writer, s Status) {
+	fmt.Fprintf(w, "Board:      %s\n", s.Board)
+	fmt.Fprintf(w, "PRG banks:  %s (8 KB at $8000-$FFFF)\n", bankList(s.PRGBanks))
+	fmt.Fprintf(w, "CHR banks:  %s (1 KB at $0000-$1FFF)\n", bankList(s.CHRBanks))
+	fmt.Fprintf(w, "Mirroring:  %s\n", s.Mirroring)
+	if s.IRQ != nil {
+		fmt.Fprintf(w, "IRQ:        counter %d, reload %d, enabled %t, pending %t\n",
+			s.IRQ.Counter, s.IRQ.Reload, s.IRQ.Enabled, s.IRQ.Pending)
+	}
+}
+
+func bankList(banks []int) string {
+	if len(banks) == 0 {
+		return "none"
+	}
+	names := make([]string, len(banks))
+	for i, bank := range banks {
+		names[i] = fmt.Sprint(bank)
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package mapper
+
+// Sunsoft-4 (mapper 68). Besides the usual banks it can replace the
+// nametables with two 1 KB pages of CHR-ROM, which After Burner uses for
+// its background.
+//
+//	$8000-$BFFF  2 KB CHR banks at $0000, $0800, $1000 and $1800, one
+//	             register per 4 KB of address space
+//	$C000, $D000 1 KB CHR-ROM banks of nametable pages 0 and 1, bit 7 is
+//	             always set by the board
+//	$E000        mirroring (bits 0-1: vertical, horizontal, single low,
+//	             single high) and nametables from CHR-ROM (bit 4)
+//	$F000        16 KB PRG bank at $8000, the last one is fixed at $C000
+
+var sunsoft4Mirroring = [4]int{MirrorVertical, MirrorHorizontal, MirrorSingleLow, MirrorSingleHigh}
+
+func writeSunsoft4(b *Board, addr uint16, value byte) {
+	switch addr & 0xF000 {
+		case 0x8000, 0x9000, 0xA000, 0xB000:
+			slot := int(addr - 0x8000) >> 12
+			b.CHR[slot*2] = int(value)*2
+			b.CHR[slot*2 + 1] = int(value)*2 + 1
+		case 0xC000:
+			b.Nametables[0] = int(value | 0x80)
+		case 0xD000:
+			b.Nametables[1] = int(value | 0x80)
+		case 0xE000:
+			b.Mirroring = sunsoft4Mirroring[value & 3]
+			b.NametableCHR = value & 0x10 != 0
+		case 0xF000:
+			bank := int(value & 0x0F)
+			b.PRG[0] = bank*2
+			b.PRG[1] = bank*2 + 1
+	}
+}
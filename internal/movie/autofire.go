@@ -0,0 +1,88 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package movie
+
+import "sort"
+
+// Auto-fire detection. A player cannot press a button faster than once every
+// two frames for long, and never twice within a frame, so input like that
+// comes from a turbo controller or an edit. WriteMovie tags the spans where
+// it happens so runs made with turbo can be told apart.
+
+// Presses in a row, one every two frames, that make a span. At 60 frames per
+// second that is 30 presses per second kept for about a quarter of a second.
+const AutofirePresses = 8
+
+// Frames where a button alternated faster than a player can press it.
+type Autofire struct {
+	Port int
+	Button byte // Bit of the button, see the BUTTON_ constants of ioports
+	Start int // First frame of the span
+	End int // Last frame of the span
+}
+
+func pressed(buttons [2]byte, port int, button byte) bool {
+	return (buttons[port] >> button) & 1 == 1
+}
+
+func DetectAutofire(frames []Frame) []Autofire {
+	var spans []Autofire
+	for port := 0; port < 2; port++ {
+		for b := byte(0); b < 8; b++ {
+			spans = append(spans, alternations(frames, port, b)...)
+			spans = append(spans, subframeAlternations(frames, port, b)...)
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	return spans
+}
+
+// Runs where the button changed on every frame.
+func alternations(frames []Frame, port int, b byte) []Autofire {
+	var spans []Autofire
+	run := 0 // Frames in a row that differ from the previous one
+	for i := 1; i <= len(frames); i++ {
+		if i < len(frames) && pressed(frames[i].Buttons, port, b) != pressed(frames[i-1].Buttons, port, b) {
+			run++
+			continue
+		}
+		if run >= 2*AutofirePresses {
+			spans = append(spans, Autofire{Port: port, Button: b, Start: i - run - 1, End: i - 1})
+		}
+		run = 0
+	}
+	return spans
+}
+
+// Frames whose latches saw the button change more than once.
+func subframeAlternations(frames []Frame, port int, b byte) []Autofire {
+	var spans []Autofire
+	for i, f := range frames {
+		changes := 0
+		for p := 1; p < len(f.Polls); p++ {
+			if pressed(f.Polls[p], port, b) != pressed(f.Polls[p-1], port, b) {
+				changes++
+			}
+		}
+		if changes >= 2 {
+			spans = append(spans, Autofire{Port: port, Button: b, Start: i, End: i})
+		}
+	}
+	return spans
+}
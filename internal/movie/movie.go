@@ -0,0 +1,169 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package movie
+
+import "errors"
+import "io/ioutil"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/snapshot"
+
+// Input movies. A movie holds the input of every frame from a starting
+// point, which is either power on or an embedded savestate.
+
+// Frame flags
+const (
+	FLAG_MICROPHONE byte = 1
+	FLAG_SUBFRAME byte = 2 // Only in movie files, the frame has Polls
+)
+
+// Input applied before a frame runs. Games that read the controllers more
+// than once per frame can see other buttons at each latch, which Polls
+// keeps; it is nil when every latch saw Buttons.
+type Frame struct {
+	Buttons [2]byte // Controllers in port 0 and 1, one bit per button
+	Flags byte
+	Polls [][2]byte // Buttons at each controller latch of the frame
+}
+
+type Movie struct {
+	Hash string // SHA-1 of the cartridge, see cartridge.HashRom
+	Start []byte // Savestate the movie starts from, empty for power on
+	Rerecords int // Times a savestate was loaded while recording
+	LagFrames uint64 // Frames where the game did not read the controllers
+	Latches uint64 // Controller latches made by the game
+	Frames []Frame
+	Autofire []Autofire // Input faster than a player, set by WriteMovie
+}
+
+const movieMagic = "ANMV"
+const movieVersion = 5 // Version 1 has no re-record count, 2 no subframe input, 3 no auto-fire tags, 4 no lag and latch counts
+
+var ErrFormat = errors.New("not an Alphanes movie")
+
+// Encoding of a frame, shared with the session journal. Polls are written
+// apart by the movie, the journal keeps one record per frame.
+func PutFrame(e *snapshot.Encoder, f Frame) {
+	snapshot.PutByte(e, f.Buttons[0])
+	snapshot.PutByte(e, f.Buttons[1])
+	snapshot.PutByte(e, f.Flags)
+}
+
+func GetFrame(d *snapshot.Decoder) Frame {
+	var f Frame
+	f.Buttons[0] = snapshot.Byte(d)
+	f.Buttons[1] = snapshot.Byte(d)
+	f.Flags = snapshot.Byte(d)
+	return f
+}
+
+// Writes the movie with its auto-fire spans, which are found again and
+// left in m.Autofire.
+func WriteMovie(path string, m *Movie) error {
+	m.Autofire = DetectAutofire(m.Frames)
+
+	var e snapshot.Encoder
+	e.Buf = append(e.Buf, movieMagic...)
+	snapshot.PutByte(&e, movieVersion)
+	snapshot.PutString(&e, m.Hash)
+	snapshot.PutBytes(&e, m.Start)
+	snapshot.PutInt(&e, m.Rerecords)
+	snapshot.PutUint64(&e, m.LagFrames)
+	snapshot.PutUint64(&e, m.Latches)
+	snapshot.PutUint64(&e, uint64(len(m.Frames)))
+	for _, f := range m.Frames {
+		if len(f.Polls) == 0 {
+			PutFrame(&e, f)
+			continue
+		}
+		f.Flags |= FLAG_SUBFRAME
+		PutFrame(&e, f)
+		snapshot.PutUint16(&e, uint16(len(f.Polls)))
+		for _, p := range f.Polls {
+			snapshot.PutByte(&e, p[0])
+			snapshot.PutByte(&e, p[1])
+		}
+	}
+	snapshot.PutUint64(&e, uint64(len(m.Autofire)))
+	for _, a := range m.Autofire {
+		snapshot.PutByte(&e, byte(a.Port))
+		snapshot.PutByte(&e, a.Button)
+		snapshot.PutInt(&e, a.Start)
+		snapshot.PutInt(&e, a.End)
+	}
+	return ioutil.WriteFile(path, e.Buf, 0644)
+}
+
+func ReadMovie(path string) (Movie, error) {
+	var m Movie
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	if len(data) < len(movieMagic) + 1 || string(data[:len(movieMagic)]) != movieMagic {
+		return m, ErrFormat
+	}
+	version := data[len(movieMagic)]
+	if version < 1 || version > movieVersion {
+		return m, ErrFormat
+	}
+
+	d := snapshot.StartDecoder(data)
+	d.Pos = len(movieMagic) + 1
+	m.Hash = string(snapshot.Bytes(&d, nil))
+	m.Start = snapshot.Bytes(&d, nil)
+	if version >= 2 {
+		m.Rerecords = snapshot.Int(&d)
+	}
+	if version >= 5 {
+		m.LagFrames = snapshot.Uint64(&d)
+		m.Latches = snapshot.Uint64(&d)
+	}
+	count := snapshot.Uint64(&d)
+	if d.Err == nil && count > uint64(len(data)) {
+		return m, snapshot.ErrShort
+	}
+	m.Frames = make([]Frame, 0, int(count))
+	for i := uint64(0); i < count && d.Err == nil; i++ {
+		f := GetFrame(&d)
+		if version >= 3 && f.Flags & FLAG_SUBFRAME != 0 {
+			f.Flags &^= FLAG_SUBFRAME
+			f.Polls = make([][2]byte, snapshot.Uint16(&d))
+			for p := range f.Polls {
+				f.Polls[p][0] = snapshot.Byte(&d)
+				f.Polls[p][1] = snapshot.Byte(&d)
+			}
+		}
+		m.Frames = append(m.Frames, f)
+	}
+	if version >= 4 {
+		spans := snapshot.Uint64(&d)
+		if d.Err == nil && spans > uint64(len(data)) {
+			return m, snapshot.ErrShort
+		}
+		for i := uint64(0); i < spans && d.Err == nil; i++ {
+			var a Autofire
+			a.Port = int(snapshot.Byte(&d) & 1)
+			a.Button = snapshot.Byte(&d) & 7
+			a.Start = snapshot.Int(&d)
+			a.End = snapshot.Int(&d)
+			m.Autofire = append(m.Autofire, a)
+		}
+	}
+	return m, d.Err
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package perf
+
+import "expvar"
+import "fmt"
+import "net/http"
+import "net/http/pprof"
+
+// Performance counters for the standard Go tooling. The server answers
+// /debug/vars with the counters and the runtime memory and GC statistics
+// (expvar), and /debug/pprof/ with the profiles of net/http/pprof:
+//
+//	go tool pprof http://localhost:6060/debug/pprof/profile
+
+type Counters struct {
+	Frames *expvar.Int
+	Instructions *expvar.Int
+	PPUDots *expvar.Int
+	AudioUnderruns *expvar.Int
+}
+
+// Only one server can be started, expvar names are global.
+func StartServer(addr string) *Counters {
+	c := new(Counters)
+	c.Frames = expvar.NewInt("frames")
+	c.Instructions = expvar.NewInt("instructions")
+	c.PPUDots = expvar.NewInt("ppu_dots")
+	c.AudioUnderruns = expvar.NewInt("audio_underruns")
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	go func() {
+		err := http.ListenAndServe(addr, mux)
+		fmt.Println("Profiling server stopped: ", err)
+	}()
+	fmt.Println("Profiling server on http://" + addr + "/debug/pprof/")
+	return c
+}
+
+// Publishes the totals after a frame.
+func Publish(c *Counters, frames int, instructions uint64, dots uint64, underruns int) {
+	c.Frames.Set(int64(frames))
+	c.Instructions.Set(int64(instructions))
+	c.PPUDots.Set(int64(dots))
+	c.AudioUnderruns.Set(int64(underruns))
+}
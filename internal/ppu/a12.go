@@ -0,0 +1,110 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ppu
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+
+// PPU address line A12 during the sprite fetches, dots 257-340, for the
+// boards that count scanlines by its rising edges. Each of the 8 sprite
+// slots takes 8 dots: two garbage nametable fetches, with A12 low, then
+// the two pattern bytes. Empty slots still fetch, tile $FF, so the edge
+// comes even on lines without sprites. The background prefetch of dots
+// 321-336 follows. The boards ignore a rise unless A12 was low for a few
+// CPU cycles before, so the short lows between fetches never count.
+
+const A12_FILTER_DOTS = 10 // About ioports.A12_FILTER_CYCLES
+
+// Finds, at dot 257, the dots of the line where the board will see A12
+// rise. The sprites fetched are the ones of the next line.
+func findA12Rises(ppu *PPU) {
+	ppu.A12_RISES = [2]int{-1, -1}
+	if mapper.CountsScanlines(&ppu.IO.BOARD) == false {
+		return
+	}
+	tables := spriteFetchTables(ppu)
+	background := ppu.IO.PPUCTRL.BACKGROUND_ADDR & 0x1000 != 0
+
+	// The last background fetches of dots 253-256 left A12 where they put it
+	low := 0
+	if background == false {
+		low = A12_FILTER_DOTS
+	}
+	found := 0
+	for dot := 257; dot <= 340; dot++ {
+		var high bool
+		switch {
+			case dot <= 320:
+				high = (dot - 257) % 8 >= 4 && tables[(dot - 257) / 8]
+			case dot <= 336:
+				high = (dot - 321) % 8 >= 4 && background
+		}
+		if high == false {
+			low++
+			continue
+		}
+		if low >= A12_FILTER_DOTS && found < len(ppu.A12_RISES) {
+			ppu.A12_RISES[found] = dot
+			found++
+		}
+		low = 0
+	}
+}
+
+// Pattern table, true for $1000, of each sprite slot fetched on this line.
+// 8x16 sprites take it from bit 0 of the tile, and the empty slots fetch
+// tile $FF. The pre-render line has no evaluation and fetches empty slots.
+func spriteFetchTables(ppu *PPU) [8]bool {
+	var tables [8]bool
+	if ppu.IO.PPUCTRL.SPRITE_SIZE != 16 {
+		for i := range tables {
+			tables[i] = ppu.IO.PPUCTRL.SPRITE_8_ADDR & 0x1000 != 0
+		}
+		return tables
+	}
+	for i := range tables {
+		tables[i] = true
+	}
+	if ppu.SCANLINE >= 240 {
+		return tables
+	}
+	n := 0
+	for s := 0; s < len(ppu.IO.PPU_OAM) && n < 8; s += 4 {
+		row := ppu.SCANLINE - int(ppu.IO.PPU_OAM[s])
+		if row >= 0 && row < 16 {
+			tables[n] = ppu.IO.PPU_OAM[s + 1] & 1 == 1
+			n++
+		}
+	}
+	return tables
+}
+
+// Called every dot, clocks the board on the rises found at dot 257.
+func checkA12(ppu *PPU) {
+	if ppu.CYC == 257 && (ppu.SCANLINE < 240 || ppu.SCANLINE == ppu.PRERENDER_LINE) {
+		if ppu.IO.PPUMASK.SHOW_BACKGROUND || ppu.IO.PPUMASK.SHOW_SPRITE {
+			findA12Rises(ppu)
+		} else {
+			ppu.A12_RISES = [2]int{-1, -1}
+		}
+	}
+	if ppu.CYC == ppu.A12_RISES[0] || ppu.CYC == ppu.A12_RISES[1] {
+		ioports.ClockMapperScanline(ppu.IO)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ppu
+
+// Sprite evaluation of the finished frame, with the OAM as it is at the
+// end of the frame. Like the PPU, the first 8 sprites of each line are
+// copied to the secondary OAM and the rest only counted.
+func countSpritesPerScanline(ppu *PPU) {
+	var size int = int(ppu.IO.PPUCTRL.SPRITE_SIZE)
+	if size == 0 {
+		size = 8
+	}
+	a := &ppu.IO.ACTIVITY
+	for y := range a.SPRITES_PER_SCANLINE {
+		a.SPRITES_PER_SCANLINE[y] = 0
+		for i := range a.SECONDARY_OAM[y] {
+			a.SECONDARY_OAM[y][i] = 0xFF
+		}
+	}
+	for s := 0; s < len(ppu.IO.PPU_OAM); s += 4 {
+		// Sprites are displayed one line below their OAM position
+		top := int(ppu.IO.PPU_OAM[s]) + 1
+		for y := top; y < top+size && y < 240; y++ {
+			n := a.SPRITES_PER_SCANLINE[y]
+			if n < 8 {
+				copy(a.SECONDARY_OAM[y][n*4:n*4+4], ppu.IO.PPU_OAM[s:s+4])
+				a.SECONDARY_SLOTS[y][n] = s / 4
+			}
+			a.SPRITES_PER_SCANLINE[y]++
+		}
+	}
+}
@@ -18,6 +18,9 @@ This file is part of Alphanes.
 */
 package ppu
 
+import "fmt"
+import "io/ioutil"
+
 
 func rgb() [][]byte {
 
@@ -87,3 +90,37 @@ func rgb() [][]byte {
 			{0,0,0}}
 	return color
 }
+
+// Replaces the system palette by a 64 colors (192 bytes) .pal file.
+func LoadPalette(filename string) bool {
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil || len(content) < 192 {
+		fmt.Printf("Cannot load the palette file: %s\n", filename)
+		return false
+	}
+
+	for i := 0; i < 64; i++ {
+		colors[i] = []byte{content[i*3], content[(i*3)+1], content[(i*3)+2]}
+	}
+	paletteFile = true
+	return true
+}
+
+// Picks the palette of the console video: the 2C07 one for PAL, the 2C02
+// one otherwise. A loaded .pal file is kept.
+func SetPALColors(pal bool) {
+	if paletteFile {
+		return
+	}
+	if pal {
+		colors = palRGB()
+	} else {
+		colors = rgb()
+	}
+}
+
+func PaletteColor(index int) (byte, byte, byte) {
+	c := colors[index & 0x3F]
+	return c[0], c[1], c[2]
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ppu
+
+// Scanline compositor. The background and the sprites of each line are
+// drawn into separate line buffers and merged in a single pass that applies
+// transparency, sprite priority and the left column clipping. The frame is
+// drawn at the start of vertical blank with the registers as they are then,
+// so there is no mid-scanline path yet.
+//
+// Drawing works on a snapshot of the PPU memory and registers taken at that
+// point (a renderJob), so it can also run on a worker goroutine while the
+// CPU emulates the next frame. The threaded mode shows each frame one frame
+// later. Sprite 0 hits are detected by the emulation itself and never wait
+// for the renderer.
+
+// Sprite pixel flags
+const (
+	spriteOpaque byte = 1
+	spriteBehind byte = 2 // Priority bit of the attributes: behind the background
+)
+
+var ThreadedRender bool = false
+var SpriteLimit bool = false // Draw at most 8 sprites per scanline, like the console
+
+type lineBuffers struct {
+	bgColor [256]int
+	bgSource [256]int
+	spriteColor [256]int
+	spriteSlot [256]int
+	spriteFlags [256]byte
+	slots []int // Sprites found on the line, by OAM slot
+}
+
+type renderJob struct {
+	BackgroundAddr uint16
+	SpriteAddr uint16
+	NametableAddr uint16
+	ShowBackground bool
+	ShowSprites bool
+	ShowLeftBackground bool
+	ShowLeftSprites bool
+	SpriteLimit bool
+	Backdrop int
+
+	Pattern [0x2000]byte
+	Nametables [0x1000]byte
+	Palette [0x20]byte
+	OAM []byte // 64 sprites, more with the OAM extension
+
+	// Output, in the layout of SCREEN_DATA, SPRITE_LAYER and SOURCE_LAYER
+	Screen []int
+	Sprites []int
+	Sources []int
+
+	lines lineBuffers
+}
+
+var job renderJob
+var renderJobs chan *renderJob
+var renderDone chan *renderJob
+var renderBusy bool = false
+
+func renderFrame(ppu *PPU) {
+
+	if ThreadedRender == false {
+		job.Screen = ppu.SCREEN_DATA
+		job.Sprites = ppu.SPRITE_LAYER
+		job.Sources = ppu.SOURCE_LAYER
+		snapshotFrame(ppu, &job)
+		rasterize(&job)
+		return
+	}
+
+	if renderJobs == nil {
+		renderJobs = make(chan *renderJob)
+		renderDone = make(chan *renderJob)
+		go renderWorker()
+	}
+	if job.Screen == nil {
+		job.Screen = make([]int, len(ppu.SCREEN_DATA))
+		job.Sprites = make([]int, len(ppu.SPRITE_LAYER))
+		job.Sources = make([]int, len(ppu.SOURCE_LAYER))
+	}
+
+	// Picks up the previous frame and hands the buffers it replaces to the
+	// next job
+	if renderBusy {
+		<-renderDone
+		ppu.SCREEN_DATA, job.Screen = job.Screen, ppu.SCREEN_DATA
+		ppu.SPRITE_LAYER, job.Sprites = job.Sprites, ppu.SPRITE_LAYER
+		ppu.SOURCE_LAYER, job.Sources = job.Sources, ppu.SOURCE_LAYER
+	}
+	snapshotFrame(ppu, &job)
+	renderJobs <- &job
+	renderBusy = true
+}
+
+// Turns the threaded drawing on or off while running. A frame still being
+// drawn on the other core is waited for and dropped.
+func SetThreadedRender(on bool) {
+	if renderBusy {
+		<-renderDone
+		renderBusy = false
+	}
+	if on && ThreadedRender == false {
+		// Without threads the job draws into the PPU buffers, it gets its own again
+		job.Screen, job.Sprites, job.Sources = nil, nil, nil
+	}
+	ThreadedRender = on
+}
+
+func renderWorker() {
+	for j := range renderJobs {
+		rasterize(j)
+		renderDone <- j
+	}
+}
+
+func snapshotFrame(ppu *PPU, j *renderJob) {
+	j.BackgroundAddr = ppu.IO.PPUCTRL.BACKGROUND_ADDR
+	j.SpriteAddr = ppu.IO.PPUCTRL.SPRITE_8_ADDR
+	j.NametableAddr = ppu.IO.PPUCTRL.BASE_NAMETABLE_ADDR
+	j.ShowBackground = ppu.IO.PPUMASK.SHOW_BACKGROUND
+	j.ShowSprites = ppu.IO.PPUMASK.SHOW_SPRITE
+	j.ShowLeftBackground = ppu.IO.PPUMASK.SHOW_LEFTMOST_8_BACKGROUND
+	j.ShowLeftSprites = ppu.IO.PPUMASK.SHOW_LEFTMOST_8_SPRITE
+	j.SpriteLimit = SpriteLimit
+	j.Backdrop = int(ppu.IO.PPU_RAM[0x3F00])
+
+	for i := range j.Pattern {
+		j.Pattern[i] = ReadPPURam(ppu, uint16(i))
+	}
+	for i := range j.Nametables {
+		j.Nametables[i] = ReadPPURam(ppu, uint16(0x2000 + i))
+	}
+	for i := range j.Palette {
+		j.Palette[i] = ReadPPURam(ppu, uint16(0x3F00 + i))
+	}
+	j.OAM = append(j.OAM[:0], ppu.IO.PPU_OAM...)
+}
+
+func rasterize(j *renderJob) {
+
+	// Attribute table of the selected nametable, as attrTable reads it
+	var attrpal [8][8]byte
+	base := int(j.NametableAddr - 0x2000)
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			attrpal[x][y] = j.Nametables[base + 0x3C0 + x + (y*8)]
+		}
+	}
+
+	for y := 0; y < 240; y++ {
+		renderBackgroundLine(j, y, &attrpal)
+		renderSpriteLine(j, y)
+		composeLine(j, y)
+	}
+}
+
+func renderBackgroundLine(j *renderJob, y int, attrpal *[8][8]byte) {
+
+	l := &j.lines
+	if j.ShowBackground == false {
+		for x := 0; x < 256; x++ {
+			l.bgColor[x] = j.Backdrop
+			l.bgSource[x] = SOURCE_BACKDROP
+		}
+		return
+	}
+
+	ty := y / 8
+	base := int(j.NametableAddr - 0x2000)
+	for tx := 0; tx < 32; tx++ {
+		index := j.Nametables[base + tx + (ty*32)]
+		addr := int(j.BackgroundAddr) + int(index)*16 + (y % 8)
+		la := &planeDecode[j.Pattern[addr]]
+		lb := &planeDecode[j.Pattern[addr + 8]]
+
+		pal := palForBackground(*attrpal, uint16(tx/2), uint16(ty/2))
+		var tilecolors [4]int
+		tilecolors[0] = j.Backdrop
+		for c := 1; c < 4; c++ {
+			tilecolors[c] = int(j.Palette[c + (int(pal)*4) + 1])
+		}
+
+		px := tx * 8
+		for kx := 0; kx < 8; kx++ {
+			v := la[kx] + lb[kx]
+			l.bgColor[px + kx] = tilecolors[v]
+			if v == 0 {
+				l.bgSource[px + kx] = SOURCE_BACKDROP
+			} else {
+				l.bgSource[px + kx] = SOURCE_BACKGROUND + int(pal)
+			}
+		}
+	}
+}
+
+// Sprites are drawn from the last OAM slot to the first, so the lower
+// slot ends up in front when two of them overlap. With the sprite limit
+// only the first 8 slots found on the line are drawn.
+func renderSpriteLine(j *renderJob, y int) {
+
+	l := &j.lines
+	for x := 0; x < 256; x++ {
+		l.spriteFlags[x] = 0
+	}
+	if j.ShowSprites == false {
+		return
+	}
+
+	sprites := len(j.OAM) / 4
+	if len(l.slots) < sprites {
+		l.slots = make([]int, sprites)
+	}
+	slots := l.slots
+	count := 0
+	for slot := 0; slot < sprites; slot++ {
+		row := y - int(j.OAM[slot*4])
+		if row < 0 || row >= 8 {
+			continue
+		}
+		if j.SpriteLimit && count == 8 {
+			break
+		}
+		slots[count] = slot
+		count++
+	}
+
+	for i := count - 1; i >= 0; i-- {
+		slot := slots[i]
+		s := slot * 4
+		row := y - int(j.OAM[s])
+		index := j.OAM[s+1]
+		attr := j.OAM[s+2]
+		x0 := int(j.OAM[s+3])
+
+		if attr & 0x80 != 0 {
+			row = 7 - row
+		}
+		addr := int(j.SpriteAddr) + int(index)*16 + row
+		la := &planeDecode[j.Pattern[addr]]
+		lb := &planeDecode[j.Pattern[addr + 8]]
+
+		colorbase := 0x10 + int(attr & 0x03)*4 + 1
+		var flags byte = spriteOpaque
+		if attr & 0x20 != 0 {
+			flags |= spriteBehind
+		}
+
+		for kx := 0; kx < 8; kx++ {
+			v := la[kx] + lb[kx]
+			x := x0 + kx
+			if attr & 0x40 != 0 {
+				x = x0 + 7 - kx
+			}
+			if v == 0 || x >= 256 {
+				continue
+			}
+			l.spriteColor[x] = int(j.Palette[colorbase + int(v)])
+			l.spriteSlot[x] = slot
+			l.spriteFlags[x] = flags
+		}
+	}
+}
+
+func composeLine(j *renderJob, y int) {
+
+	l := &j.lines
+	line := y * 256
+
+	for x := 0; x < 256; x++ {
+		color := l.bgColor[x]
+		source := l.bgSource[x]
+		if x < 8 && j.ShowLeftBackground == false {
+			color = j.Backdrop
+			source = SOURCE_BACKDROP
+		}
+
+		flags := l.spriteFlags[x]
+		if x < 8 && j.ShowLeftSprites == false {
+			flags = 0
+		}
+
+		sprite := -1
+		if flags & spriteOpaque != 0 && (flags & spriteBehind == 0 || source == SOURCE_BACKDROP) {
+			color = l.spriteColor[x]
+			source = SOURCE_SPRITE + l.spriteSlot[x]
+			sprite = color
+		}
+
+		j.Screen[line + x] = color
+		j.Sprites[line + x] = sprite
+		j.Sources[line + x] = source
+	}
+}
@@ -0,0 +1,33 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ppu
+
+// Pixels of a pattern table plane byte, leftmost first, so a tile row is
+// decoded with two table lookups instead of a shift per pixel.
+var planeDecode = buildPlaneDecode()
+
+func buildPlaneDecode() [256][8]byte {
+	var table [256][8]byte
+	for value := 0; value < 256; value++ {
+		for x := 0; x < 8; x++ {
+			table[value][x] = ReadBit(byte(value), byte(x))
+		}
+	}
+	return table
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ppu
+
+import "math"
+
+// The 2C07 of PAL consoles draws its colors with another phase than the
+// NTSC 2C02, and PAL sets have no hue knob to hide it. Instead of bundling
+// a capture its palette is generated from the composite signal of the PPU.
+
+// Voltages of the four luminance levels, low and high half of the color
+// wave, and the black and white levels.
+var signalLow = [4]float64{0.228, 0.312, 0.552, 0.880}
+var signalHigh = [4]float64{0.616, 0.840, 1.100, 1.100}
+
+const signalBlack = 0.312
+const signalWhite = 1.100
+
+// Degrees the 2C07 color wave is late against the 2C02.
+const palHueShift = 15
+
+// Decodes the 64 colors of a PPU whose color wave starts at hue degrees.
+// A hue of 5 gives back, closely, the NTSC palette of rgb.
+func compositePalette(hue float64) [][]byte {
+
+	colors := make([][]byte, 64)
+	for index := range colors {
+		color, level := index & 0x0F, index >> 4
+		var y, u, v float64
+		// Averages the signal over the 12 phases of a color cycle
+		for phase := 0; phase < 12; phase++ {
+			signal := signalLow[level]
+			switch {
+				case color == 0:
+					signal = signalHigh[level]
+				case color >= 0x0E:
+					signal = signalBlack
+				case color < 0x0D && (color + phase) % 12 < 6:
+					signal = signalHigh[level]
+			}
+			signal = (signal - signalBlack) / (signalWhite - signalBlack)
+			angle := (hue - float64(phase * 30)) * math.Pi / 180
+			y += signal
+			u += signal * math.Cos(angle)
+			v += signal * math.Sin(angle)
+		}
+		y, u, v = y / 12, u / 6, v / 6
+		colors[index] = []byte{
+			signalByte(y + 1.140*v),
+			signalByte(y - 0.395*u - 0.581*v),
+			signalByte(y + 2.032*u)}
+	}
+	return colors
+}
+
+func signalByte(x float64) byte {
+	return byte(math.Round(math.Max(0, math.Min(1, x)) * 255))
+}
+
+func palRGB() [][]byte {
+	return compositePalette(5 - palHueShift)
+}
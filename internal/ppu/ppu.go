@@ -0,0 +1,350 @@
+/*
+Copyright 2014, 2014 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ppu
+
+import "fmt"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "os"
+import "os/exec"
+
+var tx uint16 = 0
+var ty uint16 = 0
+
+
+type PPU struct {
+
+	SCREEN_DATA []int
+	SPRITE_LAYER []int // Sprite pixels of the current frame, -1 where there is none
+	PREVIOUS_SPRITE_LAYER []int
+	SOURCE_LAYER []int // What drew each pixel, see SOURCE_BACKDROP
+	SCROLL_LINES [240]SCROLL_LINE // Scroll address at the start of each visible scanline
+	
+	Name string
+	CYC int		
+	SCANLINE int
+	VBLANK_LINE int // Scanline the vertical blank starts on, 241 (291 on Dendy)
+	PRERENDER_LINE int // Last scanline of the frame, 261 (311 on PAL and Dendy)
+        D *debug.PPUDebug
+	
+	
+	
+	
+	
+	ATTR byte
+	HIGH_TILE byte
+	LOW_TILE byte
+	
+	
+	VISIBLE_SCANLINE bool
+	A12_RISES [2]int // Dots of the line where A12 rises for the board, -1 for none
+	
+	
+	IO *ioports.IOPorts
+	
+	
+}
+
+// Shows the frames and reads the host input. The PPU calls it at the start
+// of every vertical blank, once the frame is drawn.
+type Frontend interface {
+	VBlank(ppu *PPU)
+}
+
+var Display Frontend // nil runs the console headless
+var colors = rgb()
+var paletteFile bool = false // A .pal file replaced the console palette
+
+func StartPPU(IO *ioports.IOPorts) PPU {
+	var ppu PPU
+	ppu.Name = "RICOH RP-2C02\n"
+	fmt.Printf("Started PPU")
+	fmt.Printf(ppu.Name)
+	
+	
+
+
+	
+
+
+
+	
+	
+	ppu.CYC = 0
+	ppu.SCANLINE = 241
+	ppu.VBLANK_LINE = 241
+	ppu.A12_RISES = [2]int{-1, -1}
+	ppu.PRERENDER_LINE = 261
+	ppu.IO = IO
+	
+	ppu.SCREEN_DATA = make([]int, 61441)
+	ppu.SPRITE_LAYER = make([]int, 256*240)
+	ppu.PREVIOUS_SPRITE_LAYER = make([]int, 256*240)
+	ppu.SOURCE_LAYER = make([]int, 256*240)
+	clearSpriteLayer(ppu.SPRITE_LAYER)
+	clearSpriteLayer(ppu.PREVIOUS_SPRITE_LAYER)
+		
+	return ppu
+}
+
+func checkVisibleScanline(ppu *PPU) {
+
+	if ppu.SCANLINE >= 0 || ppu.SCANLINE < 240 {
+		ppu.VISIBLE_SCANLINE = true
+	} else {
+		ppu.VISIBLE_SCANLINE = false
+	}
+
+}
+
+
+
+func Process(ppu *PPU, cart *cartridge.Cartridge) {
+
+
+
+	checkVisibleScanline(ppu)
+	
+	if (ppu.VISIBLE_SCANLINE) {
+	
+		var x uint16 = uint16(ppu.CYC%256)
+		var y uint16 = uint16(ppu.SCANLINE%240)	
+		checkSprite0Bit(ppu, x, y)
+	}
+	
+
+	
+	if (ppu.SCANLINE < 0) && (ppu.CYC <= 0) {
+		ppu.SCANLINE = 0
+		ppu.CYC = 0
+		scanlineScroll(ppu)
+		ppu.IO.MIRRORING_CHANGES = ppu.IO.MIRRORING_CHANGES[:0]
+		return
+	}
+	
+	if ppu.CYC >= 0 && ppu.CYC < 256 && ppu.VISIBLE_SCANLINE {
+	}
+
+	if ppu.CYC == 0 && ppu.VISIBLE_SCANLINE && (ppu.IO.PPUMASK.SHOW_BACKGROUND || ppu.IO.PPUMASK.SHOW_SPRITE) {
+		ioports.RefreshOAM(ppu.IO)
+	}
+
+	checkA12(ppu)
+	
+	
+	
+
+
+	
+		if ppu.IO.PPUSTATUS.NMI_OCCURRED == true && ppu.IO.PPUCTRL.GEN_NMI == true {
+		    ioports.SetNMI(ppu.IO)
+                    ppu.IO.PPUSTATUS.NMI_OCCURRED = false
+		}
+					
+	ppu.CYC = ppu.CYC + 1
+	if ppu.CYC > 341 {
+		
+		ppu.CYC = 0
+		ppu.SCANLINE = ppu.SCANLINE + 1
+		scanlineScroll(ppu)
+		
+		
+		if ppu.SCANLINE == ppu.VBLANK_LINE && ppu.CYC == 0 {
+			SetVBLANK(ppu)
+			ioports.CountFrame(ppu.IO)
+			countSpritesPerScanline(ppu)
+		        renderFrame(ppu)
+			if Display != nil {
+				Display.VBlank(ppu)
+			}
+			keepSpriteLayer(ppu)
+		}
+		
+		if ppu.SCANLINE == ppu.PRERENDER_LINE {
+			ClearVBLANK(ppu)
+		}
+		
+		if ppu.SCANLINE > ppu.PRERENDER_LINE {			
+			ppu.SCANLINE = -1
+		}
+		
+				
+	}
+
+	ppu.IO.CLOCK.SCANLINE = ppu.SCANLINE
+	ppu.IO.CLOCK.DOT = ppu.CYC
+	ppu.IO.FETCH_LINE = ppu.SCANLINE < 240 || ppu.SCANLINE == ppu.PRERENDER_LINE
+}
+	
+	func SetVBLANK(ppu *PPU) {
+		ppu.IO.PPUSTATUS.VBLANK = true
+		ppu.IO.PPUSTATUS.NMI_OCCURRED = true
+	}
+
+	
+func ClearVBLANK(ppu *PPU) {
+		ppu.IO.PPUSTATUS.VBLANK = false
+		ppu.IO.PPUSTATUS.NMI_OCCURRED = false
+	}
+	
+
+
+
+
+func attrTable(ppu *PPU) [8][8]byte {
+    var result [8][8]byte
+    
+    for x := 0; x < 8; x++ {
+        for y := 0; y < 8; y++ {
+	    var addr = ppu.IO.PPUCTRL.BASE_NAMETABLE_ADDR + 0x3C0
+            addr = addr + uint16(x + (y*8))
+        result[x][y] = ReadPPURam(ppu, addr)
+        }
+    }
+    return result
+}
+
+func palForBackground(attr [8][8]byte, x uint16, y uint16) byte {
+
+    grid := attr[x/8][y/8]
+
+    bottomright := (grid >> 6)
+    bottomleft := (grid << 2) >> 6
+    topright := (grid << 4) >> 6
+    topleft := (grid << 6) >> 6
+
+    if (x%8 >= 4) && (y%8 < 4) { return topright }
+    if (x%8 < 4) && (y%8 >= 4) { return bottomleft }
+    if (x%8 < 4) && (y%8 < 4) { return topleft }
+    return bottomright
+}
+
+
+
+func FetchTile(ppu *PPU, index byte, base_addr uint16) [8][8]byte {
+
+
+	var result [8][8]byte
+	
+		
+
+	
+	for y := 0; y < 8; y++ {
+	
+	var addr uint16 = base_addr + uint16( uint16(index) * 16)
+
+	tile_addr := addr + uint16(y)
+	tile_addr_b :=  tile_addr+8
+			
+			
+			
+			
+			var a byte = ReadPPURam(ppu,  tile_addr)
+			var b byte = ReadPPURam(ppu, tile_addr_b )
+			
+			la := &planeDecode[a]
+			lb := &planeDecode[b]
+			for x := 0; x < 8; x++ {
+                                result[x][y] = la[x] + lb[x]
+		    }
+	}
+	
+	return result
+}
+
+func fetchNametable(ppu *PPU, x uint16, y uint16) byte {
+
+ 
+	absolute_addr := ppu.IO.PPUCTRL.BASE_NAMETABLE_ADDR + (x+ (y*32)  )
+	return  ReadPPURam(ppu, absolute_addr)
+	
+}
+
+
+
+
+func READ_SCREEN(ppu *PPU, x int, y int) int {
+	return ppu.SCREEN_DATA[x +(y*256) ]
+}
+
+func WRITE_SCREEN(ppu *PPU, x int, y int, k int) {
+	if x >= 256 || y >= 240 {
+		return
+	}
+	ppu.SCREEN_DATA[x + (y*256) ] = k
+}
+
+// The sprites of the finished frame stay around for the flicker fusion of
+// the next one.
+func keepSpriteLayer(ppu *PPU) {
+	ppu.SPRITE_LAYER, ppu.PREVIOUS_SPRITE_LAYER = ppu.PREVIOUS_SPRITE_LAYER, ppu.SPRITE_LAYER
+	clearSpriteLayer(ppu.SPRITE_LAYER)
+}
+
+func clearSpriteLayer(layer []int) {
+	for i := range layer {
+		layer[i] = -1
+	}
+}
+
+func printNametable(ppu *PPU) {
+
+	c := exec.Command("clear")
+	c.Stdout = os.Stdout
+	c.Run()
+
+	for x:= 0; x < 32; x++ {
+		for y:= 0; y < 32; y++ {
+		}
+	}
+
+}
+
+func checkSprite0Bit(ppu *PPU, x uint16, y uint16) {
+
+if(ppu.IO.PPUSTATUS.SPRITE_0_BIT == true) { return }
+
+	pos_y := uint16( ppu.IO.PPU_OAM[0])
+	pos_x := uint16( ppu.IO.PPU_OAM[3] )
+	ind := ppu.IO.PPU_OAM[1]
+	
+	matchVertical := pos_y >= y && (pos_y+8) <= y
+	matchHorizontal := pos_x >= y && (pos_x+8) <= x
+	
+	
+	if matchVertical == false || matchHorizontal == false { return }
+	fmt.Printf("match!\n")
+	
+	deltaX := pos_x - x
+	deltaY := pos_y - y
+	
+	sprite_tile := FetchTile(ppu, ind,  ppu.IO.PPUCTRL.SPRITE_8_ADDR )
+	fetchNametable(ppu, x/8, y/8)
+	bg_tile := FetchTile(ppu, ind,  ppu.IO.PPUCTRL.BACKGROUND_ADDR )
+	
+	if sprite_tile[deltaX][deltaY] != 0 && bg_tile[x%8][y%8] != 0 {
+		ppu.IO.PPUSTATUS.SPRITE_0_BIT = true
+		fmt.Printf("Sprite zero!\n")
+	}
+	
+	
+	
+
+}
@@ -18,9 +18,8 @@ This file is part of Alphanes.
 */
 package ppu
 
-//import "zerojnt/cartridge"
-import "zerojnt/mapper"
-//import "zerojnt/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
 
 func ReadPPURam(ppu *PPU, addr uint16) byte {
 
@@ -28,18 +27,22 @@ func ReadPPURam(ppu *PPU, addr uint16) byte {
     newaddr := mapper.PPU(ppu.IO.CART, addr)
 
     if ppu.D.Enable {
-        if newaddr < uint16(len(ppu.D.DUMP)) { return ppu.D.DUMP[addr] }
+        if int(newaddr) < len(ppu.D.DUMP) { return ppu.D.DUMP[newaddr] }
     }
 
 
-    var page8bits uint16 = 8192
-    var size uint16 = uint16(ppu.IO.CART.Header.VROM_SIZE)*page8bits
+    var page8bits int = 8192
+    var size int = int(ppu.IO.CART.Header.VROM_SIZE)*page8bits
 	    
-    if newaddr < size {
-        return ppu.IO.CART.CHR[newaddr]
+    if int(newaddr) < size {
+        return mapper.ReadCHR(&ppu.IO.BOARD, ppu.IO.CART, newaddr)
     }
 
 
+    if ioports.IsNametable(newaddr) {
+        return ioports.ReadNametable(ppu.IO, newaddr)
+    }
+
     return ppu.IO.PPU_RAM[newaddr]
 
     
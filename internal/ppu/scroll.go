@@ -0,0 +1,52 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ppu
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+
+// Scroll address in use at the start of a visible scanline.
+type SCROLL_LINE struct {
+	V uint16
+	FINE_X byte
+}
+
+// Runs the scroll register updates that happen once per scanline and
+// records the address each visible line starts with.
+func scanlineScroll(ppu *PPU) {
+	if ppu.SCANLINE == ppu.PRERENDER_LINE {
+		ioports.ReloadScroll(ppu.IO)
+		return
+	}
+	if ppu.SCANLINE < 0 || ppu.SCANLINE >= 240 {
+		return
+	}
+	if ppu.SCANLINE > 0 {
+		ioports.ReloadScrollX(ppu.IO)
+	}
+	ppu.SCROLL_LINES[ppu.SCANLINE] = SCROLL_LINE{ppu.IO.SCROLL_V, ppu.IO.FINE_X}
+	ioports.IncrementScrollY(ppu.IO)
+}
+
+// Position of the top left pixel of a scanline inside the 512x480 plane
+// formed by the four nametables.
+func ScrollPosition(line SCROLL_LINE) (int, int) {
+	x := int(line.V & 0x1F)*8 + int(line.FINE_X) + int((line.V >> 10) & 1)*256
+	y := int((line.V >> 5) & 0x1F)*8 + int((line.V >> 12) & 7) + int((line.V >> 11) & 1)*240
+	return x, y
+}
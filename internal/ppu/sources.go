@@ -0,0 +1,28 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ppu
+
+// What drew each pixel of SOURCE_LAYER, for the source view of the
+// frontend.
+
+const (
+	SOURCE_BACKDROP int = 0
+	SOURCE_BACKGROUND int = 1 // + background palette 0-3
+	SOURCE_SPRITE int = 16 // + OAM slot 0-63, up to 511 with the OAM extension
+)
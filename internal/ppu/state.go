@@ -0,0 +1,47 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package ppu
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/snapshot"
+
+// Savestate encoding of the PPU timing. The picture is redrawn from the
+// memory on the next frame, so the output layers are not saved.
+func EncodeState(ppu *PPU, e *snapshot.Encoder) {
+	snapshot.PutInt(e, ppu.CYC)
+	snapshot.PutInt(e, ppu.SCANLINE)
+	snapshot.PutByte(e, ppu.ATTR)
+	snapshot.PutByte(e, ppu.HIGH_TILE)
+	snapshot.PutByte(e, ppu.LOW_TILE)
+	snapshot.PutBool(e, ppu.VISIBLE_SCANLINE)
+	for _, dot := range ppu.A12_RISES {
+		snapshot.PutInt(e, dot)
+	}
+}
+
+func DecodeState(ppu *PPU, d *snapshot.Decoder) {
+	ppu.CYC = snapshot.Int(d)
+	ppu.SCANLINE = snapshot.Int(d)
+	ppu.ATTR = snapshot.Byte(d)
+	ppu.HIGH_TILE = snapshot.Byte(d)
+	ppu.LOW_TILE = snapshot.Byte(d)
+	ppu.VISIBLE_SCANLINE = snapshot.Bool(d)
+	for i := range ppu.A12_RISES {
+		ppu.A12_RISES[i] = snapshot.Int(d)
+	}
+}
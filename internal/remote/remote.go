@@ -0,0 +1,220 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package remote
+
+import "encoding/json"
+import "errors"
+import "fmt"
+import "image"
+import "image/color"
+import "image/png"
+import "net/http"
+import "strconv"
+import "strings"
+import "sync"
+import "time"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/timing"
+
+// Embedded HTTP server for status and remote control. The handlers run on
+// their own goroutines: they read a snapshot published by the frontend
+// after each frame and send commands that the frontend runs between frames.
+
+type Status struct {
+	Rom string `json:"rom"`
+	Hash string `json:"hash"`
+	FPS float64 `json:"fps"`
+	Frame int `json:"frame"`
+	Paused bool `json:"paused"`
+	Hardcore bool `json:"hardcore"` // Savestates cannot be loaded
+	Timing timing.Stats `json:"timing"`
+	Mapper mapper.Status `json:"mapper"`
+}
+
+type Command struct {
+	Name string // pause, resume, reset, savestate, loadstate or input
+	Port int
+	Buttons byte
+	Reply chan error
+}
+
+type Server struct {
+	Commands chan Command
+
+	mutex sync.Mutex
+	status Status
+	screen []int
+	scroll [240]alphanes.ScrollLine
+	sprites [240]alphanes.SpriteLine
+	palette func(int) (byte, byte, byte)
+}
+
+// Button names accepted by /input, in controller bit order.
+var buttonNames = []string{"a", "b", "select", "start", "up", "down", "left", "right"}
+
+func StartServer(addr string, palette func(int) (byte, byte, byte)) *Server {
+	s := new(Server)
+	s.Commands = make(chan Command)
+	s.screen = make([]int, 256*240)
+	s.palette = palette
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) { handleStatus(s, w, r) })
+	mux.HandleFunc("/scroll", func(w http.ResponseWriter, r *http.Request) { handleScroll(s, w, r) })
+	mux.HandleFunc("/sprites", func(w http.ResponseWriter, r *http.Request) { handleSprites(s, w, r) })
+	mux.HandleFunc("/screenshot", func(w http.ResponseWriter, r *http.Request) { handleScreenshot(s, w, r) })
+	mux.HandleFunc("/input", func(w http.ResponseWriter, r *http.Request) { handleInput(s, w, r) })
+	for _, name := range []string{"pause", "resume", "reset", "savestate", "loadstate"} {
+		command := name
+		mux.HandleFunc("/" + command, func(w http.ResponseWriter, r *http.Request) { handleCommand(s, w, r, Command{Name: command}) })
+	}
+
+	go func() {
+		err := http.ListenAndServe(addr, mux)
+		fmt.Println("HTTP server stopped: ", err)
+	}()
+	fmt.Println("HTTP server listening on " + addr)
+	return s
+}
+
+// Publishes the state of the frame that just finished.
+func Publish(s *Server, status Status, screen []int, scroll [240]alphanes.ScrollLine, sprites [240]alphanes.SpriteLine) {
+	s.mutex.Lock()
+	s.status = status
+	copy(s.screen, screen)
+	s.scroll = scroll
+	s.sprites = sprites
+	s.mutex.Unlock()
+}
+
+// Returns the next pending command, if any, without blocking.
+func NextCommand(s *Server) (Command, bool) {
+	select {
+		case c := <-s.Commands:
+			return c, true
+		default:
+			return Command{}, false
+	}
+}
+
+func handleStatus(s *Server, w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	status := s.status
+	s.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// Scroll of each scanline of the last frame.
+func handleScroll(s *Server, w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	scroll := s.scroll
+	s.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scroll)
+}
+
+// Secondary OAM and sprite count of each scanline of the last frame.
+func handleSprites(s *Server, w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	sprites := s.sprites
+	s.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sprites)
+}
+
+func handleScreenshot(s *Server, w http.ResponseWriter, r *http.Request) {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 240))
+	s.mutex.Lock()
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 256; x++ {
+			cr, cg, cb := s.palette(s.screen[x + (y*256)])
+			img.Set(x, y, color.RGBA{cr, cg, cb, 255})
+		}
+	}
+	s.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, img)
+}
+
+// POST /input?port=0&buttons=a,start
+func handleInput(s *Server, w http.ResponseWriter, r *http.Request) {
+	port := 0
+	if value := r.FormValue("port"); value != "" {
+		var err error
+		port, err = strconv.Atoi(value)
+		if err != nil || port < 0 || port > 1 {
+			http.Error(w, "invalid port: " + value, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var buttons byte = 0
+	for _, name := range strings.Split(strings.ToLower(r.FormValue("buttons")), ",") {
+		if name == "" {
+			continue
+		}
+		found := false
+		for bit, b := range buttonNames {
+			if b == name {
+				buttons |= 1 << uint(bit)
+				found = true
+			}
+		}
+		if found == false {
+			http.Error(w, "unknown button: " + name, http.StatusBadRequest)
+			return
+		}
+	}
+	handleCommand(s, w, r, Command{Name: "input", Port: port, Buttons: buttons})
+}
+
+func handleCommand(s *Server, w http.ResponseWriter, r *http.Request, c Command) {
+	if r.Method != "POST" {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	c.Reply = make(chan error, 1)
+	select {
+		case s.Commands <- c:
+		case <-time.After(2 * time.Second):
+			http.Error(w, "the emulator is not responding", http.StatusServiceUnavailable)
+			return
+	}
+
+	var err error
+	select {
+		case err = <-c.Reply:
+		case <-time.After(2 * time.Second):
+			err = errors.New("timeout")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, "{\"ok\":true}\n")
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package remote
+
+import "net/http"
+import "net/http/httptest"
+import "testing"
+
+// A bad port is refused before the command reaches the frontend, so no
+// one has to read s.Commands.
+func TestInputRejectsBadPort(t *testing.T) {
+	s := &Server{Commands: make(chan Command)}
+	for _, port := range []string{"2", "-1", "one"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/input?port=" + port + "&buttons=a", nil)
+		handleInput(s, w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("port %q answers %d, want %d", port, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+// Without a port the input goes to the first controller.
+func TestInputDefaultPort(t *testing.T) {
+	s := &Server{Commands: make(chan Command)}
+	go func() {
+		c := <-s.Commands
+		if c.Port != 0 || c.Buttons != 1 {
+			t.Errorf("got port %d buttons %#x, want port 0 buttons 0x1", c.Port, c.Buttons)
+		}
+		c.Reply <- nil
+	}()
+	w := httptest.NewRecorder()
+	handleInput(s, w, httptest.NewRequest("POST", "/input?buttons=a", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("answers %d, want %d", w.Code, http.StatusOK)
+	}
+}
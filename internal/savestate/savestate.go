@@ -0,0 +1,105 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package savestate
+
+import "encoding/binary"
+import "errors"
+import "fmt"
+import "hash/crc32"
+import "io/ioutil"
+import "os"
+
+// Savestate slot files, game.st0 to game.st9. The console state written by
+// alphanes.WriteState goes after a header of its own:
+//
+//	"ANSLOT\x1A"  magic
+//	version       1 byte, Version
+//	length        8 bytes, little endian, of the state
+//	crc32         4 bytes, little endian, IEEE of the state
+//
+// so a file of another format version is refused before the state is
+// looked at, and a truncated or damaged one is caught by the length and
+// the checksum.
+
+const Magic = "ANSLOT\x1A"
+const Version = 1
+
+const headerSize = len(Magic) + 1 + 8 + 4
+
+var ErrFormat = errors.New("not an Alphanes savestate slot")
+var ErrCorrupt = errors.New("the savestate slot is truncated or damaged")
+
+// The slot was written by another version of the format.
+type VersionError struct {
+	Version byte
+}
+
+func (e VersionError) Error() string {
+	return fmt.Sprintf("savestate slot version %d, this build reads version %d", e.Version, Version)
+}
+
+// Puts the header in front of a state.
+func Encode(state []byte) []byte {
+	data := make([]byte, 0, headerSize + len(state))
+	data = append(data, Magic...)
+	data = append(data, Version)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(state)))
+	data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(state))
+	return append(data, state...)
+}
+
+// Checks the header and returns the state after it.
+func Decode(data []byte) ([]byte, error) {
+	if len(data) < len(Magic) + 1 || string(data[:len(Magic)]) != Magic {
+		return nil, ErrFormat
+	}
+	if data[len(Magic)] != Version {
+		return nil, VersionError{data[len(Magic)]}
+	}
+	if len(data) < headerSize {
+		return nil, ErrCorrupt
+	}
+	length := binary.LittleEndian.Uint64(data[len(Magic)+1:])
+	sum := binary.LittleEndian.Uint32(data[len(Magic)+9:])
+	state := data[headerSize:]
+	if length != uint64(len(state)) || crc32.ChecksumIEEE(state) != sum {
+		return nil, ErrCorrupt
+	}
+	return state, nil
+}
+
+// Written aside and renamed, a crash keeps the previous slot.
+func WriteFile(path string, state []byte) error {
+	err := ioutil.WriteFile(path + ".tmp", Encode(state), 0644)
+	if err == nil {
+		err = os.Rename(path + ".tmp", path)
+	}
+	if err != nil {
+		os.Remove(path + ".tmp")
+	}
+	return err
+}
+
+func ReadFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
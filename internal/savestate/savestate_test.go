@@ -0,0 +1,60 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package savestate
+
+import "path/filepath"
+import "testing"
+
+func TestSlotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.st0")
+	state := []byte("ANST\x10 state")
+	if err := WriteFile(path, state); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadFile(path)
+	if err != nil || string(got) != string(state) {
+		t.Fatalf("read %q, error %v", got, err)
+	}
+}
+
+// Files of another version, of another format, truncated or damaged are
+// refused.
+func TestSlotRejected(t *testing.T) {
+	state := []byte("ANST\x10 state")
+	newer := Encode(state)
+	newer[len(Magic)] = Version + 1
+	if _, err := Decode(newer); err != (VersionError{Version + 1}) {
+		t.Errorf("a version %d slot gave %v", Version + 1, err)
+	}
+	if _, err := Decode(state); err != ErrFormat {
+		t.Errorf("a bare state gave %v", err)
+	}
+	truncated := Encode(state)
+	if _, err := Decode(truncated[:len(truncated) - 1]); err != ErrCorrupt {
+		t.Errorf("a truncated slot gave %v", err)
+	}
+	damaged := Encode(state)
+	damaged[len(damaged) - 1] ^= 1
+	if _, err := Decode(damaged); err != ErrCorrupt {
+		t.Errorf("a damaged slot gave %v", err)
+	}
+	if _, err := Decode(Encode(state)[:headerSize - 1]); err != ErrCorrupt {
+		t.Errorf("a short header gave %v", err)
+	}
+}
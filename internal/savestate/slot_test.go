@@ -0,0 +1,76 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package savestate_test
+
+import "path/filepath"
+import "reflect"
+import "testing"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/savestate"
+
+// CNROM cartridge that switches to CHR bank 2 and spins.
+func cnromCartridge(t *testing.T) *cartridge.Cartridge {
+	image := make([]byte, 16 + 32768 + 4*8192)
+	copy(image, "NES\x1A\x02\x04\x30")
+	program := []byte{
+		0xA9, 0x02, // LDA #$02
+		0x8D, 0x01, 0x80, // STA $8001, which holds the 2 of LDA
+		0x4C, 0x05, 0x80, // JMP $8005
+	}
+	copy(image[16:], program)
+	copy(image[16 + 0x7FFA:], []byte{0x05, 0x80, 0x00, 0x80, 0x05, 0x80})
+	cart, err := cartridge.ParseRom(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cart
+}
+
+// A slot file written in one session and loaded in another keeps the bank
+// registers of the mapper.
+func TestSlotFileKeepsMapperBanks(t *testing.T) {
+	cart := cnromCartridge(t)
+	c := alphanes.StartConsole(cart)
+	alphanes.RunFrame(c)
+	banks := alphanes.MapperStatus(c)
+	if banks.CHRBanks[0] != 16 {
+		t.Fatalf("CHR bank %d after the write, the test program did not run", banks.CHRBanks[0])
+	}
+	path := filepath.Join(t.TempDir(), "game.st0")
+	if err := savestate.WriteFile(path, alphanes.WriteState(c, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	other := alphanes.StartConsole(cart)
+	data, err := savestate.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := alphanes.ReadState(other, data); err != nil {
+		t.Fatal(err)
+	}
+	loaded := alphanes.MapperStatus(other)
+	if reflect.DeepEqual(loaded.PRGBanks, banks.PRGBanks) == false || reflect.DeepEqual(loaded.CHRBanks, banks.CHRBanks) == false {
+		t.Errorf("banks PRG %v CHR %v after the load, want PRG %v CHR %v", loaded.PRGBanks, loaded.CHRBanks, banks.PRGBanks, banks.CHRBanks)
+	}
+	if alphanes.GetRegisters(other) != alphanes.GetRegisters(c) {
+		t.Error("the CPU was not restored")
+	}
+}
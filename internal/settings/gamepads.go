@@ -0,0 +1,92 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package settings
+
+import "fmt"
+import "io/ioutil"
+import "os"
+import "path/filepath"
+import "sort"
+import "strings"
+
+// Button mappings of the gamepads, by the SDL GUID of the device. Each
+// mapping goes from a NES button name to an SDL controller button name,
+// stored as guid.button=name lines.
+type GamepadProfiles map[string]map[string]string
+
+func GamepadFile() string {
+	return filepath.Join(filepath.Dir(StoreDir()), "gamepads.cfg")
+}
+
+func LoadGamepads() GamepadProfiles {
+	profiles := make(GamepadProfiles)
+
+	content, err := ioutil.ReadFile(GamepadFile())
+	if err != nil {
+		return profiles
+	}
+
+	for n, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		key := strings.SplitN(strings.TrimSpace(kv[0]), ".", 2)
+		if len(kv) != 2 || len(key) != 2 {
+			fmt.Printf("Gamepads: ignoring line %d: %s\n", n+1, line)
+			continue
+		}
+		guid := strings.ToLower(key[0])
+		if profiles[guid] == nil {
+			profiles[guid] = make(map[string]string)
+		}
+		profiles[guid][strings.ToLower(key[1])] = strings.TrimSpace(kv[1])
+	}
+	return profiles
+}
+
+func SaveGamepads(profiles GamepadProfiles) error {
+
+	err := os.MkdirAll(filepath.Dir(GamepadFile()), 0755)
+	if err != nil {
+		return err
+	}
+
+	var guids []string
+	for guid := range profiles {
+		guids = append(guids, guid)
+	}
+	sort.Strings(guids)
+
+	var b strings.Builder
+	b.WriteString("# Alphanes gamepad mappings: guid.nes_button=controller_button\n")
+	for _, guid := range guids {
+		var buttons []string
+		for button := range profiles[guid] {
+			buttons = append(buttons, button)
+		}
+		sort.Strings(buttons)
+		for _, button := range buttons {
+			fmt.Fprintf(&b, "%s.%s=%s\n", guid, button, profiles[guid][button])
+		}
+	}
+
+	return ioutil.WriteFile(GamepadFile(), []byte(b.String()), 0644)
+}
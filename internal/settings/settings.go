@@ -0,0 +1,189 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package settings
+
+import "fmt"
+import "io/ioutil"
+import "os"
+import "path/filepath"
+import "strconv"
+import "strings"
+
+// Per-game overrides, stored in a plain key=value file named after the
+// SHA-1 of the ROM contents.
+type GameSettings struct {
+	Hash string
+	Found bool // A settings file exists for this ROM
+	Region string // "ntsc", "pal", "dendy" or "" to detect it
+	Revision string // "front-loader", "top-loader", "famicom", "famicom-early", "av-famicom" or "" for the default
+	Palette string // Path of a 192 bytes .pal file
+	Preset string // "performance", "balanced", "accuracy" or "" for the default
+	FastPPU bool // Threaded PPU drawing, see ppu.ThreadedRender
+	ExpansionVolume float64 // 0.0 - 1.0
+	Opposing string // "allow", "neutral", "last" or "" for the default
+	ExpansionMix map[string]int // Chip name -> gain in millibels
+	Buttons map[string]string // NES button name -> SDL keyboard key name
+}
+
+func DefaultGameSettings(hash string) GameSettings {
+	var s GameSettings
+	s.Hash = hash
+	s.Found = false
+	s.Region = ""
+	s.Revision = ""
+	s.Palette = ""
+	s.Preset = ""
+	s.FastPPU = false
+	s.ExpansionVolume = 1.0
+	s.Opposing = ""
+	s.ExpansionMix = make(map[string]int)
+	s.Buttons = make(map[string]string)
+	return s
+}
+
+// Directory where the per-game files are kept.
+func StoreDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "alphanes", "games")
+}
+
+// ROM database of regions, kept next to the per-game files.
+func RegionDatabaseFile() string {
+	return filepath.Join(filepath.Dir(StoreDir()), "regions.txt")
+}
+
+// Compatibility reports of the sessions, one JSON object per line.
+func CompatDatabaseFile() string {
+	return filepath.Join(filepath.Dir(StoreDir()), "compat.jsonl")
+}
+
+func GameSettingsFile(hash string) string {
+	return filepath.Join(StoreDir(), hash + ".cfg")
+}
+
+func LoadGameSettings(hash string) GameSettings {
+	s := DefaultGameSettings(hash)
+
+	content, err := ioutil.ReadFile(GameSettingsFile(hash))
+	if err != nil {
+		return s
+	}
+	s.Found = true
+
+	for n, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			fmt.Printf("Settings: ignoring line %d: %s\n", n+1, line)
+			continue
+		}
+		key := strings.TrimSpace(kv[0])
+		value := strings.TrimSpace(kv[1])
+		applyKey(&s, key, value, n+1)
+	}
+	return s
+}
+
+func applyKey(s *GameSettings, key string, value string, n int) {
+
+	if strings.HasPrefix(key, "button.") {
+		s.Buttons[strings.TrimPrefix(key, "button.")] = value
+		return
+	}
+	if strings.HasPrefix(key, "expansion.") {
+		mb, err := strconv.Atoi(value)
+		if err != nil {
+			fmt.Printf("Settings: invalid %s at line %d\n", key, n)
+			return
+		}
+		s.ExpansionMix[strings.TrimPrefix(key, "expansion.")] = mb
+		return
+	}
+
+	switch(key) {
+		case "region":
+			s.Region = strings.ToLower(value)
+		case "revision":
+			s.Revision = strings.ToLower(value)
+		case "palette":
+			s.Palette = value
+		case "preset":
+			s.Preset = strings.ToLower(value)
+		case "opposing":
+			s.Opposing = strings.ToLower(value)
+		case "fastppu":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				fmt.Printf("Settings: invalid fastppu at line %d\n", n)
+				return
+			}
+			s.FastPPU = b
+		case "expansion_volume":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil || v < 0 || v > 1 {
+				fmt.Printf("Settings: invalid expansion_volume at line %d\n", n)
+				return
+			}
+			s.ExpansionVolume = v
+		default:
+			fmt.Printf("Settings: unknown key %s at line %d\n", key, n)
+	}
+}
+
+func SaveGameSettings(s GameSettings) error {
+
+	err := os.MkdirAll(StoreDir(), 0755)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("# Alphanes per-game settings\n")
+	if s.Region != "" {
+		fmt.Fprintf(&b, "region=%s\n", s.Region)
+	}
+	if s.Revision != "" {
+		fmt.Fprintf(&b, "revision=%s\n", s.Revision)
+	}
+	if s.Palette != "" {
+		fmt.Fprintf(&b, "palette=%s\n", s.Palette)
+	}
+	if s.Preset != "" {
+		fmt.Fprintf(&b, "preset=%s\n", s.Preset)
+	}
+	if s.Opposing != "" {
+		fmt.Fprintf(&b, "opposing=%s\n", s.Opposing)
+	}
+	fmt.Fprintf(&b, "fastppu=%t\n", s.FastPPU)
+	fmt.Fprintf(&b, "expansion_volume=%g\n", s.ExpansionVolume)
+	for chip, mb := range s.ExpansionMix {
+		fmt.Fprintf(&b, "expansion.%s=%d\n", chip, mb)
+	}
+	for button, key := range s.Buttons {
+		fmt.Fprintf(&b, "button.%s=%s\n", button, key)
+	}
+
+	return ioutil.WriteFile(GameSettingsFile(s.Hash), []byte(b.String()), 0644)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package settings
+
+import "fmt"
+import "io/ioutil"
+import "os"
+import "path/filepath"
+import "strconv"
+import "strings"
+
+// Last position and size of the emulator window outside fullscreen.
+type Window struct {
+	Found bool
+	X, Y, W, H int32
+}
+
+func WindowFile() string {
+	return filepath.Join(filepath.Dir(StoreDir()), "window.cfg")
+}
+
+func LoadWindow() Window {
+	var w Window
+
+	content, err := ioutil.ReadFile(WindowFile())
+	if err != nil {
+		return w
+	}
+
+	for n, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			fmt.Printf("Window settings: ignoring line %d: %s\n", n+1, line)
+			continue
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 32)
+		if err != nil {
+			fmt.Printf("Window settings: invalid value at line %d\n", n+1)
+			continue
+		}
+		switch(strings.TrimSpace(kv[0])) {
+			case "x":
+				w.X = int32(v)
+			case "y":
+				w.Y = int32(v)
+			case "width":
+				w.W = int32(v)
+			case "height":
+				w.H = int32(v)
+			default:
+				fmt.Printf("Window settings: unknown key at line %d\n", n+1)
+		}
+	}
+	w.Found = w.W > 0 && w.H > 0
+	return w
+}
+
+func SaveWindow(w Window) error {
+
+	err := os.MkdirAll(filepath.Dir(WindowFile()), 0755)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("# Alphanes window position and size\n")
+	fmt.Fprintf(&b, "x=%d\n", w.X)
+	fmt.Fprintf(&b, "y=%d\n", w.Y)
+	fmt.Fprintf(&b, "width=%d\n", w.W)
+	fmt.Fprintf(&b, "height=%d\n", w.H)
+
+	return ioutil.WriteFile(WindowFile(), []byte(b.String()), 0644)
+}
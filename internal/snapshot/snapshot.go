@@ -0,0 +1,166 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package snapshot
+
+import "encoding/binary"
+import "errors"
+
+// Field by field binary encoding for savestates. Every component writes its
+// own fields in a fixed order, so there is no reflection and the encoder
+// only allocates when its buffer has to grow.
+
+var ErrShort = errors.New("savestate is truncated")
+var ErrRange = errors.New("savestate value does not fit in an int on this platform")
+var ErrLong = errors.New("savestate has data past its end")
+
+type Encoder struct {
+	Buf []byte
+}
+
+type Decoder struct {
+	Data []byte
+	Pos int
+	Err error // First error found, the following reads return zero values
+}
+
+// Empties the encoder keeping its buffer.
+func Reset(e *Encoder) {
+	e.Buf = e.Buf[:0]
+}
+
+func PutByte(e *Encoder, v byte) {
+	e.Buf = append(e.Buf, v)
+}
+
+func PutBool(e *Encoder, v bool) {
+	if v {
+		e.Buf = append(e.Buf, 1)
+	} else {
+		e.Buf = append(e.Buf, 0)
+	}
+}
+
+func PutUint16(e *Encoder, v uint16) {
+	e.Buf = binary.LittleEndian.AppendUint16(e.Buf, v)
+}
+
+func PutUint64(e *Encoder, v uint64) {
+	e.Buf = binary.LittleEndian.AppendUint64(e.Buf, v)
+}
+
+func PutInt(e *Encoder, v int) {
+	PutUint64(e, uint64(int64(v)))
+}
+
+// Length prefixed block of memory.
+func PutBytes(e *Encoder, b []byte) {
+	PutUint64(e, uint64(len(b)))
+	e.Buf = append(e.Buf, b...)
+}
+
+func PutString(e *Encoder, s string) {
+	PutUint64(e, uint64(len(s)))
+	e.Buf = append(e.Buf, s...)
+}
+
+func StartDecoder(data []byte) Decoder {
+	return Decoder{Data: data}
+}
+
+// Fails the decoder when it did not read all the data, which means the
+// fields were read in another order than they were written.
+func Finish(d *Decoder) {
+	if d.Err == nil && d.Pos != len(d.Data) {
+		d.Err = ErrLong
+	}
+}
+
+func take(d *Decoder, n int) []byte {
+	if d.Err != nil {
+		return nil
+	}
+	// Written so that it cannot overflow with a 32-bit int
+	if n < 0 || n > len(d.Data) - d.Pos {
+		d.Err = ErrShort
+		return nil
+	}
+	b := d.Data[d.Pos:d.Pos+n]
+	d.Pos += n
+	return b
+}
+
+func Byte(d *Decoder) byte {
+	b := take(d, 1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func Bool(d *Decoder) bool {
+	return Byte(d) != 0
+}
+
+func Uint16(d *Decoder) uint16 {
+	b := take(d, 2)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+func Uint64(d *Decoder) uint64 {
+	b := take(d, 8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// PutInt always writes 64 bits, so a state written on a 64-bit platform
+// can hold values a 32-bit int cannot.
+func Int(d *Decoder) int {
+	v := int64(Uint64(d))
+	if int64(int(v)) != v {
+		if d.Err == nil {
+			d.Err = ErrRange
+		}
+		return 0
+	}
+	return int(v)
+}
+
+// Reads a block written by PutBytes into dst, reusing it when the size
+// matches.
+func Bytes(d *Decoder, dst []byte) []byte {
+	n := Uint64(d)
+	if n > uint64(len(d.Data)) {
+		d.Err = ErrShort
+		return dst
+	}
+	b := take(d, int(n))
+	if b == nil {
+		return dst
+	}
+	if len(dst) != len(b) {
+		dst = make([]byte, len(b))
+	}
+	copy(dst, b)
+	return dst
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package snapshot
+
+import "testing"
+import "math"
+import "strconv"
+
+// Ints are kept in 64 bits. A value a 32-bit int cannot hold is an error
+// on those platforms instead of a truncated number.
+func TestIntRange(t *testing.T) {
+	var e Encoder
+	values := []int64{0, -1, math.MaxInt32, math.MinInt32, 1 << 40, -(1 << 40)}
+	for _, v := range values {
+		PutUint64(&e, uint64(v))
+	}
+	d := StartDecoder(e.Buf)
+	for _, v := range values {
+		got := Int(&d)
+		if v != int64(int(v)) {
+			if d.Err != ErrRange || got != 0 {
+				t.Fatalf("%d decoded as %d with error %v on a %d-bit int", v, got, d.Err, strconv.IntSize)
+			}
+			return
+		}
+		if int64(got) != v || d.Err != nil {
+			t.Fatalf("%d decoded as %d with error %v", v, got, d.Err)
+		}
+	}
+}
+
+// Block sizes past the end of the data, up to 2^64 - 1, are a truncated
+// state and never wrap the bounds check around.
+func TestBlockSizeOverflow(t *testing.T) {
+	sizes := []uint64{9, 1 << 31, 1 << 32 + 4, math.MaxInt64, math.MaxUint64}
+	for _, size := range sizes {
+		var e Encoder
+		PutUint64(&e, size)
+		e.Buf = append(e.Buf, 1, 2, 3, 4, 5, 6, 7, 8)
+		d := StartDecoder(e.Buf)
+		dst := Bytes(&d, nil)
+		if d.Err != ErrShort || dst != nil {
+			t.Errorf("block of %d bytes: error %v, %d bytes read", size, d.Err, len(dst))
+		}
+	}
+
+	d := StartDecoder([]byte{1, 2, 3})
+	d.Pos = 2
+	if take(&d, math.MaxInt) != nil || d.Err != ErrShort {
+		t.Errorf("take of MaxInt bytes at %d: error %v", d.Pos, d.Err)
+	}
+}
+
+func TestFinish(t *testing.T) {
+	var e Encoder
+	PutUint16(&e, 0x1234)
+	PutByte(&e, 1)
+	d := StartDecoder(e.Buf)
+	Uint16(&d)
+	Finish(&d)
+	if d.Err != ErrLong {
+		t.Errorf("a byte left over gave %v", d.Err)
+	}
+	d = StartDecoder(e.Buf)
+	Uint16(&d)
+	Byte(&d)
+	Finish(&d)
+	if d.Err != nil {
+		t.Errorf("all data read gave %v", d.Err)
+	}
+}
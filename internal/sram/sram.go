@@ -0,0 +1,105 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package sram
+
+import "fmt"
+import "io/ioutil"
+import "os"
+import "path/filepath"
+import "strings"
+
+// Battery backed RAM at $6000-$7FFF.
+const Size = 0x2000
+
+// The save file is never written in place: the data goes to a temporary
+// file that is synced and renamed over the .sav, after the previous .sav
+// is kept as .sav.bak. A crash leaves either the old or the new file.
+
+func SavePath(romfile string) string {
+	return strings.TrimSuffix(romfile, filepath.Ext(romfile)) + ".sav"
+}
+
+func valid(path string) (bool, []byte, int64) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != Size {
+		return false, nil, 0
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil || len(data) != Size {
+		return false, nil, 0
+	}
+	return true, data, info.ModTime().UnixNano()
+}
+
+// Returns the newest valid copy among the .sav and the .sav.bak. A
+// leftover temporary file means a save was interrupted, it is discarded.
+func LoadSRAM(path string) ([]byte, bool) {
+
+	tmp := path + ".tmp"
+	if _, err := os.Stat(tmp); err == nil {
+		fmt.Println("Discarding an interrupted save: " + tmp)
+		os.Remove(tmp)
+	}
+
+	okSave, save, saveTime := valid(path)
+	okBackup, backup, backupTime := valid(path + ".bak")
+
+	if okBackup && (okSave == false || backupTime > saveTime) {
+		fmt.Println("Recovering the battery save from " + path + ".bak")
+		if err := SaveSRAM(path, backup); err != nil {
+			fmt.Println("Cannot restore the battery save: ", err)
+		}
+		return backup, true
+	}
+	if okSave {
+		return save, true
+	}
+	return nil, false
+}
+
+func SaveSRAM(path string, data []byte) error {
+
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if _, err = os.Stat(path); err == nil {
+		if err = os.Rename(path, path + ".bak"); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package stream
+
+import "fmt"
+import "image"
+import "image/color"
+import "image/jpeg"
+import "net/http"
+import "sync"
+
+// MJPEG stream of the native 256x240 picture, for capture software such as
+// OBS (add it as a media or browser source). Frames come straight from the
+// PPU output, so they do not depend on the window scale, shaders or overlays.
+
+type Stream struct {
+	Quality int
+
+	mutex sync.Mutex
+	ready chan struct{} // Closed and replaced by each published frame
+	frame int // Number of the last published frame
+	screen []int
+	palette func(int) (byte, byte, byte)
+}
+
+func StartStream(addr string, palette func(int) (byte, byte, byte)) *Stream {
+	s := new(Stream)
+	s.Quality = 85
+	s.screen = make([]int, 256*240)
+	s.palette = palette
+	s.ready = make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { serveStream(s, w, r) })
+
+	go func() {
+		err := http.ListenAndServe(addr, mux)
+		fmt.Println("Stream stopped: ", err)
+	}()
+	fmt.Println("MJPEG stream on http://" + addr + "/")
+	return s
+}
+
+// Publishes a completed frame and wakes the clients waiting for it.
+func PublishFrame(s *Stream, screen []int) {
+	s.mutex.Lock()
+	copy(s.screen, screen)
+	s.frame++
+	close(s.ready)
+	s.ready = make(chan struct{})
+	s.mutex.Unlock()
+}
+
+// Each client encodes at its own pace; a slow one skips frames instead of
+// holding back the emulation. A client that disconnects while waiting for
+// a frame ends its handler at once, even if the emulation is paused.
+func serveStream(s *Stream, w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary=frame")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	img := image.NewRGBA(image.Rect(0, 0, 256, 240))
+	last := 0
+	for {
+		s.mutex.Lock()
+		if s.frame == last {
+			ready := s.ready
+			s.mutex.Unlock()
+			select {
+				case <-ready:
+					continue
+				case <-r.Context().Done():
+					return
+			}
+		}
+		last = s.frame
+		for y := 0; y < 240; y++ {
+			for x := 0; x < 256; x++ {
+				cr, cg, cb := s.palette(s.screen[x + (y*256)])
+				img.SetRGBA(x, y, color.RGBA{cr, cg, cb, 255})
+			}
+		}
+		s.mutex.Unlock()
+
+		_, err := fmt.Fprintf(w, "--frame\r\nContent-Type: image/jpeg\r\n\r\n")
+		if err == nil {
+			err = jpeg.Encode(w, img, &jpeg.Options{Quality: s.Quality})
+		}
+		if err == nil {
+			_, err = fmt.Fprintf(w, "\r\n")
+		}
+		if err != nil {
+			return
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package stream
+
+import "bufio"
+import "context"
+import "net/http"
+import "net/http/httptest"
+import "testing"
+import "time"
+
+func testStream() *Stream {
+	s := new(Stream)
+	s.Quality = 85
+	s.screen = make([]int, 256*240)
+	s.palette = func(int) (byte, byte, byte) { return 0, 0, 0 }
+	s.ready = make(chan struct{})
+	return s
+}
+
+// A client that goes away while no frame comes, as when the emulation is
+// paused, must not leave its handler waiting forever.
+func TestServeStreamEndsOnDisconnect(t *testing.T) {
+	s := testStream()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	done := make(chan bool)
+	go func() {
+		serveStream(s, httptest.NewRecorder(), r)
+		done <- true
+	}()
+
+	cancel()
+	select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("serveStream still waiting after the client disconnected")
+	}
+}
+
+// A client gets the last published frame.
+func TestServeStreamSendsPublishedFrame(t *testing.T) {
+	s := testStream()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { serveStream(s, w, r) }))
+	defer server.Close()
+
+	PublishFrame(s, make([]int, 256*240))
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	line, err := bufio.NewReader(response.Body).ReadString('\n')
+	if err != nil || line != "--frame\r\n" {
+		t.Errorf("first line %q (%v), want the frame boundary", line, err)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package timing
+
+import "slices"
+import "time"
+
+// Frame duration statistics over the last Samples frames, used to measure
+// the effect of pacing changes and GC pauses.
+
+const Samples = 600 // 10 seconds at 60 fps
+const Buckets = 17 // Histogram buckets
+const BucketWidth = 2 * time.Millisecond // The last bucket takes everything over 32ms
+
+type FrameTiming struct {
+	Durations [Samples]time.Duration
+	Next int
+	Count int
+	Last time.Time // End of the previous frame, zero after a restart
+	sorted []time.Duration
+}
+
+type Stats struct {
+	Frames int `json:"frames"`
+	Min float64 `json:"min_ms"`
+	Avg float64 `json:"avg_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+	Jitter float64 `json:"jitter_ms"` // Mean difference between consecutive frames
+	Histogram [Buckets]int `json:"histogram"` // Frames per BucketWidth wide bucket
+}
+
+func StartFrameTiming() FrameTiming {
+	var t FrameTiming
+	t.sorted = make([]time.Duration, 0, Samples)
+	return t
+}
+
+// Marks the end of a frame.
+func Tick(t *FrameTiming) {
+	now := time.Now()
+	if t.Last.IsZero() == false {
+		t.Durations[t.Next] = now.Sub(t.Last)
+		t.Next = (t.Next + 1) % Samples
+		if t.Count < Samples {
+			t.Count++
+		}
+	}
+	t.Last = now
+}
+
+// Forgets the end of the last frame, so time spent paused is not counted.
+func Restart(t *FrameTiming) {
+	t.Last = time.Time{}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func Compute(t *FrameTiming) Stats {
+	var s Stats
+	s.Frames = t.Count
+	if t.Count == 0 {
+		return s
+	}
+
+	// Oldest sample first
+	start := (t.Next - t.Count + Samples) % Samples
+	var total time.Duration
+	var jitter time.Duration
+	t.sorted = t.sorted[:0]
+	for i := 0; i < t.Count; i++ {
+		d := t.Durations[(start + i) % Samples]
+		t.sorted = append(t.sorted, d)
+		total += d
+		if i > 0 {
+			diff := d - t.Durations[(start + i - 1) % Samples]
+			if diff < 0 {
+				diff = -diff
+			}
+			jitter += diff
+		}
+
+		bucket := int(d / BucketWidth)
+		if bucket >= Buckets {
+			bucket = Buckets - 1
+		}
+		s.Histogram[bucket]++
+	}
+	slices.Sort(t.sorted)
+
+	s.Min = ms(t.sorted[0])
+	s.Max = ms(t.sorted[t.Count - 1])
+	s.P99 = ms(t.sorted[(t.Count - 1) * 99 / 100])
+	s.Avg = ms(total) / float64(t.Count)
+	if t.Count > 1 {
+		s.Jitter = ms(jitter) / float64(t.Count - 1)
+	}
+	return s
+}
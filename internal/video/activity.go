@@ -0,0 +1,132 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// Diagnostic overlay with small real-time graphs of the core counters.
+
+const activityFrames = 64 // Frames kept in the graphs
+
+type ActivityGraph struct {
+	Samples [activityFrames]float64 // Normalized to 0.0 - 1.0
+	Next int
+}
+
+var ShowActivity bool = false
+var instructionsGraph ActivityGraph
+var irqGraph ActivityGraph
+var audioGraph ActivityGraph
+var spritesPerScanline [240]byte
+
+func ToggleActivity() {
+	ShowActivity = !ShowActivity
+}
+
+func addSample(g *ActivityGraph, value float64) {
+	if value > 1 {
+		value = 1
+	}
+	g.Samples[g.Next] = value
+	g.Next = (g.Next + 1) % activityFrames
+}
+
+// Samples the counters of the finished frame and clears them.
+func recordActivity(p *ppu.PPU) {
+	a := &p.IO.ACTIVITY
+	// A frame has 29780 CPU cycles, so ~15000 instructions is a busy frame
+	addSample(&instructionsGraph, float64(a.INSTRUCTIONS) / 15000)
+	addSample(&irqGraph, float64(a.MAPPER_IRQS) / 8)
+	addSample(&audioGraph, a.AUDIO_FILL)
+	spritesPerScanline = a.SPRITES_PER_SCANLINE
+
+	a.INSTRUCTIONS = 0
+	a.MAPPER_IRQS = 0
+}
+
+func drawGraph(g *ActivityGraph, x int32, y int32, r byte, gr byte, b byte) {
+	const height = 24
+	frameFillRect(sdl.Rect{X: x, Y: y, W: activityFrames, H: height}, 0, 0, 0, 160)
+	for i := 0; i < activityFrames; i++ {
+		v := g.Samples[(g.Next + i) % activityFrames]
+		bar := int32(v * height)
+		frameFillRect(sdl.Rect{X: x + int32(i), Y: y + height - bar, W: 1, H: bar}, r, gr, b, 255)
+	}
+}
+
+// Frame duration histogram, set by the frontend, and the bucket of the
+// 60 fps frame time.
+var FrameHistogram []int
+var FrameHistogramTarget int = 8
+
+func drawHistogram(x int32, y int32) {
+	const height = 24
+	if len(FrameHistogram) == 0 {
+		return
+	}
+	most := 1
+	for _, n := range FrameHistogram {
+		if n > most {
+			most = n
+		}
+	}
+	w := int32(len(FrameHistogram)) * 4
+	frameFillRect(sdl.Rect{X: x, Y: y, W: w, H: height}, 0, 0, 0, 160)
+	for i, n := range FrameHistogram {
+		bar := int32(n * height / most)
+		if n > 0 && bar == 0 {
+			bar = 1
+		}
+		if i > FrameHistogramTarget {
+			frameFillRect(sdl.Rect{X: x + int32(i)*4, Y: y + height - bar, W: 3, H: bar}, 240, 60, 60, 255)
+		} else {
+			frameFillRect(sdl.Rect{X: x + int32(i)*4, Y: y + height - bar, W: 3, H: bar}, 200, 200, 200, 255)
+		}
+	}
+}
+
+func drawActivity(IO *ioports.IOPorts) {
+
+	if ShowActivity == false {
+		return
+	}
+
+	drawGraph(&instructionsGraph, 4, 4, 80, 220, 80)
+	drawGraph(&irqGraph, 4, 32, 220, 120, 60)
+	drawGraph(&audioGraph, 4, 60, 80, 140, 240)
+	drawHistogram(4, 88)
+
+	// Sprites per scanline, with the 8 sprites hardware limit marked
+	frameFillRect(sdl.Rect{X: 220, Y: 0, W: 32, H: 240}, 0, 0, 0, 128)
+	for y := 0; y < 240; y++ {
+		n := int32(spritesPerScanline[y])
+		if n > 16 {
+			n = 16
+		}
+		if n > 8 {
+			frameFillRect(sdl.Rect{X: 220, Y: int32(y), W: n*2, H: 1}, 240, 60, 60, 255)
+		} else {
+			frameFillRect(sdl.Rect{X: 220, Y: int32(y), W: n*2, H: 1}, 240, 240, 80, 255)
+		}
+	}
+	frameFillRect(sdl.Rect{X: 236, Y: 0, W: 1, H: 240}, 255, 255, 255, 96)
+}
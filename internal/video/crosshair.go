@@ -0,0 +1,143 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "fmt"
+import "strconv"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// Light gun crosshair: a marker drawn into the frame at the NES pixel under
+// the mouse, so aiming does not depend on the size, rotation or scaling of
+// the window. The frontend enables it for Zapper games.
+
+const (
+	CROSSHAIR_OFF = 0
+	CROSSHAIR_CROSS = 1 // Lines 5 pixels long around an empty center
+	CROSSHAIR_DOT = 2 // The aimed pixel and its 4 neighbours
+	CROSSHAIR_BOX = 3 // A 7x7 outline
+)
+
+var crosshairNames = []string{"off", "cross", "dot", "box"}
+
+type CrosshairOptions struct {
+	Style int // One of the CROSSHAIR_ styles
+	R, G, B byte
+	HideCursor bool // Hide the system cursor over the window
+}
+
+var Crosshair = CrosshairOptions{Style: CROSSHAIR_OFF, R: 255, G: 40, B: 40}
+
+// NES pixel under the mouse, and whether the mouse is over the picture.
+var aimX, aimY int32
+var aimed bool
+
+func SetCrosshair(name string) bool {
+	for style, n := range crosshairNames {
+		if n == name {
+			Crosshair.Style = style
+			return true
+		}
+	}
+	fmt.Printf("Invalid crosshair: %s (use off, cross, dot or box)\n", name)
+	return false
+}
+
+// Color as six hex digits, like ff2828.
+func SetCrosshairColor(hex string) bool {
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		fmt.Printf("Invalid crosshair color: %s (use six hex digits like ff2828)\n", hex)
+		return false
+	}
+	Crosshair.R, Crosshair.G, Crosshair.B = byte(rgb >> 16), byte(rgb >> 8), byte(rgb)
+	return true
+}
+
+func initCrosshair() {
+	if Crosshair.Style != CROSSHAIR_OFF && Crosshair.HideCursor {
+		sdl.ShowCursor(sdl.DISABLE)
+	}
+}
+
+// Mouse position in the window to a pixel of the NES picture.
+func mouseToScreen(x int32, y int32) (int32, int32) {
+	if glReady {
+		// Without a renderer the mouse is in window coordinates
+		ww, wh := window.GetSize()
+		lx, ly, lw, lh := letterbox(ww, wh)
+		ow, oh := outputSize()
+		if lw > 0 && lh > 0 {
+			x = (x - lx) * ow / lw
+			y = (y - ly) * oh / lh
+		}
+	} else {
+		x -= frameOrigin.X
+		y -= frameOrigin.Y
+	}
+	return outputToScreen(x / Output.Scale, y / Output.Scale)
+}
+
+// Follows the mouse. The event is left to the other handlers.
+func crosshairEvent(event sdl.Event) {
+	switch e := event.(type) {
+		case *sdl.MouseMotionEvent:
+			aimX, aimY = mouseToScreen(e.X, e.Y)
+			aimed = aimX >= 0 && aimX < 256 && aimY >= 0 && aimY < 240
+		case *sdl.WindowEvent:
+			if e.Event == sdl.WINDOWEVENT_LEAVE {
+				aimed = false
+			}
+	}
+}
+
+func crosshairPixel(x int32, y int32) {
+	frameBlend(int(x), int(y), Crosshair.R, Crosshair.G, Crosshair.B, 255)
+}
+
+func drawCrosshair() {
+
+	if Crosshair.Style == CROSSHAIR_OFF || aimed == false {
+		return
+	}
+
+	x, y := aimX, aimY
+	switch Crosshair.Style {
+		case CROSSHAIR_CROSS:
+			for d := int32(2); d <= 6; d++ {
+				crosshairPixel(x-d, y)
+				crosshairPixel(x+d, y)
+				crosshairPixel(x, y-d)
+				crosshairPixel(x, y+d)
+			}
+		case CROSSHAIR_DOT:
+			crosshairPixel(x, y)
+			crosshairPixel(x-1, y)
+			crosshairPixel(x+1, y)
+			crosshairPixel(x, y-1)
+			crosshairPixel(x, y+1)
+		case CROSSHAIR_BOX:
+			for d := int32(-3); d <= 3; d++ {
+				crosshairPixel(x+d, y-3)
+				crosshairPixel(x+d, y+3)
+				crosshairPixel(x-3, y+d)
+				crosshairPixel(x+3, y+d)
+			}
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "fmt"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// Position and size of the window outside fullscreen. W is 0 when
+// nothing was remembered.
+type WindowGeometry struct {
+	X, Y, W, H int32
+}
+
+// Prints the monitors SDL can place the window on.
+func ListDisplays() {
+	if err := sdl.InitSubSystem(sdl.INIT_VIDEO); err != nil {
+		fmt.Printf("Failed to initialize video: %s\n", err)
+		return
+	}
+	n, err := sdl.GetNumVideoDisplays()
+	if err != nil {
+		fmt.Printf("Failed to enumerate displays: %s\n", err)
+		return
+	}
+	for i := 0; i < n; i++ {
+		name, _ := sdl.GetDisplayName(i)
+		bounds, _ := sdl.GetDisplayBounds(i)
+		fmt.Printf("%d: %s (%dx%d at %d,%d)\n", i, name, bounds.W, bounds.H, bounds.X, bounds.Y)
+	}
+}
+
+// Window position and size at creation. An explicit display centers the
+// window on it, otherwise the remembered geometry is used as long as it
+// still overlaps a connected monitor.
+func windowPlacement() (int32, int32, int32, int32) {
+	w, h := outputSize()
+	n, _ := sdl.GetNumVideoDisplays()
+	if Output.Display >= 0 {
+		display := Output.Display
+		if display >= n {
+			fmt.Printf("Display %d not found, using display 0\n", display)
+			display = 0
+		}
+		pos := int32(sdl.WINDOWPOS_CENTERED_MASK | display)
+		return pos, pos, w, h
+	}
+	g := Output.Window
+	if g.W <= 0 || g.H <= 0 {
+		return sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED, w, h
+	}
+	rect := sdl.Rect{X: g.X, Y: g.Y, W: g.W, H: g.H}
+	for i := 0; i < n; i++ {
+		bounds, err := sdl.GetDisplayBounds(i)
+		if err == nil && rect.HasIntersection(&bounds) {
+			return g.X, g.Y, g.W, g.H
+		}
+	}
+	return sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, g.W, g.H
+}
+
+// Keeps Output.Window in step with the window while it is not fullscreen.
+func trackWindow(e *sdl.WindowEvent) {
+	if e.Event == sdl.WINDOWEVENT_MOVED || e.Event == sdl.WINDOWEVENT_RESIZED {
+		rememberWindow()
+	}
+}
+
+func rememberWindow() {
+	if window == nil || window.GetFlags()&sdl.WINDOW_FULLSCREEN_DESKTOP != 0 {
+		return
+	}
+	Output.Window.X, Output.Window.Y = window.GetPosition()
+	Output.Window.W, Output.Window.H = window.GetSize()
+}
+
+// Largest rectangle with the aspect of the output that fits in a w x h
+// drawable, centered.
+func letterbox(w int32, h int32) (int32, int32, int32, int32) {
+	ow, oh := outputSize()
+	if w*oh > h*ow {
+		fw := h * ow / oh
+		return (w - fw) / 2, 0, fw, h
+	}
+	fh := w * oh / ow
+	return 0, (h - fh) / 2, w, fh
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2014, 2014 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "fmt"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/audio"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/mapper"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+import "os"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// SDL frontend of the PPU: the window, the overlays, the keyboard and the
+// gamepads. The core runs without it, see ppu.Display.
+
+var window *sdl.Window
+var renderer *sdl.Renderer
+
+type frontend struct {
+	ppu *ppu.PPU // The PPU of the last frame, for CheckEvents
+}
+
+var display frontend
+
+// Opens the window and the gamepads, unless Output.Driver is "null", and
+// has the PPU send its frames here. Set the output options first.
+func Start() {
+	if Output.Driver != "null" {
+		initCanvas()
+		initGamepads()
+		initCrosshair()
+	}
+	ppu.Display = &display
+}
+
+func (f *frontend) VBlank(p *ppu.PPU) {
+	f.ppu = p
+	recordActivity(p)
+	if Output.Driver != "null" {
+		checkKeyboard(p)
+	}
+	p.IO.MICROPHONE = micKey || audio.MicrophoneActive(&mic)
+	ShowScreen(p)
+}
+
+// The frontend stops running the console while Paused is set and polls
+// CheckEvents in a low-power loop until it is cleared.
+var Paused bool = false
+var Quit bool = false // The window was closed, the frontend should exit
+var AutoPause bool = false // Pause when the window loses the focus
+var pausedByFocus bool = false
+var Rewind bool = false // Backspace was pressed, the frontend should rewind
+var SaveSlot bool = false // F10 was pressed, the frontend should take a savestate
+var LoadSlot bool = false // F11 was pressed, the frontend should load the savestate
+var UndoLoad bool = false // Shift+F11 was pressed, the frontend should go back to before the last load
+var SelectSlot int = -1 // A number key was pressed, the frontend should switch to that savestate slot
+var Capture bool = false // F12 was pressed, the frontend should capture the next frame
+
+func CheckEvents() {
+	if Output.Driver != "null" && display.ppu != nil {
+		checkKeyboard(display.ppu)
+	}
+}
+
+// Famicom microphone: held key or sound captured over the threshold
+var micKey bool = false
+var mic audio.Microphone
+
+func EnableMicrophone(threshold float64) {
+	mic = audio.OpenMicrophone(threshold)
+}
+
+func checkKeyboard(p *ppu.PPU) {
+for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			crosshairEvent(event)
+			if padEvent(p.IO, event) || gamepadEvent(p.IO, event) {
+				continue
+			}
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				println("Quit")
+				Quit = true
+				Paused = false
+				break
+			case *sdl.WindowEvent:
+				if nametableWindowEvent(e) {
+					break
+				}
+				trackWindow(e)
+				focusEvent(p.IO, e)
+				if e.Event == sdl.WINDOWEVENT_FOCUS_LOST && AutoPause && Paused == false {
+					Paused = true
+					pausedByFocus = true
+				}
+				if e.Event == sdl.WINDOWEVENT_FOCUS_GAINED && pausedByFocus {
+					Paused = false
+					pausedByFocus = false
+				}
+				break
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F2 {
+					CycleShader()
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F3 {
+					CycleBlend()
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F4 {
+					ToggleActivity()
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F5 {
+					dumpAccessLog(p.IO)
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F6 {
+					ToggleInputDisplay()
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F7 {
+					ToggleSourceView()
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F8 {
+					ToggleNametables()
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F9 {
+					cartridge.WriteInfo(os.Stdout, p.IO.CART, mapper.Supported(p.IO.CART.Header.RomType.Mapper))
+					mapper.WriteStatus(os.Stdout, ioports.MapperStatus(p.IO))
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F10 {
+					SaveSlot = true
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F11 {
+					if e.Keysym.Mod & sdl.KMOD_SHIFT != 0 {
+						UndoLoad = true
+					} else {
+						LoadSlot = true
+					}
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_F12 {
+					Capture = true
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym == sdl.K_BACKSPACE {
+					Rewind = true
+				}
+				if e.Type == sdl.KEYDOWN && e.Keysym.Sym >= sdl.K_0 && e.Keysym.Sym <= sdl.K_9 {
+					SelectSlot = int(e.Keysym.Sym - sdl.K_0)
+				}
+				if e.Keysym.Sym == sdl.K_m {
+					micKey = e.Type == sdl.KEYDOWN
+				}
+				break
+			}
+		}
+	pollGamepads(p.IO)
+}
+
+func initCanvas() {
+
+	var winTitle string = "Alphanes"
+	if kmsdrm() {
+		initKMSDRM()
+	}
+	winX, winY, winWidth, winHeight := windowPlacement()
+	var flags uint32 = sdl.WINDOW_SHOWN | sdl.WINDOW_RESIZABLE
+	if Output.Fullscreen {
+		flags |= sdl.WINDOW_FULLSCREEN_DESKTOP
+	}
+	if Output.Shader != "" {
+		flags |= sdl.WINDOW_OPENGL
+	}
+
+	var err error
+	window, err = sdl.CreateWindow(winTitle, winX, winY,
+		winWidth, winHeight, flags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return
+	}
+	rememberWindow()
+
+	if Output.Shader != "" && initGL() {
+		return
+	}
+
+	// Without OpenGL the shaders fall back to the software scanlines
+	if Output.Shader != "" && Output.Shader != "none" {
+		Output.Scanlines = true
+	}
+	initRenderer()
+//	defer renderer.Destroy()
+}
+
+// Writes the register access log to a file in the working directory.
+func dumpAccessLog(IO *ioports.IOPorts) {
+	if IO.ACCESS_LOG.Enable == false {
+		fmt.Printf("The register access log is off (use --iolog)\n")
+		return
+	}
+	file, err := os.Create("alphanes-io.log")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the access log: %s\n", err)
+		return
+	}
+	defer file.Close()
+	debug.DumpAccessLog(&IO.ACCESS_LOG, file)
+	fmt.Printf("Register access log written to alphanes-io.log\n")
+}
+
+func ShowScreen(p *ppu.PPU) {
+	buildFrame(p)
+	drawVirtualPad(p.IO)
+	drawActivity(p.IO)
+	drawInputDisplay(p.IO)
+	drawRecording()
+	drawQuality()
+	drawCrosshair()
+	presentFrame()
+	drawNametables(p)
+}
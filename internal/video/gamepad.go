@@ -0,0 +1,327 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "fmt"
+import "strings"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// Button mappings of the gamepads by SDL GUID, NES button name -> SDL
+// controller button name. The frontend loads them before Start; a device
+// seen for the first time gets the default mapping and sets
+// GamepadProfilesChanged so the frontend can save it.
+var GamepadProfiles = map[string]map[string]string{}
+var GamepadProfilesChanged bool
+
+// NES buttons on an Xbox style layout: B and A on the bottom and right face
+// buttons, where they sit on the NES controller.
+var defaultGamepadProfile = map[string]string{
+	"a": "b", "b": "a", "select": "back", "start": "start",
+	"up": "dpup", "down": "dpdown", "left": "dpleft", "right": "dpright",
+}
+
+// The keyboard always drives the controller in port 1, with or without
+// gamepads.
+var keyboardButtons = map[sdl.Keycode]byte{
+	sdl.K_x: ioports.BUTTON_A,
+	sdl.K_z: ioports.BUTTON_B,
+	sdl.K_RSHIFT: ioports.BUTTON_SELECT,
+	sdl.K_RETURN: ioports.BUTTON_START,
+	sdl.K_UP: ioports.BUTTON_UP,
+	sdl.K_DOWN: ioports.BUTTON_DOWN,
+	sdl.K_LEFT: ioports.BUTTON_LEFT,
+	sdl.K_RIGHT: ioports.BUTTON_RIGHT,
+}
+
+var keyboardHeld byte
+
+// Moves a NES button, by its name in ioports.ButtonNames, to the keyboard
+// key with the SDL name key, like "X" or "Left Shift". The key it had
+// before no longer presses it. Returns false for unknown names.
+func SetKeyboardButton(button string, key string) bool {
+	code := sdl.GetKeyFromName(key)
+	if code == sdl.K_UNKNOWN {
+		return false
+	}
+	for b, name := range ioports.ButtonNames {
+		if name != button {
+			continue
+		}
+		for k, bit := range keyboardButtons {
+			if bit == byte(b) {
+				delete(keyboardButtons, k)
+			}
+		}
+		keyboardButtons[code] = byte(b)
+		return true
+	}
+	return false
+}
+
+// Keeps the gamepads working while the window is not focused, for second
+// screen and streaming setups. SDL may still hold their events back, so
+// their state is also polled every frame while the focus is elsewhere.
+var BackgroundInput bool = false
+var windowFocused bool = true
+
+type gamepad struct {
+	controller *sdl.GameController
+	id sdl.JoystickID
+	name string
+	port int // -1 while both ports are taken by other gamepads
+	buttons map[uint8]byte // SDL controller button -> NES button bits
+	held byte
+	stickX, stickY float64 // Left stick, -1.0 - 1.0
+	stick byte // Directions of the left stick
+}
+
+// Open gamepads in the order they were connected.
+var gamepads []*gamepad
+
+// SDL reports the gamepads already connected as added events, so the ones
+// present at start up go through the same path as the hot-plugged ones.
+func initGamepads() {
+	if BackgroundInput {
+		sdl.SetHint(sdl.HINT_JOYSTICK_ALLOW_BACKGROUND_EVENTS, "1")
+	}
+	if err := sdl.InitSubSystem(sdl.INIT_GAMECONTROLLER); err != nil {
+		fmt.Printf("Gamepads are not available: %s\n", err)
+	}
+}
+
+// Handles the gamepad events. Returns true if it used the event.
+func gamepadEvent(IO *ioports.IOPorts, event sdl.Event) bool {
+	switch e := event.(type) {
+		case *sdl.ControllerDeviceEvent:
+			if e.Type == sdl.CONTROLLERDEVICEADDED {
+				addGamepad(int(e.Which))
+			} else if e.Type == sdl.CONTROLLERDEVICEREMOVED {
+				removeGamepad(e.Which)
+			}
+			applyInputs(IO)
+			return true
+
+		case *sdl.ControllerButtonEvent:
+			g := findGamepad(e.Which)
+			if g == nil {
+				return true
+			}
+			if e.State == sdl.PRESSED {
+				g.held |= g.buttons[e.Button]
+			} else {
+				g.held &^= g.buttons[e.Button]
+			}
+			applyInputs(IO)
+			return true
+
+		case *sdl.ControllerAxisEvent:
+			g := findGamepad(e.Which)
+			if g == nil {
+				return true
+			}
+			stick := g.stick
+			stickAxisEvent(g, e)
+			if g.stick != stick {
+				applyInputs(IO)
+			}
+			return true
+
+		case *sdl.KeyboardEvent:
+			button, found := keyboardButtons[e.Keysym.Sym]
+			if found == false {
+				return false
+			}
+			if e.Type == sdl.KEYDOWN {
+				keyboardHeld |= 1 << button
+			} else {
+				keyboardHeld &^= 1 << button
+			}
+			applyInputs(IO)
+			return true
+	}
+	return false
+}
+
+// The keys held when the window loses the focus are never released to it,
+// so they are let go.
+func focusEvent(IO *ioports.IOPorts, e *sdl.WindowEvent) {
+	switch e.Event {
+		case sdl.WINDOWEVENT_FOCUS_LOST:
+			windowFocused = false
+			keyboardHeld = 0
+			applyInputs(IO)
+		case sdl.WINDOWEVENT_FOCUS_GAINED:
+			windowFocused = true
+	}
+}
+
+// Reads the buttons and the stick of every gamepad, for BackgroundInput.
+func pollGamepads(IO *ioports.IOPorts) {
+	if BackgroundInput == false || windowFocused {
+		return
+	}
+	sdl.GameControllerUpdate()
+	changed := false
+	for _, g := range gamepads {
+		var held byte = 0
+		for button, bits := range g.buttons {
+			if g.controller.Button(sdl.GameControllerButton(button)) == sdl.PRESSED {
+				held |= bits
+			}
+		}
+		g.stickX = float64(g.controller.Axis(sdl.CONTROLLER_AXIS_LEFTX)) / 32767
+		g.stickY = float64(g.controller.Axis(sdl.CONTROLLER_AXIS_LEFTY)) / 32767
+		stick := stickDirections(g.stickX, g.stickY)
+		if held != g.held || stick != g.stick {
+			g.held, g.stick = held, stick
+			changed = true
+		}
+	}
+	if changed {
+		applyInputs(IO)
+	}
+}
+
+func findGamepad(id sdl.JoystickID) *gamepad {
+	for _, g := range gamepads {
+		if g.id == id {
+			return g
+		}
+	}
+	return nil
+}
+
+func addGamepad(index int) {
+	if sdl.IsGameController(index) == false {
+		return
+	}
+	id := sdl.JoystickGetDeviceInstanceID(index)
+	if findGamepad(id) != nil {
+		return
+	}
+	controller := sdl.GameControllerOpen(index)
+	if controller == nil {
+		fmt.Printf("Failed to open gamepad %d: %s\n", index, sdl.GetError())
+		return
+	}
+
+	guid := strings.ToLower(sdl.JoystickGetGUIDString(sdl.JoystickGetDeviceGUID(index)))
+	profile, found := GamepadProfiles[guid]
+	if found == false {
+		profile = make(map[string]string)
+		for button, name := range defaultGamepadProfile {
+			profile[button] = name
+		}
+		GamepadProfiles[guid] = profile
+		GamepadProfilesChanged = true
+	}
+
+	g := &gamepad{controller: controller, id: id, name: controller.Name(), port: freePort()}
+	g.buttons = gamepadButtons(profile)
+	gamepads = append(gamepads, g)
+	announceGamepad(g, "connected")
+}
+
+func removeGamepad(id sdl.JoystickID) {
+	for i, g := range gamepads {
+		if g.id != id {
+			continue
+		}
+		g.controller.Close()
+		gamepads = append(gamepads[:i], gamepads[i+1:]...)
+		fmt.Printf("Gamepad %s disconnected\n", g.name)
+		if g.port < 0 {
+			return
+		}
+		// A gamepad left without a port takes the one that was freed
+		for _, waiting := range gamepads {
+			if waiting.port < 0 {
+				waiting.port = g.port
+				announceGamepad(waiting, "moved")
+				return
+			}
+		}
+		return
+	}
+}
+
+// First controller port without a gamepad, -1 if both have one.
+func freePort() int {
+	for port := 0; port < 2; port++ {
+		if portGamepad(port) == nil {
+			return port
+		}
+	}
+	return -1
+}
+
+func portGamepad(port int) *gamepad {
+	for _, g := range gamepads {
+		if g.port == port {
+			return g
+		}
+	}
+	return nil
+}
+
+func announceGamepad(g *gamepad, what string) {
+	if g.port < 0 {
+		fmt.Printf("Gamepad %s %s, both ports are taken\n", g.name, what)
+		return
+	}
+	fmt.Printf("Gamepad %s %s to port %d\n", g.name, what, g.port+1)
+}
+
+func gamepadButtons(profile map[string]string) map[uint8]byte {
+	buttons := make(map[uint8]byte)
+	for b, name := range ioports.ButtonNames {
+		control, found := profile[name]
+		if found == false {
+			continue
+		}
+		sdlButton := sdl.GameControllerGetButtonFromString(control)
+		if sdlButton == sdl.CONTROLLER_BUTTON_INVALID {
+			fmt.Printf("Gamepad: unknown button %s for %s\n", control, name)
+			continue
+		}
+		buttons[uint8(sdlButton)] |= 1 << uint(b)
+	}
+	return buttons
+}
+
+// Combines the keyboard, the on-screen pad and the gamepads into the
+// buttons of both controllers.
+func applyInputs(IO *ioports.IOPorts) {
+	var held [2]byte
+	held[0] = keyboardHeld | padHeld()
+	for _, g := range gamepads {
+		if g.port >= 0 {
+			held[g.port] |= g.held | g.stick
+		}
+	}
+	for port := 0; port < 2; port++ {
+		held[port] = blockOpposing(port, held[port])
+		for b := byte(0); b < 8; b++ {
+			ioports.SetButton(IO, port, b, (held[port] >> b) & 1 == 1)
+		}
+	}
+}
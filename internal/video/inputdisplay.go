@@ -0,0 +1,92 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// Input viewer for streams and movie verification. It shows the buttons the
+// game latched on its last controller strobe, so it matches what the game
+// saw in that frame rather than the live keyboard. The frame, lag frame
+// and latch counters are shown in the top left corner, each after a mark
+// of its color: white, red and blue.
+
+var ShowInputDisplay bool = false
+
+// Button positions inside a 38x14 controller box.
+var inputDisplayButtons = []PadArea{
+	{sdl.Rect{X: 6, Y: 2, W: 3, H: 3}, ioports.BUTTON_UP},
+	{sdl.Rect{X: 6, Y: 8, W: 3, H: 3}, ioports.BUTTON_DOWN},
+	{sdl.Rect{X: 3, Y: 5, W: 3, H: 3}, ioports.BUTTON_LEFT},
+	{sdl.Rect{X: 9, Y: 5, W: 3, H: 3}, ioports.BUTTON_RIGHT},
+	{sdl.Rect{X: 14, Y: 8, W: 4, H: 2}, ioports.BUTTON_SELECT},
+	{sdl.Rect{X: 20, Y: 8, W: 4, H: 2}, ioports.BUTTON_START},
+	{sdl.Rect{X: 27, Y: 6, W: 3, H: 3}, ioports.BUTTON_B},
+	{sdl.Rect{X: 32, Y: 6, W: 3, H: 3}, ioports.BUTTON_A},
+}
+
+func ToggleInputDisplay() {
+	ShowInputDisplay = !ShowInputDisplay
+}
+
+func drawInputDisplay(IO *ioports.IOPorts) {
+
+	if ShowInputDisplay == false {
+		return
+	}
+
+	// Player 1 at the bottom left, player 2 at the bottom right
+	for port := 0; port < 2; port++ {
+		var x int32 = 4
+		if port == 1 {
+			x = 256 - 4 - 38
+		}
+		var y int32 = 240 - 4 - 14
+
+		frameFillRect(sdl.Rect{X: x, Y: y, W: 38, H: 14}, 0, 0, 0, 160)
+		for _, b := range inputDisplayButtons {
+			rect := sdl.Rect{X: x + b.Rect.X, Y: y + b.Rect.Y, W: b.Rect.W, H: b.Rect.H}
+			if (IO.JOYPAD[port].LATCHED >> b.Button) & 1 == 1 {
+				frameFillRect(rect, 255, 60, 60, 255)
+			} else {
+				frameFillRect(rect, 120, 120, 120, 255)
+			}
+		}
+	}
+	drawCounters(IO)
+}
+
+func drawCounters(IO *ioports.IOPorts) {
+	counters := []struct {
+		n uint64
+		r, g, b byte
+	}{
+		{IO.COUNTERS.FRAMES, 255, 255, 255},
+		{IO.COUNTERS.LAG_FRAMES, 255, 60, 60},
+		{IO.COUNTERS.LATCHES, 80, 140, 255},
+	}
+	frameFillRect(sdl.Rect{X: 4, Y: 4, W: 40, H: 23}, 0, 0, 0, 160)
+	for i, c := range counters {
+		y := int32(6 + i*7)
+		frameFillRect(sdl.Rect{X: 6, Y: y, W: 2, H: 5}, c.r, c.g, c.b, 255)
+		// Seven digits fit, the lowest ones are kept past that
+		drawNumber(int(c.n % 10000000), 4 + 40 - 1, y)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "fmt"
+import "os"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// The kmsdrm driver draws straight to the display through SDL KMSDRM, with
+// no X11 or Wayland, for the Raspberry Pi and similar boards. The window is
+// always the whole screen. Instead of a logical size, which has SDL scale
+// every draw call, the frame is placed once at the largest integer scale
+// that fits and copied there from the one streaming texture.
+
+// Top left corner of the output in renderer pixels.
+var frameOrigin sdl.Point
+
+func kmsdrm() bool {
+	return Output.Driver == "kmsdrm"
+}
+
+// Selects the SDL video driver before the first window is created.
+func initKMSDRM() {
+	os.Setenv("SDL_VIDEODRIVER", "kmsdrm")
+	Output.Fullscreen = true
+	// Shaders need desktop OpenGL, the scanline overlay takes their place
+	if Output.Shader != "" && Output.Shader != "none" {
+		Output.Scanlines = true
+	}
+	Output.Shader = ""
+}
+
+func fitDisplay() {
+	dw, dh, err := renderer.GetOutputSize()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get the display size: %s\n", err)
+		return
+	}
+	Output.Scale = 1
+	for {
+		Output.Scale++
+		w, h := outputSize()
+		if w > dw || h > dh {
+			Output.Scale--
+			break
+		}
+	}
+	w, h := outputSize()
+	frameOrigin = sdl.Point{X: (dw - w) / 2, Y: (dh - h) / 2}
+	fmt.Printf("KMSDRM: %dx%d display, scale %d\n", dw, dh, Output.Scale)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "fmt"
+import "os"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+import "unsafe"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// Second window with the four nametables side by side and the area the
+// screen shows drawn over them, one row per scanline, so mid-frame scroll
+// changes show up as steps in the outline. Mirroring switches made by the
+// mapper while the screen was drawn are marked across the outline in
+// yellow, at the scanline they happened on. It uses the software renderer
+// to stay away from the OpenGL context of the main window.
+
+var ShowNametables bool = false
+var nametableWindow *sdl.Window
+var nametableRenderer *sdl.Renderer
+var nametableTexture *sdl.Texture
+var nametableFrame = make([]byte, 512*480*4)
+
+func ToggleNametables() {
+	ShowNametables = !ShowNametables
+	if nametableWindow == nil {
+		return
+	}
+	if ShowNametables {
+		nametableWindow.Show()
+	} else {
+		nametableWindow.Hide()
+	}
+}
+
+func openNametableWindow() bool {
+	var err error
+	nametableWindow, err = sdl.CreateWindow("Alphanes - Nametables", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		512, 480, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create the nametable window: %s\n", err)
+		ShowNametables = false
+		return false
+	}
+	nametableRenderer, err = sdl.CreateRenderer(nametableWindow, -1, sdl.RENDERER_SOFTWARE)
+	if err == nil {
+		nametableTexture, err = nametableRenderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STREAMING, 512, 480)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create the nametable renderer: %s\n", err)
+		nametableWindow.Destroy()
+		nametableWindow = nil
+		ShowNametables = false
+		return false
+	}
+	return true
+}
+
+// Closing the viewer only hides it. Returns true if the event belonged to it.
+func nametableWindowEvent(e *sdl.WindowEvent) bool {
+	if nametableWindow == nil {
+		return false
+	}
+	id, _ := nametableWindow.GetID()
+	if e.WindowID != id {
+		return false
+	}
+	if e.Event == sdl.WINDOWEVENT_CLOSE && ShowNametables {
+		ToggleNametables()
+	}
+	return true
+}
+
+func nametablePixel(x int, y int, r byte, g byte, b byte) {
+	i := ((x % 512) + ((y % 480) * 512)) * 4
+	nametableFrame[i] = b
+	nametableFrame[i+1] = g
+	nametableFrame[i+2] = r
+	nametableFrame[i+3] = 255
+}
+
+func drawNametable(p *ppu.PPU, table int) {
+	base := uint16(0x2000 + table*0x400)
+	ox := (table % 2) * 256
+	oy := (table / 2) * 240
+
+	for ty := 0; ty < 30; ty++ {
+		for tx := 0; tx < 32; tx++ {
+			index := ppu.ReadPPURam(p, base + uint16(tx + ty*32))
+			attr := ppu.ReadPPURam(p, base + 0x3C0 + uint16((tx/4) + (ty/4)*8))
+			pal := (attr >> uint(((ty%4)/2)*4 + ((tx%4)/2)*2)) & 3
+			tile := ppu.FetchTile(p, index, p.IO.PPUCTRL.BACKGROUND_ADDR)
+
+			for ky := 0; ky < 8; ky++ {
+				for kx := 0; kx < 8; kx++ {
+					c := p.IO.PPU_RAM[0x3F00]
+					if tile[kx][ky] != 0 {
+						c = ppu.ReadPPURam(p, 0x3F00 + uint16(pal)*4 + uint16(tile[kx][ky]))
+					}
+					r, g, b := ppu.PaletteColor(int(c))
+					nametablePixel(ox + tx*8 + kx, oy + ty*8 + ky, r, g, b)
+				}
+			}
+		}
+	}
+}
+
+func drawNametables(p *ppu.PPU) {
+
+	if ShowNametables == false || Output.Driver == "null" {
+		return
+	}
+	if nametableWindow == nil && openNametableWindow() == false {
+		return
+	}
+
+	for table := 0; table < 4; table++ {
+		drawNametable(p, table)
+	}
+
+	// Left and right edge of every scanline, top and bottom rows in full
+	for line := 0; line < 240; line++ {
+		x, y := ppu.ScrollPosition(p.SCROLL_LINES[line])
+		if line == 0 || line == 239 {
+			for i := 0; i < 256; i++ {
+				nametablePixel(x + i, y, 255, 40, 40)
+			}
+		} else {
+			nametablePixel(x, y, 255, 40, 40)
+			nametablePixel(x + 255, y, 255, 40, 40)
+		}
+	}
+
+	for _, change := range p.IO.MIRRORING_CHANGES {
+		if change.SCANLINE < 0 || change.SCANLINE > 239 {
+			continue
+		}
+		x, y := ppu.ScrollPosition(p.SCROLL_LINES[change.SCANLINE])
+		for i := 0; i < 256; i++ {
+			nametablePixel(x + i, y, 255, 255, 0)
+		}
+	}
+
+	nametableTexture.Update(nil, unsafe.Pointer(&nametableFrame[0]), 512*4)
+	nametableRenderer.Clear()
+	nametableRenderer.Copy(nametableTexture, nil, nil)
+	nametableRenderer.Present()
+}
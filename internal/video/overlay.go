@@ -0,0 +1,145 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// On-screen controller composited into the presentation buffer. Each hit area maps
+// a touch or mouse press to a button of the controller in port 1.
+type VirtualPad struct {
+	Enable bool
+	Areas []PadArea
+	Pressed map[int64]byte // Pointer id -> buttons held by that pointer
+}
+
+type PadArea struct {
+	Rect sdl.Rect
+	Button byte
+}
+
+// Mouse presses use this pointer id, touches use their finger id.
+const mousePointer int64 = -1
+
+var pad VirtualPad
+
+func EnableVirtualPad() {
+	pad.Enable = true
+	pad.Pressed = make(map[int64]byte)
+	pad.Areas = []PadArea{
+		{sdl.Rect{X: 24, Y: 160, W: 16, H: 16}, ioports.BUTTON_UP},
+		{sdl.Rect{X: 24, Y: 192, W: 16, H: 16}, ioports.BUTTON_DOWN},
+		{sdl.Rect{X: 8, Y: 176, W: 16, H: 16}, ioports.BUTTON_LEFT},
+		{sdl.Rect{X: 40, Y: 176, W: 16, H: 16}, ioports.BUTTON_RIGHT},
+		{sdl.Rect{X: 96, Y: 212, W: 24, H: 10}, ioports.BUTTON_SELECT},
+		{sdl.Rect{X: 136, Y: 212, W: 24, H: 10}, ioports.BUTTON_START},
+		{sdl.Rect{X: 192, Y: 184, W: 20, H: 20}, ioports.BUTTON_B},
+		{sdl.Rect{X: 224, Y: 168, W: 20, H: 20}, ioports.BUTTON_A},
+	}
+}
+
+func padButtonAt(x int32, y int32) (bool, byte) {
+	for _, a := range pad.Areas {
+		if x >= a.Rect.X && x < a.Rect.X+a.Rect.W && y >= a.Rect.Y && y < a.Rect.Y+a.Rect.H {
+			return true, a.Button
+		}
+	}
+	return false, 0
+}
+
+func padPress(IO *ioports.IOPorts, pointer int64, x int32, y int32) {
+	pad.Pressed[pointer] = 0
+	found, button := padButtonAt(x, y)
+	if found {
+		pad.Pressed[pointer] = 1 << button
+	}
+	padApply(IO)
+}
+
+func padRelease(IO *ioports.IOPorts, pointer int64) {
+	delete(pad.Pressed, pointer)
+	padApply(IO)
+}
+
+func padApply(IO *ioports.IOPorts) {
+	applyInputs(IO)
+}
+
+func padHeld() byte {
+	var held byte = 0
+	for _, buttons := range pad.Pressed {
+		held |= buttons
+	}
+	return held
+}
+
+// Feeds mouse and touch events to the pad. Returns true if it used the event.
+func padEvent(IO *ioports.IOPorts, event sdl.Event) bool {
+
+	if pad.Enable == false {
+		return false
+	}
+
+	switch e := event.(type) {
+		case *sdl.MouseButtonEvent:
+			if e.Button != sdl.BUTTON_LEFT { return false }
+			if e.State == sdl.PRESSED {
+				x, y := outputToScreen((e.X - frameOrigin.X) / Output.Scale, (e.Y - frameOrigin.Y) / Output.Scale)
+				padPress(IO, mousePointer, x, y)
+			} else {
+				padRelease(IO, mousePointer)
+			}
+			return true
+
+		case *sdl.MouseMotionEvent:
+			if _, held := pad.Pressed[mousePointer]; held {
+				x, y := outputToScreen((e.X - frameOrigin.X) / Output.Scale, (e.Y - frameOrigin.Y) / Output.Scale)
+				padPress(IO, mousePointer, x, y)
+			}
+			return true
+
+		case *sdl.TouchFingerEvent:
+			// Touch coordinates are normalized to the window size
+			w, h := outputSize()
+			x, y := outputToScreen(int32(e.X * float32(w / Output.Scale)), int32(e.Y * float32(h / Output.Scale)))
+			if e.Type == sdl.FINGERUP {
+				padRelease(IO, int64(e.FingerID))
+			} else {
+				padPress(IO, int64(e.FingerID), x, y)
+			}
+			return true
+	}
+	return false
+}
+
+func drawVirtualPad(IO *ioports.IOPorts) {
+
+	if pad.Enable == false {
+		return
+	}
+
+	for _, a := range pad.Areas {
+		if (IO.JOYPAD[0].BUTTONS >> a.Button) & 1 == 1 {
+			frameFillRect(a.Rect, 255, 255, 255, 160)
+		} else {
+			frameFillRect(a.Rect, 255, 255, 255, 64)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "fmt"
+import "os"
+import "strconv"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+import "unsafe"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// Output options. The frontend sets them before calling Start.
+type Presentation struct {
+	Driver string // "sdl" or "null" (no window, frames are still built)
+	Scale int32 // Window size in multiples of 256x240
+	Shader string // Built-in shader name or GLSL fragment shader file, "" for none
+	Scanlines bool // Software scanline overlay used when OpenGL is not available
+	Rotation int // Clockwise output rotation: 0, 90, 180 or 270 degrees
+	Mirror bool // Horizontal mirroring, applied after the rotation
+	Blend int // Flicker reduction, one of the BLEND_ modes
+	Fullscreen bool // Borderless fullscreen at the desktop resolution
+	Display int // Monitor the window opens on, -1 for the remembered position
+	Window WindowGeometry // Windowed position and size, kept up to date while running
+}
+
+const (
+	BLEND_NONE = 0
+	BLEND_MIX = 1 // 50% mix of the current and the previous frame
+	BLEND_FUSION = 2 // Sprite pixels of the previous frame are kept
+)
+
+var blendNames = []string{"none", "mix", "fusion"}
+
+var Output = Presentation{Driver: "sdl", Scale: 2, Display: -1}
+
+// ARGB8888 presentation buffer. The NES framebuffer (SCREEN_DATA) is
+// converted into it and the overlays are composited on top of it.
+var frame = make([]byte, 256*240*4)
+var previousFrame = make([]byte, 256*240*4) // Unblended copy of the last frame, for BLEND_MIX
+var texture *sdl.Texture
+var presentRect sdl.Rect // Kept here, a local passed to SDL escapes to the heap every frame
+
+func initRenderer() {
+	var err error
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return
+	}
+	if kmsdrm() {
+		fitDisplay()
+	} else {
+		// Resized and fullscreen windows are letterboxed around the frame
+		w, h := outputSize()
+		renderer.SetLogicalSize(w, h)
+	}
+	texture, err = renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STREAMING, 256, 240)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create texture: %s\n", err)
+	}
+}
+
+func framePixel(x int, y int, r byte, g byte, b byte) {
+	i := (x + (y*256)) * 4
+	frame[i] = b
+	frame[i+1] = g
+	frame[i+2] = r
+	frame[i+3] = 255
+}
+
+// Alpha blends a color over the presentation buffer.
+func frameBlend(x int, y int, r byte, g byte, b byte, a byte) {
+	if x < 0 || y < 0 || x >= 256 || y >= 240 {
+		return
+	}
+	i := (x + (y*256)) * 4
+	frame[i] = mix(frame[i], b, a)
+	frame[i+1] = mix(frame[i+1], g, a)
+	frame[i+2] = mix(frame[i+2], r, a)
+}
+
+func mix(dst byte, src byte, a byte) byte {
+	return byte((int(src)*int(a) + int(dst)*(255-int(a))) / 255)
+}
+
+func frameFillRect(rect sdl.Rect, r byte, g byte, b byte, a byte) {
+	for y := rect.Y; y < rect.Y+rect.H; y++ {
+		for x := rect.X; x < rect.X+rect.W; x++ {
+			frameBlend(int(x), int(y), r, g, b, a)
+		}
+	}
+}
+
+func buildFrame(p *ppu.PPU) {
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 256; x++ {
+			c := ppu.READ_SCREEN(p, x, y)
+
+			// Flicker fusion: sprites drawn only in the previous frame
+			// are shown again on top of the current one.
+			i := x + (y*256)
+			if Output.Blend == BLEND_FUSION && p.SPRITE_LAYER[i] < 0 && p.PREVIOUS_SPRITE_LAYER[i] >= 0 {
+				c = p.PREVIOUS_SPRITE_LAYER[i]
+			}
+
+			if ShowSources {
+				r, g, b := sourceColor(p.SOURCE_LAYER[i])
+				framePixel(x, y, r, g, b)
+			} else if c == 0 {
+				framePixel(x, y, 0, 0, 0)
+			} else {
+				r, g, b := ppu.PaletteColor(c)
+				framePixel(x, y, r, g, b)
+			}
+		}
+	}
+
+	if Output.Blend == BLEND_MIX {
+		for i := 0; i < len(frame); i++ {
+			current := frame[i]
+			frame[i] = byte((int(current) + int(previousFrame[i])) / 2)
+			previousFrame[i] = current
+		}
+	}
+}
+
+func SetBlend(name string) bool {
+	for mode, n := range blendNames {
+		if n == name {
+			Output.Blend = mode
+			return true
+		}
+	}
+	fmt.Printf("Invalid blend mode: %s (use none, mix or fusion)\n", name)
+	return false
+}
+
+// Switches to the next flicker reduction mode.
+func CycleBlend() {
+	Output.Blend = (Output.Blend + 1) % len(blendNames)
+	fmt.Printf("Blend: %s\n", blendNames[Output.Blend])
+}
+
+func presentFrame() {
+
+	if Output.Driver == "null" {
+		return
+	}
+
+	if glReady {
+		glPresent()
+		return
+	}
+
+	renderer.SetDrawColor(0, 0, 0, 255)
+	renderer.Clear()
+	texture.Update(nil, unsafe.Pointer(&frame[0]), 256*4)
+
+	// CopyEx rotates around the center of the destination, so the frame
+	// keeps its unrotated size centered in the (possibly swapped) window.
+	w, h := outputSize()
+	presentRect = sdl.Rect{X: frameOrigin.X + (w - 256*Output.Scale) / 2, Y: frameOrigin.Y + (h - 240*Output.Scale) / 2, W: 256*Output.Scale, H: 240*Output.Scale}
+	if Output.Rotation == 0 && Output.Mirror == false {
+		renderer.Copy(texture, nil, &presentRect)
+	} else {
+		// SDL flips the texture before rotating it, so a sideways
+		// output mirrors along the other axis of the texture
+		var flip sdl.RendererFlip = sdl.FLIP_NONE
+		if Output.Mirror && sideways() {
+			flip = sdl.FLIP_VERTICAL
+		} else if Output.Mirror {
+			flip = sdl.FLIP_HORIZONTAL
+		}
+		renderer.CopyEx(texture, nil, &presentRect, float64(Output.Rotation), nil, flip)
+	}
+
+	if Output.Scanlines {
+		drawScanlines()
+	}
+	renderer.Present()
+}
+
+func SetRotation(degrees string) bool {
+	r, err := strconv.Atoi(degrees)
+	if err != nil || r < 0 || r >= 360 || r%90 != 0 {
+		fmt.Printf("Invalid rotation: %s (use 0, 90, 180 or 270)\n", degrees)
+		return false
+	}
+	Output.Rotation = r
+	return true
+}
+
+// True when the output is rotated by 90 or 270 degrees.
+func sideways() bool {
+	return Output.Rotation == 90 || Output.Rotation == 270
+}
+
+// Window size in pixels.
+func outputSize() (int32, int32) {
+	if sideways() {
+		return 240*Output.Scale, 256*Output.Scale
+	}
+	return 256*Output.Scale, 240*Output.Scale
+}
+
+// Maps a point of the output, in NES pixels, back to the NES framebuffer.
+func outputToScreen(x int32, y int32) (int32, int32) {
+	w, _ := outputSize()
+	if Output.Mirror {
+		x = (w / Output.Scale) - 1 - x
+	}
+	switch(Output.Rotation) {
+		case 90:
+			return y, 239 - x
+		case 180:
+			return 255 - x, 239 - y
+		case 270:
+			return 255 - y, x
+	}
+	return x, y
+}
+
+// Darkens the last output line of each scaled NES line.
+func drawScanlines() {
+	if Output.Scale < 2 {
+		return
+	}
+	renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	renderer.SetDrawColor(0, 0, 0, 96)
+	w, h := outputSize()
+	for i := int32(0); i < 240; i++ {
+		line := (i * Output.Scale) + Output.Scale - 1
+		x, y := frameOrigin.X, frameOrigin.Y
+		if sideways() {
+			renderer.DrawLine(x+line, y, x+line, y+h-1)
+		} else {
+			renderer.DrawLine(x, y+line, x+w-1, y+line)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// Adaptive quality indicator: an amber dot in the top left corner while the
+// frontend runs with the performance options because the host was too slow.
+
+var ReducedQuality bool = false
+
+func drawQuality() {
+
+	if ReducedQuality == false {
+		return
+	}
+
+	frameFillRect(sdl.Rect{X: 4, Y: 4, W: 10, H: 10}, 0, 0, 0, 160)
+	frameFillRect(sdl.Rect{X: 6, Y: 7, W: 6, H: 4}, 240, 170, 20, 255)
+	frameFillRect(sdl.Rect{X: 7, Y: 6, W: 4, H: 6}, 240, 170, 20, 255)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// Movie recording indicator: a red dot in the top right corner with the
+// re-record count under it, drawn with a 3x5 digit font.
+
+var Recording bool = false
+var Rerecords int = 0
+
+// Rows of each digit, three bits per row with the leftmost pixel highest.
+var digitFont = [10][5]byte{
+	{7, 5, 5, 5, 7},
+	{2, 6, 2, 2, 7},
+	{7, 1, 7, 4, 7},
+	{7, 1, 3, 1, 7},
+	{5, 5, 7, 1, 1},
+	{7, 4, 7, 1, 7},
+	{7, 4, 7, 5, 7},
+	{7, 1, 1, 1, 1},
+	{7, 5, 7, 5, 7},
+	{7, 5, 7, 1, 7},
+}
+
+// Draws a number with its last digit ending at x.
+func drawNumber(n int, x int32, y int32) {
+	for {
+		x -= 4
+		glyph := digitFont[n % 10]
+		for row := int32(0); row < 5; row++ {
+			for col := int32(0); col < 3; col++ {
+				if (glyph[row] >> uint(2 - col)) & 1 == 1 {
+					frameFillRect(sdl.Rect{X: x + col, Y: y + row, W: 1, H: 1}, 255, 255, 255, 255)
+				}
+			}
+		}
+		n /= 10
+		if n == 0 {
+			return
+		}
+	}
+}
+
+func drawRecording() {
+
+	if Recording == false {
+		return
+	}
+
+	frameFillRect(sdl.Rect{X: 256 - 4 - 20, Y: 4, W: 20, H: 16}, 0, 0, 0, 160)
+	frameFillRect(sdl.Rect{X: 256 - 4 - 13, Y: 6, W: 6, H: 4}, 230, 30, 30, 255)
+	frameFillRect(sdl.Rect{X: 256 - 4 - 12, Y: 5, W: 4, H: 6}, 230, 30, 30, 255)
+	drawNumber(Rerecords, 256 - 4 - 1, 13)
+}
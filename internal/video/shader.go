@@ -0,0 +1,282 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "fmt"
+import "io/ioutil"
+import "os"
+import "strings"
+
+import "github.com/go-gl/gl/v2.1/gl"
+import "github.com/veandco/go-sdl2/sdl"
+
+// OpenGL presentation path. The presentation buffer is uploaded into a
+// texture and drawn through a single-pass GLSL fragment shader.
+
+const vertexShader = `
+#version 120
+varying vec2 uv;
+void main() {
+	uv = gl_MultiTexCoord0.xy;
+	gl_Position = gl_Vertex;
+}
+`
+
+const shaderHeader = `
+#version 120
+uniform sampler2D tex;
+uniform vec2 source_size;
+uniform vec2 output_size;
+varying vec2 uv;
+`
+
+var builtinShaders = map[string]string{
+	"none": `
+void main() {
+	gl_FragColor = texture2D(tex, uv);
+}
+`,
+	"scanlines": `
+void main() {
+	vec3 color = texture2D(tex, uv).rgb;
+	float line = fract(uv.y * source_size.y);
+	color *= 0.7 + 0.3 * smoothstep(0.0, 0.5, 1.0 - abs(line - 0.5) * 2.0);
+	gl_FragColor = vec4(color, 1.0);
+}
+`,
+	"crt": `
+vec2 curve(vec2 p) {
+	p = p * 2.0 - 1.0;
+	p *= 1.0 + vec2(p.y * p.y, p.x * p.x) * 0.04;
+	return p * 0.5 + 0.5;
+}
+void main() {
+	vec2 p = curve(uv);
+	if (p.x < 0.0 || p.y < 0.0 || p.x > 1.0 || p.y > 1.0) {
+		gl_FragColor = vec4(0.0, 0.0, 0.0, 1.0);
+		return;
+	}
+	vec3 color = texture2D(tex, p).rgb;
+	float line = sin(p.y * source_size.y * 6.2831853) * 0.5 + 0.5;
+	color *= 0.75 + 0.25 * line;
+	float mask = mod(floor(gl_FragCoord.x), 3.0);
+	color *= vec3(mask == 0.0 ? 1.0 : 0.85, mask == 1.0 ? 1.0 : 0.85, mask == 2.0 ? 1.0 : 0.85);
+	gl_FragColor = vec4(color * 1.15, 1.0);
+}
+`,
+	"sharp-bilinear": `
+void main() {
+	vec2 texel = uv * source_size;
+	vec2 scale = floor(output_size / source_size);
+	vec2 region = 0.5 - 0.5 / scale;
+	vec2 f = fract(texel) - 0.5;
+	vec2 offset = (f - clamp(f, -region, region)) * scale + 0.5;
+	gl_FragColor = texture2D(tex, (floor(texel) + offset) / source_size);
+}
+`,
+	"lcd": `
+void main() {
+	vec3 color = texture2D(tex, uv).rgb;
+	vec2 cell = fract(uv * source_size);
+	float grid = step(0.1, cell.x) * step(0.1, cell.y);
+	gl_FragColor = vec4(color * (0.75 + 0.25 * grid), 1.0);
+}
+`,
+}
+
+// Order used when cycling through the shaders at runtime.
+var shaderNames = []string{"none", "scanlines", "crt", "sharp-bilinear", "lcd"}
+
+var glReady bool = false
+var glContext sdl.GLContext
+var glTexture uint32
+var glProgram uint32
+var glUniforms [3]int32 // tex, source_size and output_size of glProgram
+var currentShader string
+
+// Creates the OpenGL context. Returns false if the plain renderer must be used.
+func initGL() bool {
+
+	sdl.GLSetAttribute(sdl.GL_CONTEXT_MAJOR_VERSION, 2)
+	sdl.GLSetAttribute(sdl.GL_CONTEXT_MINOR_VERSION, 1)
+
+	var err error
+	glContext, err = window.GLCreateContext()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create OpenGL context: %s\n", err)
+		return false
+	}
+	if err = gl.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize OpenGL: %s\n", err)
+		sdl.GLDeleteContext(glContext)
+		return false
+	}
+	sdl.GLSetSwapInterval(1)
+
+	gl.Enable(gl.TEXTURE_2D)
+	gl.GenTextures(1, &glTexture)
+	gl.BindTexture(gl.TEXTURE_2D, glTexture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, 256, 240, 0, gl.BGRA, gl.UNSIGNED_BYTE, gl.Ptr(frame))
+
+	if loadShader(Output.Shader) == false && loadShader("none") == false {
+		return false
+	}
+	glReady = true
+	return true
+}
+
+// Loads a built-in shader by name or a fragment shader from a file.
+func loadShader(name string) bool {
+
+	source, builtin := builtinShaders[name]
+	if builtin == false {
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			fmt.Printf("Unknown shader: %s\n", name)
+			return false
+		}
+		source = string(content)
+	}
+	if strings.Contains(source, "#version") == false {
+		source = shaderHeader + source
+	}
+
+	program, err := linkProgram(vertexShader, source)
+	if err != nil {
+		fmt.Printf("Shader %s: %s\n", name, err)
+		return false
+	}
+	if glProgram != 0 {
+		gl.DeleteProgram(glProgram)
+	}
+	glProgram = program
+	glUniforms[0] = gl.GetUniformLocation(program, gl.Str("tex\x00"))
+	glUniforms[1] = gl.GetUniformLocation(program, gl.Str("source_size\x00"))
+	glUniforms[2] = gl.GetUniformLocation(program, gl.Str("output_size\x00"))
+	currentShader = name
+	fmt.Printf("Shader: %s\n", name)
+	return true
+}
+
+func compileShader(source string, kind uint32) (uint32, error) {
+	shader := gl.CreateShader(kind)
+	csource, free := gl.Strs(source + "\x00")
+	gl.ShaderSource(shader, 1, csource, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var length int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &length)
+		log := strings.Repeat("\x00", int(length+1))
+		gl.GetShaderInfoLog(shader, length, nil, gl.Str(log))
+		gl.DeleteShader(shader)
+		return 0, fmt.Errorf("compile error: %s", log)
+	}
+	return shader, nil
+}
+
+func linkProgram(vertex string, fragment string) (uint32, error) {
+	vs, err := compileShader(vertex, gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, err
+	}
+	fs, err := compileShader(fragment, gl.FRAGMENT_SHADER)
+	if err != nil {
+		gl.DeleteShader(vs)
+		return 0, err
+	}
+
+	program := gl.CreateProgram()
+	gl.AttachShader(program, vs)
+	gl.AttachShader(program, fs)
+	gl.LinkProgram(program)
+	gl.DeleteShader(vs)
+	gl.DeleteShader(fs)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		gl.DeleteProgram(program)
+		return 0, fmt.Errorf("link error")
+	}
+	return program, nil
+}
+
+// Switches to the next built-in shader, or toggles the software scanlines
+// when running on the plain renderer.
+func CycleShader() {
+
+	if glReady == false {
+		Output.Scanlines = !Output.Scanlines
+		return
+	}
+
+	next := 0
+	for i, name := range shaderNames {
+		if name == currentShader {
+			next = (i + 1) % len(shaderNames)
+		}
+	}
+	loadShader(shaderNames[next])
+}
+
+func glPresent() {
+
+	w, h := window.GLGetDrawableSize()
+	gl.Viewport(0, 0, w, h)
+	gl.ClearColor(0, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	x, y, w, h := letterbox(w, h)
+	gl.Viewport(x, y, w, h)
+
+	gl.BindTexture(gl.TEXTURE_2D, glTexture)
+	// A pointer, unlike the slice, fits in the interface without allocating
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, 256, 240, gl.BGRA, gl.UNSIGNED_BYTE, gl.Ptr(&frame[0]))
+
+	gl.UseProgram(glProgram)
+	gl.Uniform1i(glUniforms[0], 0)
+	gl.Uniform2f(glUniforms[1], 256, 240)
+	gl.Uniform2f(glUniforms[2], float32(w), float32(h))
+
+	// Corners in counterclockwise order starting at the bottom left. The
+	// texture coordinates are shifted around them to rotate the output.
+	corners := [4][2]float32{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}
+	coords := [4][2]float32{{0, 1}, {1, 1}, {1, 0}, {0, 0}}
+	steps := Output.Rotation / 90
+	gl.Begin(gl.QUADS)
+	for k := 0; k < 4; k++ {
+		c := (k + steps) % 4
+		x := corners[k][0]
+		if Output.Mirror {
+			x = -x
+		}
+		gl.TexCoord2f(coords[c][0], coords[c][1])
+		gl.Vertex2f(x, corners[k][1])
+	}
+	gl.End()
+
+	window.GLSwap()
+}
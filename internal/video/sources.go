@@ -0,0 +1,69 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+
+// Diagnostic view that colors every pixel by what drew it instead of by its
+// palette entry. Background pixels are shades of blue by attribute palette,
+// sprites get a hue by OAM slot and sprite 0 is always white, which makes
+// priority, sprite 0 and clipping problems easy to spot.
+
+var ShowSources bool = false
+
+func ToggleSourceView() {
+	ShowSources = !ShowSources
+}
+
+func sourceColor(source int) (byte, byte, byte) {
+
+	if source == ppu.SOURCE_BACKDROP {
+		return 40, 40, 40
+	}
+
+	if source < ppu.SOURCE_SPRITE {
+		pal := byte(source - ppu.SOURCE_BACKGROUND)
+		return 0, 40 + pal*30, 120 + pal*40
+	}
+
+	slot := source - ppu.SOURCE_SPRITE
+	if slot == 0 {
+		return 255, 255, 255
+	}
+	// The hues repeat on each page of the extended OAM
+	return hue((slot % 64) * 360 / 64)
+}
+
+// Fully saturated color for a hue in degrees.
+func hue(degrees int) (byte, byte, byte) {
+	x := byte((degrees % 60) * 255 / 60)
+	switch degrees / 60 {
+		case 0:
+			return 255, x, 0
+		case 1:
+			return 255 - x, 255, 0
+		case 2:
+			return 0, 255, x
+		case 3:
+			return 0, 255 - x, 255
+		case 4:
+			return x, 0, 255
+	}
+	return 255, 0, 255 - x
+}
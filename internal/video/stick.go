@@ -0,0 +1,165 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package video
+
+import "fmt"
+import "math"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// How the left stick of the gamepads drives the D-pad, and what reaches the
+// game when opposing directions are held. The frontend sets them before
+// Start.
+type InputOptions struct {
+	DeadZone float64 // Fraction of the full deflection that is ignored, 0.0 - 1.0
+	StickMode int // One of the STICK_ modes
+	FourWay bool // Only one direction at a time, never a diagonal
+	Opposing int // One of the OPPOSING_ modes, applied to all the inputs
+}
+
+const (
+	STICK_RADIAL = 0 // The direction follows the angle of the stick
+	STICK_AXIAL = 1 // Each axis is pressed on its own past the dead zone
+)
+
+const (
+	OPPOSING_ALLOW = 0 // Left+Right and Up+Down reach the game
+	OPPOSING_NEUTRAL = 1 // Both directions of an opposing pair are released
+	OPPOSING_LAST = 2 // The direction pressed last is kept
+)
+
+var stickNames = []string{"radial", "axial"}
+var opposingNames = []string{"allow", "neutral", "last"}
+
+var Input = InputOptions{DeadZone: 0.3, StickMode: STICK_RADIAL}
+
+func SetStickMode(name string) bool {
+	for mode, n := range stickNames {
+		if n == name {
+			Input.StickMode = mode
+			return true
+		}
+	}
+	fmt.Printf("Invalid stick mode: %s (use radial or axial)\n", name)
+	return false
+}
+
+func SetOpposing(name string) bool {
+	for mode, n := range opposingNames {
+		if n == name {
+			Input.Opposing = mode
+			return true
+		}
+	}
+	fmt.Printf("Invalid opposing directions mode: %s (use allow, neutral or last)\n", name)
+	return false
+}
+
+// Directions of a stick at x, y, with both axes in -1.0 - 1.0 and y
+// growing downwards as SDL reports it.
+func stickDirections(x float64, y float64) byte {
+	if Input.StickMode == STICK_AXIAL {
+		return axialDirections(x, y)
+	}
+	if math.Hypot(x, y) < Input.DeadZone {
+		return 0
+	}
+	// Counterclockwise from the right in sectors of 45 degrees, or of 90
+	// with the diagonals left out
+	angle := math.Atan2(-y, x)
+	if Input.FourWay {
+		quadrant := int(math.Floor(angle / (math.Pi / 2) + 0.5)) & 3
+		return []byte{stickRight, stickUp, stickLeft, stickDown}[quadrant]
+	}
+	octant := int(math.Floor(angle / (math.Pi / 4) + 0.5)) & 7
+	return []byte{stickRight, stickRight | stickUp, stickUp, stickUp | stickLeft,
+		stickLeft, stickLeft | stickDown, stickDown, stickDown | stickRight}[octant]
+}
+
+const (
+	stickUp = 1 << ioports.BUTTON_UP
+	stickDown = 1 << ioports.BUTTON_DOWN
+	stickLeft = 1 << ioports.BUTTON_LEFT
+	stickRight = 1 << ioports.BUTTON_RIGHT
+)
+
+func axialDirections(x float64, y float64) byte {
+	if Input.FourWay {
+		// The axis deflected the most wins
+		if math.Abs(x) >= math.Abs(y) {
+			y = 0
+		} else {
+			x = 0
+		}
+	}
+	var held byte = 0
+	if x > Input.DeadZone {
+		held |= stickRight
+	}
+	if x < -Input.DeadZone {
+		held |= stickLeft
+	}
+	if y > Input.DeadZone {
+		held |= stickDown
+	}
+	if y < -Input.DeadZone {
+		held |= stickUp
+	}
+	return held
+}
+
+func stickAxisEvent(g *gamepad, e *sdl.ControllerAxisEvent) {
+	value := float64(e.Value) / 32767
+	switch e.Axis {
+		case sdl.CONTROLLER_AXIS_LEFTX:
+			g.stickX = value
+		case sdl.CONTROLLER_AXIS_LEFTY:
+			g.stickY = value
+		default:
+			return
+	}
+	g.stick = stickDirections(g.stickX, g.stickY)
+}
+
+// Buttons of each controller before blockOpposing, and the direction of
+// each pair pressed last.
+var opposingHeld [2]byte
+var opposingLast [2]byte
+
+// Applies Input.Opposing to the buttons of a controller.
+func blockOpposing(port int, held byte) byte {
+	pressed := held &^ opposingHeld[port]
+	opposingHeld[port] = held
+	for _, pair := range []byte{stickLeft | stickRight, stickUp | stickDown} {
+		// Both pressed by the same event keep the previous choice
+		if p := pressed & pair; p != 0 && p != pair {
+			opposingLast[port] = opposingLast[port] &^ pair | p
+		}
+		if Input.Opposing == OPPOSING_ALLOW || held & pair != pair {
+			continue
+		}
+		held &^= pair
+		if Input.Opposing == OPPOSING_LAST {
+			held |= opposingLast[port] & pair
+		}
+	}
+	return held
+}
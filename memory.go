@@ -0,0 +1,112 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "errors"
+
+// Named memory domains for cheat finders, RAM search and TAS tools. Each
+// domain is a flat block addressed from 0; reads and writes go straight to
+// the memory behind it, without the side effects of the CPU or PPU buses.
+
+type MemoryDomain struct {
+	Name string
+	Size int
+}
+
+var ErrMemoryDomain = errors.New("unknown memory domain")
+var ErrMemoryAddress = errors.New("address outside the memory domain")
+
+type memoryDomain struct {
+	name string
+	memory func(c *Console) []byte
+	address func(addr int) int // Folds mirrored addresses, nil for none
+}
+
+var memoryDomains = []memoryDomain{
+	// The 2 KB of the console, mirrored up to $1FFF on the CPU bus
+	{"CPU RAM", func(c *Console) []byte { return c.cpu.IO.CPU_RAM[:0x800] }, nil},
+	{"PRG ROM", func(c *Console) []byte { return c.Cart.PRG }, nil},
+	// CHR-ROM, or the 8 KB of CHR-RAM of boards without it
+	{"CHR", func(c *Console) []byte {
+		if len(c.Cart.CHR) > 0 {
+			return c.Cart.CHR
+		}
+		return c.cpu.IO.PPU_RAM[:0x2000]
+	}, nil},
+	{"SRAM", SRAM, nil},
+	// Nametable pages, 2 KB on the console plus the ones of the cartridge
+	{"VRAM", func(c *Console) []byte { return c.cpu.IO.NAMETABLE_MEMORY }, nil},
+	{"OAM", func(c *Console) []byte { return c.cpu.IO.PPU_OAM }, nil},
+	// $3F10, $3F14, $3F18 and $3F1C are the same entries as $3F00-$3F0C
+	{"Palette", func(c *Console) []byte { return c.cpu.IO.PPU_RAM[0x3F00:0x3F20] }, func(addr int) int {
+		if addr >= 0x10 && addr % 4 == 0 {
+			return addr - 0x10
+		}
+		return addr
+	}},
+}
+
+// Domains of the console, with the size of each one for the inserted
+// cartridge.
+func MemoryDomains(c *Console) []MemoryDomain {
+	domains := make([]MemoryDomain, len(memoryDomains))
+	for i, d := range memoryDomains {
+		domains[i] = MemoryDomain{Name: d.name, Size: len(d.memory(c))}
+	}
+	return domains
+}
+
+func domainByte(c *Console, domain string, addr int) (*byte, error) {
+	for _, d := range memoryDomains {
+		if d.name != domain {
+			continue
+		}
+		memory := d.memory(c)
+		if addr < 0 || addr >= len(memory) {
+			return nil, ErrMemoryAddress
+		}
+		if d.address != nil {
+			addr = d.address(addr)
+		}
+		return &memory[addr], nil
+	}
+	return nil, ErrMemoryDomain
+}
+
+func ReadMemory(c *Console, domain string, addr int) (byte, error) {
+	b, err := domainByte(c, domain, addr)
+	if err != nil {
+		return 0, err
+	}
+	return *b, nil
+}
+
+// Writes are allowed in every domain, ROM included, so tools can patch it.
+// They are cheats, so hardcore mode refuses them.
+func WriteMemory(c *Console, domain string, addr int, value byte) error {
+	if c.hardcore {
+		return ErrHardcore
+	}
+	b, err := domainByte(c, domain, addr)
+	if err != nil {
+		return err
+	}
+	*b = value
+	return nil
+}
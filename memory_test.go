@@ -0,0 +1,73 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "testing"
+
+func TestMemoryDomainBounds(t *testing.T) {
+	c := StartConsole(testCartridge(t))
+	for _, d := range MemoryDomains(c) {
+		if _, err := ReadMemory(c, d.Name, d.Size - 1); err != nil {
+			t.Errorf("%s: last byte gave %v", d.Name, err)
+		}
+		if _, err := ReadMemory(c, d.Name, d.Size); err != ErrMemoryAddress {
+			t.Errorf("%s: byte past the end gave %v", d.Name, err)
+		}
+		if err := WriteMemory(c, d.Name, -1, 0); err != ErrMemoryAddress {
+			t.Errorf("%s: negative address gave %v", d.Name, err)
+		}
+	}
+	if _, err := ReadMemory(c, "WRAM", 0); err != ErrMemoryDomain {
+		t.Errorf("unknown domain gave %v", err)
+	}
+
+	// The palette mirrors of the backdrop are the same byte
+	WriteMemory(c, "Palette", 0x10, 0x2A)
+	if v, _ := ReadMemory(c, "Palette", 0x00); v != 0x2A {
+		t.Errorf("$3F10 did not write $3F00, it reads %02X", v)
+	}
+}
+
+// ROM is patched in place, the CPU sees the new byte.
+func TestMemoryWriteROM(t *testing.T) {
+	c := StartConsole(testCartridge(t))
+	if err := WriteMemory(c, "PRG ROM", 1, 0x40); err != nil {
+		t.Fatal(err)
+	}
+	if c.Cart.PRG[1] != 0x40 {
+		t.Errorf("PRG ROM byte 1 is %02X after the write", c.Cart.PRG[1])
+	}
+}
+
+func TestMemoryWriteHardcore(t *testing.T) {
+	c := StartConsole(testCartridge(t))
+	SetHardcore(c, true)
+	if err := WriteMemory(c, "CPU RAM", 0x10, 0x99); err != ErrHardcore {
+		t.Errorf("write in hardcore mode gave %v", err)
+	}
+	if err := WriteMemory(c, "PRG ROM", 0, 0xEA); err != ErrHardcore {
+		t.Errorf("ROM write in hardcore mode gave %v", err)
+	}
+	if c.cpu.IO.CPU_RAM[0x10] == 0x99 || c.Cart.PRG[0] == 0xEA {
+		t.Error("hardcore mode let the write through")
+	}
+	if _, err := ReadMemory(c, "CPU RAM", 0x10); err != nil {
+		t.Errorf("read in hardcore mode gave %v", err)
+	}
+}
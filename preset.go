@@ -0,0 +1,73 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "strings"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+
+// Accuracy presets. Each one sets a group of options that trade speed for
+// accuracy, so the options can be chosen together with a single name.
+type Preset struct {
+	Name string
+	SpriteLimit bool // 8 sprites per scanline, the others flicker like on the console
+	AudioDecimation bool // Mix once per sample instead of every CPU cycle
+	AudioQuality int // Resampling, apu.QUALITY_LINEAR or apu.QUALITY_SINC
+	ThreadedPPU bool // Draw each frame on another core, one frame late
+}
+
+var Presets = []Preset{
+	{Name: "performance", SpriteLimit: false, AudioDecimation: true, AudioQuality: apu.QUALITY_LINEAR, ThreadedPPU: true},
+	{Name: "balanced", SpriteLimit: false, AudioDecimation: false, AudioQuality: apu.QUALITY_LINEAR, ThreadedPPU: false},
+	{Name: "accuracy", SpriteLimit: true, AudioDecimation: false, AudioQuality: apu.QUALITY_SINC, ThreadedPPU: false},
+}
+
+// Preset of a name like "accuracy". Returns false for unknown names.
+func ParsePreset(name string) (Preset, bool) {
+	for _, p := range Presets {
+		if p.Name == strings.ToLower(name) {
+			return p, true
+		}
+	}
+	return Presets[1], false
+}
+
+// Options in use, named after the preset they match or "custom".
+func CurrentPreset(c *Console) Preset {
+	current := Preset{Name: "custom", SpriteLimit: ppu.SpriteLimit, AudioDecimation: c.cpu.IO.APU.Mixer.Decimate,
+		AudioQuality: c.cpu.IO.APU.Mixer.Quality, ThreadedPPU: ppu.ThreadedRender}
+	for _, p := range Presets {
+		current.Name = p.Name
+		if p == current {
+			return p
+		}
+	}
+	current.Name = "custom"
+	return current
+}
+
+// Sets the options of a preset. Options given one by one afterwards still
+// override it.
+func ApplyPreset(c *Console, p Preset) {
+	ppu.SpriteLimit = p.SpriteLimit
+	ppu.SetThreadedRender(p.ThreadedPPU)
+	c.cpu.IO.APU.Mixer.Decimate = p.AudioDecimation
+	c.cpu.IO.APU.Mixer.Quality = p.AudioQuality
+}
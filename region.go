@@ -0,0 +1,190 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "bufio"
+import "fmt"
+import "os"
+import "path/filepath"
+import "strconv"
+import "strings"
+import "time"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/cartridge"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+
+// Console region: the length of the frame, the speed of the PPU against
+// the CPU and the timing of the APU.
+type Region int
+
+const (
+	RegionNTSC Region = apu.REGION_NTSC
+	RegionPAL Region = apu.REGION_PAL
+	RegionDendy Region = apu.REGION_DENDY
+)
+
+type regionTiming struct {
+	VBlankLine int
+	PreRenderLine int
+	PPUDots int // PPU dots every 5 CPU cycles
+	FrameRate float64
+	WarmUp uint64 // CPU cycles until the first pre-render line ends
+	CPUClock float64 // CPU cycles per second
+}
+
+var regionTimings = [3]regionTiming{
+	{VBlankLine: 241, PreRenderLine: 261, PPUDots: 15, FrameRate: 60.0988, WarmUp: 29658, CPUClock: 1789773},
+	{VBlankLine: 241, PreRenderLine: 311, PPUDots: 16, FrameRate: 50.0070, WarmUp: 33132, CPUClock: 1662607},
+	{VBlankLine: 291, PreRenderLine: 311, PPUDots: 15, FrameRate: 50.0070, WarmUp: 35341, CPUClock: 1773448},
+}
+
+// Switches the console to another region. Call it before the game runs,
+// the frame in progress is not adjusted.
+func SetRegion(c *Console, region Region) {
+	t := regionTimings[region]
+	c.Region = region
+	c.ppu.VBLANK_LINE = t.VBlankLine
+	c.ppu.PRERENDER_LINE = t.PreRenderLine
+	c.ppuDots = t.PPUDots
+	c.cpu.IO.PPU_WARMUP = 0
+	if c.PPUWarmUp {
+		c.cpu.IO.PPU_WARMUP = t.WarmUp
+	}
+	apu.SetRegion(&c.cpu.IO.APU, int(region))
+	ppu.SetPALColors(region == RegionPAL)
+}
+
+// Turns the PPU warm-up on or off. While it lasts, after power up, writes
+// to $2000, $2001, $2005 and $2006 are ignored like on the console; homebrew
+// in development may rather not wait for it.
+func SetPPUWarmUp(c *Console, enable bool) {
+	c.PPUWarmUp = enable
+	SetRegion(c, c.Region)
+}
+
+// Emulates the decay of the OAM left without refresh for longer than d,
+// see ioports.RefreshOAM; 0 turns it off. About 1.5 ms is typical of real
+// consoles. The time is converted with the CPU clock of the current
+// region, set the region first.
+func SetOAMDecay(c *Console, d time.Duration) {
+	c.cpu.IO.OAM_DECAY = uint64(d.Seconds() * regionTimings[c.Region].CPUClock)
+}
+
+// Frames per second of the console region.
+func FrameRate(c *Console) float64 {
+	return regionTimings[c.Region].FrameRate
+}
+
+func RegionName(region Region) string {
+	return apu.Timings[region].Name
+}
+
+// Region of a name like "pal". Returns false for unknown names.
+func ParseRegion(name string) (Region, bool) {
+	for region, t := range apu.Timings {
+		if t.Name == strings.ToLower(name) {
+			return Region(region), true
+		}
+	}
+	return RegionNTSC, false
+}
+
+// Filename tags of GoodNES and No-Intro sets.
+var regionTags = map[string]Region{
+	"u": RegionNTSC, "usa": RegionNTSC, "j": RegionNTSC, "japan": RegionNTSC, "ntsc": RegionNTSC,
+	"e": RegionPAL, "europe": RegionPAL, "eu": RegionPAL, "pal": RegionPAL, "australia": RegionPAL,
+	"g": RegionPAL, "germany": RegionPAL, "f": RegionPAL, "france": RegionPAL, "spain": RegionPAL,
+	"italy": RegionPAL, "sweden": RegionPAL, "uk": RegionPAL,
+	"dendy": RegionDendy, "russia": RegionDendy,
+}
+
+// Regions of known games by the CRC32 of their PRG-ROM, see
+// LoadRegionDatabase.
+var regionDatabase = make(map[uint32]Region)
+
+// Reads a ROM database of "crc32 region" lines, like "5B4C6146 pal". Lines
+// starting with # are comments.
+func LoadRegionDatabase(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("%s:%d: expected crc32 and region", path, n)
+		}
+		crc, err := strconv.ParseUint(fields[0], 16, 32)
+		if err != nil {
+			return fmt.Errorf("%s:%d: invalid crc32 %s", path, n, fields[0])
+		}
+		region, valid := ParseRegion(fields[1])
+		if valid == false {
+			return fmt.Errorf("%s:%d: unknown region %s", path, n, fields[1])
+		}
+		regionDatabase[uint32(crc)] = region
+	}
+	return scanner.Err()
+}
+
+// Guesses the region of a game from the ROM database, then from the NES 2.0
+// timing byte, then from the tags of the file name like "(E)" or
+// "(Europe)". Returns false when nothing tells.
+func DetectRegion(cart *cartridge.Cartridge, filename string) (Region, bool) {
+	if region, found := regionDatabase[cartridge.Info(cart).PRGCRC32]; found {
+		return region, true
+	}
+
+	h := &cart.Header
+	if h.ROM_TYPE2 & 0x0C == 0x08 {
+		switch(h.ROM_BLANK[4] & 3) {
+			case 0:
+				return RegionNTSC, true
+			case 1:
+				return RegionPAL, true
+			case 3:
+				return RegionDendy, true
+		}
+	}
+
+	name := strings.ToLower(filepath.Base(filename))
+	for {
+		start := strings.Index(name, "(")
+		end := strings.Index(name, ")")
+		if start < 0 || end < start {
+			return RegionNTSC, false
+		}
+		for _, tag := range strings.Split(name[start+1:end], ",") {
+			if region, found := regionTags[strings.TrimSpace(tag)]; found {
+				return region, true
+			}
+		}
+		name = name[end+1:]
+	}
+}
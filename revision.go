@@ -0,0 +1,89 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "strings"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+
+// Console revision: the model of the console and the revision of its
+// 2A03, for the small differences a few games and test ROMs show. The
+// region is set apart, see SetRegion.
+type Revision int
+
+const (
+	RevisionFrontLoader Revision = iota // NES-001, RP2A03G
+	RevisionTopLoader // NES-101, RP2A03H
+	RevisionFamicom // HVC-001, RP2A03G
+	RevisionFamicomEarly // HVC-001 of 1983, letterless RP2A03
+	RevisionAVFamicom // HVC-101, RP2A03H
+)
+
+// The front-loader brings the audio of the cartridge to its expansion port,
+// mixed in by the usual modification; the top-loader has no way to. The
+// Famicom has its controllers wired in, the second one with the microphone
+// and without Select and Start; the AV Famicom takes NES controllers.
+type revisionTraits struct {
+	Name string
+	Chip string
+	ShortNoise bool
+	ExpansionAudio bool
+	Ports ioports.PORT_WIRING
+}
+
+var revisions = [...]revisionTraits{
+	{Name: "front-loader", Chip: "RP2A03G", ShortNoise: true, ExpansionAudio: true},
+	{Name: "top-loader", Chip: "RP2A03H", ShortNoise: true, ExpansionAudio: false},
+	{Name: "famicom", Chip: "RP2A03G", ShortNoise: true, ExpansionAudio: true,
+		Ports: ioports.PORT_WIRING{MICROPHONE: true, FAMICOM_PAD2: true}},
+	{Name: "famicom-early", Chip: "RP2A03", ShortNoise: false, ExpansionAudio: true,
+		Ports: ioports.PORT_WIRING{MICROPHONE: true, FAMICOM_PAD2: true}},
+	{Name: "av-famicom", Chip: "RP2A03H", ShortNoise: true, ExpansionAudio: true},
+}
+
+// Switches the console to another revision. It can be changed while the
+// game runs. The expansion audio switch is shared by every console, like
+// the expansion volume.
+func SetRevision(c *Console, revision Revision) {
+	t := revisions[revision]
+	c.Revision = revision
+	apu.SetShortNoise(&c.cpu.IO.APU, t.ShortNoise)
+	apu.ExpansionOutput = t.ExpansionAudio
+	c.cpu.IO.WIRING = t.Ports
+}
+
+func RevisionName(revision Revision) string {
+	return revisions[revision].Name
+}
+
+// Name of the 2A03 revision of the console.
+func RevisionChip(revision Revision) string {
+	return revisions[revision].Chip
+}
+
+// Revision of a name like "top-loader". Returns false for unknown names.
+func ParseRevision(name string) (Revision, bool) {
+	for revision, t := range revisions {
+		if t.Name == strings.ToLower(name) {
+			return Revision(revision), true
+		}
+	}
+	return RevisionFrontLoader, false
+}
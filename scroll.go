@@ -0,0 +1,48 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+// Scroll of one visible scanline, decoded from the PPU scroll address the
+// line started with. Comparing the lines of a frame shows where a game
+// splits the screen and on which line the split lands.
+type ScrollLine struct {
+	CoarseX int `json:"coarse_x"` // Tile column, 0-31
+	FineX int `json:"fine_x"` // Pixel inside the tile, 0-7
+	CoarseY int `json:"coarse_y"` // Tile row, 0-29 (30 and 31 read the attribute table)
+	FineY int `json:"fine_y"`
+	Nametable int `json:"nametable"` // 0-3, as selected by $2000
+	X int `json:"x"` // Position in the 512x480 plane of the four nametables
+	Y int `json:"y"`
+}
+
+// Scroll of each visible scanline of the last frame.
+func Scroll(c *Console) [240]ScrollLine {
+	var lines [240]ScrollLine
+	for i, l := range c.ppu.SCROLL_LINES {
+		s := &lines[i]
+		s.CoarseX = int(l.V & 0x1F)
+		s.FineX = int(l.FINE_X)
+		s.CoarseY = int((l.V >> 5) & 0x1F)
+		s.FineY = int((l.V >> 12) & 0x07)
+		s.Nametable = int((l.V >> 10) & 0x03)
+		s.X = s.CoarseX*8 + s.FineX + (s.Nametable & 1)*256
+		s.Y = s.CoarseY*8 + s.FineY + (s.Nametable >> 1)*240
+	}
+	return lines
+}
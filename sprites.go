@@ -0,0 +1,55 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+// Sprites the PPU found for one visible scanline. The console keeps the
+// first 8 in the secondary OAM and drops the others, so a line with Count
+// over 8 is where sprites go missing or a game makes them flicker.
+type SpriteLine struct {
+	Count int `json:"count"` // Sprites on the line, past the limit of 8 too
+	Sprites []Sprite `json:"sprites"` // Contents of the secondary OAM
+}
+
+type Sprite struct {
+	Slot int `json:"slot"` // Index in the OAM, 0-63 (0-511 with the OAM extension)
+	Y int `json:"y"`
+	Tile int `json:"tile"`
+	Attributes int `json:"attributes"`
+	X int `json:"x"`
+}
+
+// Sprites of each visible scanline of the last frame.
+func Sprites(c *Console) [240]SpriteLine {
+	var lines [240]SpriteLine
+	a := &c.cpu.IO.ACTIVITY
+	for y := range lines {
+		l := &lines[y]
+		l.Count = int(a.SPRITES_PER_SCANLINE[y])
+		n := l.Count
+		if n > 8 {
+			n = 8
+		}
+		l.Sprites = make([]Sprite, n)
+		for i := 0; i < n; i++ {
+			oam := a.SECONDARY_OAM[y][i*4:i*4+4]
+			l.Sprites[i] = Sprite{a.SECONDARY_SLOTS[y][i], int(oam[0]), int(oam[1]), int(oam[2]), int(oam[3])}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/apu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/cpu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+
+// Snapshot of the console. The exported fields are the snapshot's own copy
+// of the memory; changing them changes what LoadState restores.
+type State struct {
+	A byte
+	X byte
+	Y byte
+	P byte
+	SP byte
+	PC uint16
+	Clock ioports.MASTER_CLOCK
+	RAM []byte // CPU address space backing memory
+	VRAM []byte // PPU address space backing memory
+	OAM []byte
+
+	cpu cpu.CPU
+	ppu ppu.PPU
+	ports ioports.IOPorts
+	ppuDelay int
+	dotCredit int
+}
+
+// Copies src into dst, reusing dst when it has the same size.
+func copyBytes(dst []byte, src []byte) []byte {
+	if len(dst) != len(src) {
+		dst = make([]byte, len(src))
+	}
+	copy(dst, src)
+	return dst
+}
+
+func SaveState(c *Console) State {
+	var s State
+	SaveStateInto(c, &s)
+	return s
+}
+
+// Like SaveState, but reuses the memory of a previous snapshot, so keeping
+// a ring of states for rewind does not allocate.
+func SaveStateInto(c *Console, s *State) {
+
+	ram := s.ports.CPU_RAM
+	vram := s.ports.PPU_RAM
+	oam := s.ports.PPU_OAM
+	nametables := s.ports.NAMETABLE_MEMORY
+
+	s.cpu = c.cpu
+	s.cpu.IO = ioports.IOPorts{}
+	s.cpu.D = debug.Debug{}
+
+	s.ports = c.cpu.IO
+	s.ports.CPU_RAM = copyBytes(ram, c.cpu.IO.CPU_RAM)
+	s.ports.PPU_RAM = copyBytes(vram, c.cpu.IO.PPU_RAM)
+	s.ports.PPU_OAM = copyBytes(oam, c.cpu.IO.PPU_OAM)
+	s.ports.NAMETABLE_MEMORY = copyBytes(nametables, c.cpu.IO.NAMETABLE_MEMORY)
+	s.ports.CLOCK = nil
+	s.ports.ACCESS_LOG = debug.AccessLog{}
+	s.ports.COMPAT = ioports.COMPAT_LOG{}
+	s.ports.PPU_CAPTURE = nil
+	s.ports.MIRRORING_CHANGES = nil
+	s.ports.APU.Mixer.Samples = nil
+	s.ports.APU.Mixer.StemSamples = [apu.CHANNELS][]int16{}
+	s.ports.APU.Log = apu.RegisterLog{}
+
+	s.ppu = c.ppu
+	s.ppu.IO = nil
+	s.ppu.D = nil
+
+	s.ppuDelay = c.ppuDelay
+	s.dotCredit = c.dotCredit
+	s.Clock = c.clock
+
+	s.A, s.X, s.Y, s.P, s.SP, s.PC = c.cpu.A, c.cpu.X, c.cpu.Y, c.cpu.P, c.cpu.SP, c.cpu.PC
+	s.RAM = s.ports.CPU_RAM
+	s.VRAM = s.ports.PPU_RAM
+	s.OAM = s.ports.PPU_OAM
+}
+
+// Restores a snapshot taken from a console running the same cartridge.
+// Refused in hardcore mode.
+func LoadState(c *Console, s State) error {
+	if c.hardcore {
+		return ErrHardcore
+	}
+
+	debugger := c.cpu.D
+	accesslog := c.cpu.IO.ACCESS_LOG
+	compat := c.cpu.IO.COMPAT
+	changes := c.cpu.IO.MIRRORING_CHANGES[:0]
+	mixer := c.cpu.IO.APU.Mixer
+	writelog := c.cpu.IO.APU.Log
+	// The output buffers stay with the console, the threaded renderer may
+	// own the ones the snapshot refers to
+	screen := c.ppu.SCREEN_DATA
+	sprites := c.ppu.SPRITE_LAYER
+	previous := c.ppu.PREVIOUS_SPRITE_LAYER
+	sources := c.ppu.SOURCE_LAYER
+
+	c.cpu = s.cpu
+	c.cpu.D = debugger
+	ram := c.cpu.IO.CPU_RAM
+	vram := c.cpu.IO.PPU_RAM
+	oam := c.cpu.IO.PPU_OAM
+	nametables := c.cpu.IO.NAMETABLE_MEMORY
+	c.cpu.IO = s.ports
+	c.cpu.IO.CPU_RAM = copyBytes(ram, s.ports.CPU_RAM)
+	c.cpu.IO.PPU_RAM = copyBytes(vram, s.ports.PPU_RAM)
+	c.cpu.IO.PPU_OAM = copyBytes(oam, s.ports.PPU_OAM)
+	c.cpu.IO.NAMETABLE_MEMORY = copyBytes(nametables, s.ports.NAMETABLE_MEMORY)
+	c.cpu.IO.CART = c.Cart
+	c.cpu.IO.CLOCK = &c.clock
+	c.cpu.IO.ACCESS_LOG = accesslog
+	c.cpu.IO.COMPAT = compat
+	c.cpu.IO.MIRRORING_CHANGES = changes
+	// The phase of the mixer comes from the state, the output from the console
+	c.cpu.IO.APU.Mixer.SampleRate = mixer.SampleRate
+	c.cpu.IO.APU.Mixer.Step = mixer.Step
+	c.cpu.IO.APU.Mixer.Stems = mixer.Stems
+	c.cpu.IO.APU.Mixer.Samples = mixer.Samples
+	c.cpu.IO.APU.Mixer.StemSamples = mixer.StemSamples
+	c.cpu.IO.APU.Log = writelog
+	SetRevision(c, c.Revision)
+
+	c.ppu = s.ppu
+	c.ppu.IO = &c.cpu.IO
+	c.ppu.D = &c.ppuDebug
+	c.ppu.SCREEN_DATA = screen
+	c.ppu.SPRITE_LAYER = sprites
+	c.ppu.PREVIOUS_SPRITE_LAYER = previous
+	c.ppu.SOURCE_LAYER = sources
+
+	c.clock = s.Clock
+	c.ppuDelay = s.ppuDelay
+	c.dotCredit = s.dotCredit
+	return nil
+}
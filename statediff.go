@@ -0,0 +1,192 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "fmt"
+import "reflect"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/debug"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+
+// Field by field comparison of two savestates, to find what a load does
+// not restore. Both states are loaded into the console in turn, so only
+// what they restore can differ, and the console is put back afterwards.
+
+// Field that differs between two savestates. Blocks of memory are reported
+// as one entry per run of differing bytes.
+type StateDiff struct {
+	Field string // Subsystem and field, like "cpu.PC" or "ioports.PPUCTRL.GEN_NMI"
+	A string
+	B string
+}
+
+type stateField struct {
+	Name string
+	Value reflect.Value
+}
+
+// Arrays and slices longer than this are compared as memory.
+const diffMemoryLength = 16
+
+func DiffStates(c *Console, a []byte, b []byte) ([]StateDiff, error) {
+	saved := SaveState(c)
+	defer LoadState(c, saved)
+
+	if err := ReadState(c, a); err != nil {
+		return nil, fmt.Errorf("first savestate: %w", err)
+	}
+	before := stateFields(c)
+	if err := ReadState(c, b); err != nil {
+		return nil, fmt.Errorf("second savestate: %w", err)
+	}
+	after := stateFields(c)
+
+	var diffs []StateDiff
+	for i := range before {
+		diffs = append(diffs, diffValues(before[i].Name, before[i].Value, after[i].Value)...)
+	}
+	return diffs, nil
+}
+
+// Snapshot of the fields of the console, in a fixed order. The values are
+// copies, the console can change afterwards.
+func stateFields(c *Console) []stateField {
+	processor := c.cpu
+	processor.IO = ioports.IOPorts{}
+	processor.D = debug.Debug{}
+	ports := c.cpu.IO
+	ports.CART = nil
+	ports.CLOCK = nil
+	ports.CPU_RAM = append([]byte(nil), ports.CPU_RAM...)
+	ports.PPU_RAM = append([]byte(nil), ports.PPU_RAM...)
+	ports.PPU_OAM = append([]byte(nil), ports.PPU_OAM...)
+	ports.NAMETABLE_MEMORY = append([]byte(nil), ports.NAMETABLE_MEMORY...)
+	video := c.ppu
+	video.IO = nil
+	video.D = nil
+	// The frame buffers are drawn from the state, they are not part of it
+	video.SCREEN_DATA, video.SPRITE_LAYER, video.PREVIOUS_SPRITE_LAYER, video.SOURCE_LAYER = nil, nil, nil, nil
+	console := struct {
+		Clock ioports.MASTER_CLOCK
+		PPUDelay int
+		DotCredit int
+	}{c.clock, c.ppuDelay, c.dotCredit}
+
+	return []stateField{
+		{"console", reflect.ValueOf(console)},
+		{"cpu", reflect.ValueOf(processor)},
+		{"ioports", reflect.ValueOf(ports)},
+		{"ppu", reflect.ValueOf(video)},
+	}
+}
+
+func diffValues(name string, a reflect.Value, b reflect.Value) []StateDiff {
+	switch a.Kind() {
+		case reflect.Struct:
+			var diffs []StateDiff
+			for i := 0; i < a.NumField(); i++ {
+				diffs = append(diffs, diffValues(name + "." + a.Type().Field(i).Name, a.Field(i), b.Field(i))...)
+			}
+			return diffs
+
+		case reflect.Array, reflect.Slice:
+			if a.Len() != b.Len() {
+				return []StateDiff{{name + ".len", fmt.Sprint(a.Len()), fmt.Sprint(b.Len())}}
+			}
+			if a.Len() > diffMemoryLength && isNumber(a.Type().Elem().Kind()) {
+				return diffMemory(name, a, b)
+			}
+			var diffs []StateDiff
+			for i := 0; i < a.Len(); i++ {
+				diffs = append(diffs, diffValues(fmt.Sprintf("%s[%d]", name, i), a.Index(i), b.Index(i))...)
+			}
+			return diffs
+
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Func, reflect.Chan:
+			return nil
+	}
+
+	va, vb := formatValue(a), formatValue(b)
+	if va == vb {
+		return nil
+	}
+	return []StateDiff{{name, va, vb}}
+}
+
+func isNumber(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uint64) || k == reflect.Float32 || k == reflect.Float64
+}
+
+// Unexported fields can be read through reflect but not turned back into
+// interfaces, so the basic kinds are formatted by hand.
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+		case reflect.Bool:
+			return fmt.Sprint(v.Bool())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return fmt.Sprint(v.Int())
+		case reflect.Uint8:
+			return fmt.Sprintf("$%02X", v.Uint())
+		case reflect.Uint16:
+			return fmt.Sprintf("$%04X", v.Uint())
+		case reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return fmt.Sprint(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			return fmt.Sprint(v.Float())
+		case reflect.String:
+			return fmt.Sprintf("%q", v.String())
+	}
+	return v.Kind().String()
+}
+
+// One entry per run of differing elements, with the elements of each side.
+// Runs longer than 8 show their first elements.
+func diffMemory(name string, a reflect.Value, b reflect.Value) []StateDiff {
+	var diffs []StateDiff
+	for i := 0; i < a.Len(); {
+		if formatValue(a.Index(i)) == formatValue(b.Index(i)) {
+			i++
+			continue
+		}
+		start := i
+		for i < a.Len() && formatValue(a.Index(i)) != formatValue(b.Index(i)) {
+			i++
+		}
+		field := fmt.Sprintf("%s[$%04X]", name, start)
+		if i - start > 1 {
+			field = fmt.Sprintf("%s[$%04X-$%04X]", name, start, i-1)
+		}
+		diffs = append(diffs, StateDiff{field, memoryRun(a, start, i), memoryRun(b, start, i)})
+	}
+	return diffs
+}
+
+func memoryRun(v reflect.Value, start int, end int) string {
+	s := ""
+	for i := start; i < end && i < start+8; i++ {
+		if i > start {
+			s += " "
+		}
+		s += formatValue(v.Index(i))
+	}
+	if end - start > 8 {
+		s += " ..."
+	}
+	return s
+}
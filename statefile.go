@@ -0,0 +1,139 @@
+/*
+Copyright 2014, 2015 Jonathan da Silva SAntos
+
+This file is part of Alphanes.
+
+    Alphanes is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Alphanes is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with Alphanes.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package alphanes
+
+import "errors"
+
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/cpu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ioports"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/ppu"
+import "github.com/jonathandasilvasantos/2014-alphanes-nintendo-emulator/internal/snapshot"
+
+// Binary savestates. The console is encoded field by field straight into
+// the caller's buffer, so a snapshot is a ~130KB memory copy and does not
+// allocate once the buffer is big enough. That keeps rewind and run-ahead
+// affordable every frame.
+
+const stateMagic = "ANST"
+const stateVersion = 17
+
+var ErrStateFormat = errors.New("not an Alphanes savestate")
+var ErrStateVersion = errors.New("unsupported savestate version")
+var ErrStateCartridge = errors.New("the savestate belongs to another cartridge")
+
+// Appends the encoded console to buf and returns it. Pass buf[:0] of the
+// previous call to reuse its memory.
+func WriteState(c *Console, buf []byte) []byte {
+	e := snapshot.Encoder{Buf: buf}
+	e.Buf = append(e.Buf, stateMagic...)
+	snapshot.PutByte(&e, stateVersion)
+	snapshot.PutString(&e, c.Cart.Hash)
+
+	snapshot.PutUint64(&e, c.clock.CPU_CYCLES)
+	snapshot.PutInt(&e, c.clock.SCANLINE)
+	snapshot.PutInt(&e, c.clock.DOT)
+	snapshot.PutInt(&e, c.ppuDelay)
+	snapshot.PutInt(&e, c.dotCredit)
+	cpu.EncodeState(&c.cpu, &e)
+	ioports.EncodeState(&c.cpu.IO, &e)
+	ppu.EncodeState(&c.ppu, &e)
+	return e.Buf
+}
+
+// Restores a console encoded by WriteState. The console is left untouched
+// when the data is not a savestate of the inserted cartridge, and in
+// hardcore mode.
+func ReadState(c *Console, data []byte) error {
+	if c.hardcore {
+		return ErrHardcore
+	}
+	if len(data) < len(stateMagic) + 1 || string(data[:len(stateMagic)]) != stateMagic {
+		return ErrStateFormat
+	}
+	d := snapshot.StartDecoder(data)
+	d.Pos = len(stateMagic)
+	if snapshot.Byte(&d) != stateVersion {
+		return ErrStateVersion
+	}
+	hash := snapshot.Bytes(&d, nil)
+	if d.Err != nil {
+		return d.Err
+	}
+	if string(hash) != c.Cart.Hash {
+		return ErrStateCartridge
+	}
+
+	// Decode into copies so a truncated state does not leave a half
+	// restored console behind
+	clock := c.clock
+	clock.CPU_CYCLES = snapshot.Uint64(&d)
+	clock.SCANLINE = snapshot.Int(&d)
+	clock.DOT = snapshot.Int(&d)
+	delay := snapshot.Int(&d)
+	credit := snapshot.Int(&d)
+	if credit < 0 || credit >= 5 {
+		credit = 0
+	}
+	processor := c.cpu
+	cpu.DecodeState(&processor, &d)
+	ports := c.cpu.IO
+	ports.CPU_RAM = scratch(&stateScratch[0], len(ports.CPU_RAM))
+	ports.PPU_RAM = scratch(&stateScratch[1], len(ports.PPU_RAM))
+	ports.PPU_OAM = scratch(&stateScratch[2], len(ports.PPU_OAM))
+	ports.NAMETABLE_MEMORY = scratch(&stateScratch[3], len(ports.NAMETABLE_MEMORY))
+	ioports.DecodeState(&ports, &d)
+	video := c.ppu
+	ppu.DecodeState(&video, &d)
+	snapshot.Finish(&d)
+	if d.Err != nil {
+		return d.Err
+	}
+
+	// The memory decoded into the scratch buffers becomes the console's and
+	// the console's memory becomes the scratch of the next load
+	stateScratch[0], c.cpu.IO.CPU_RAM = c.cpu.IO.CPU_RAM, ports.CPU_RAM
+	stateScratch[1], c.cpu.IO.PPU_RAM = c.cpu.IO.PPU_RAM, ports.PPU_RAM
+	stateScratch[2], c.cpu.IO.PPU_OAM = c.cpu.IO.PPU_OAM, ports.PPU_OAM
+	stateScratch[3], c.cpu.IO.NAMETABLE_MEMORY = c.cpu.IO.NAMETABLE_MEMORY, ports.NAMETABLE_MEMORY
+
+	ports.CPU_RAM = c.cpu.IO.CPU_RAM
+	ports.PPU_RAM = c.cpu.IO.PPU_RAM
+	ports.PPU_OAM = c.cpu.IO.PPU_OAM
+	ports.NAMETABLE_MEMORY = c.cpu.IO.NAMETABLE_MEMORY
+	processor.IO = ports
+	c.cpu = processor
+	c.ppu.CYC, c.ppu.SCANLINE = video.CYC, video.SCANLINE
+	c.ppu.ATTR, c.ppu.HIGH_TILE, c.ppu.LOW_TILE = video.ATTR, video.HIGH_TILE, video.LOW_TILE
+	c.ppu.VISIBLE_SCANLINE = video.VISIBLE_SCANLINE
+	c.ppu.A12_RISES = video.A12_RISES
+	c.clock = clock
+	c.ppuDelay = delay
+	c.dotCredit = credit
+	return nil
+}
+
+// Spare memory ReadState decodes into.
+var stateScratch [4][]byte
+
+func scratch(b *[]byte, size int) []byte {
+	if len(*b) != size {
+		*b = make([]byte, size)
+	}
+	return *b
+}